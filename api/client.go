@@ -0,0 +1,303 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"expvar"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+)
+
+//ErrCircuitOpen is returned without calling the upstream while its circuit breaker is open
+var ErrCircuitOpen = errors.New("circuit breaker is open")
+
+//upstreamMetrics holds the metrics of every upstream by name, published on /metrics
+var upstreamMetrics = expvar.NewMap("upstreams")
+
+//States of the circuit breaker
+const (
+	CircuitClosed   = "closed"
+	CircuitOpen     = "open"
+	CircuitHalfOpen = "halfOpen"
+)
+
+//ClientConfig holds the settings of the calls to one upstream. Only idempotent GET requests are retried.
+//The circuit breaker opens after FailureThreshold consecutive failures, and lets a trial request through after OpenTimeout
+type ClientConfig struct {
+	Timeout          time.Duration
+	MaxRetries       int
+	BaseBackoff      time.Duration
+	MaxBackoff       time.Duration
+	FailureThreshold int
+	OpenTimeout      time.Duration
+}
+
+//DefaultClientConfig returns the settings used for the values not set in the environment
+func DefaultClientConfig() ClientConfig {
+	return ClientConfig{
+		Timeout:          5 * time.Second,
+		MaxRetries:       2,
+		BaseBackoff:      200 * time.Millisecond,
+		MaxBackoff:       2 * time.Second,
+		FailureThreshold: 5,
+		OpenTimeout:      30 * time.Second,
+	}
+}
+
+//LoadClientConfig reads the settings of an upstream from the environment variables with the prefix,
+//e.g. STOCK_SCREENER_TIMEOUT, STOCK_SCREENER_RETRIES, STOCK_SCREENER_BREAKER_THRESHOLD and STOCK_SCREENER_BREAKER_TIMEOUT
+func LoadClientConfig(prefix string) (ClientConfig, error) {
+	config := DefaultClientConfig()
+
+	durations := map[string]*time.Duration{
+		prefix + "_TIMEOUT":         &config.Timeout,
+		prefix + "_BREAKER_TIMEOUT": &config.OpenTimeout,
+	}
+
+	for name, value := range durations {
+		if env := os.Getenv(name); env != "" {
+			d, err := time.ParseDuration(env)
+
+			if err != nil || d <= 0 {
+				return config, fmt.Errorf("Invalid duration [%s] in [%s]", env, name)
+			}
+
+			*value = d
+		}
+	}
+
+	numbers := map[string]*int{
+		prefix + "_RETRIES":           &config.MaxRetries,
+		prefix + "_BREAKER_THRESHOLD": &config.FailureThreshold,
+	}
+
+	for name, value := range numbers {
+		if env := os.Getenv(name); env != "" {
+			n, err := strconv.Atoi(env)
+
+			if err != nil || n < 0 {
+				return config, fmt.Errorf("Invalid number [%s] in [%s]", env, name)
+			}
+
+			*value = n
+		}
+	}
+
+	return config, nil
+}
+
+//Client calls one upstream with timeouts, retries with jittered exponential backoff and a circuit breaker
+type Client struct {
+	name    string
+	config  ClientConfig
+	http    *http.Client
+	breaker *circuitBreaker
+	metrics *expvar.Map
+	sleep   func(ctx context.Context, d time.Duration) error
+}
+
+//NewClient creates the client of the named upstream, and publishes its metrics under that name
+func NewClient(name string, config ClientConfig) *Client {
+	breaker := &circuitBreaker{threshold: config.FailureThreshold, timeout: config.OpenTimeout, now: time.Now}
+
+	metrics := new(expvar.Map).Init()
+	metrics.Set("state", expvar.Func(func() interface{} { return breaker.current() }))
+	upstreamMetrics.Set(name, metrics)
+
+	return &Client{
+		name:    name,
+		config:  config,
+		http:    &http.Client{Timeout: config.Timeout},
+		breaker: breaker,
+		metrics: metrics,
+		sleep:   sleepContext,
+	}
+}
+
+//Do sends the request. GET requests are retried on network errors, 429 and 5xx responses.
+//The response of the last attempt is returned, the caller has to close its body
+func (c *Client) Do(ctx context.Context, method string, url string, body []byte) (*http.Response, error) {
+	attempts := 1
+	if method == http.MethodGet {
+		attempts += c.config.MaxRetries
+	}
+
+	var lastErr error
+
+	for attempt := 0; attempt < attempts; attempt++ {
+		if attempt > 0 {
+			c.metrics.Add("retries", 1)
+
+			if err := c.sleep(ctx, c.backoff(attempt)); err != nil {
+				return nil, fmt.Errorf("Request to [%s] cancelled: [%v]", c.name, err)
+			}
+		}
+
+		if !c.breaker.allow() {
+			c.metrics.Add("rejected", 1)
+			return nil, fmt.Errorf("Request to [%s] rejected: [%w]", c.name, ErrCircuitOpen)
+		}
+
+		resp, err := c.attempt(ctx, method, url, body)
+
+		//cancelled calls tell nothing about the health of the upstream
+		if err != nil && ctx.Err() != nil {
+			c.breaker.release()
+			return nil, fmt.Errorf("Request to [%s] cancelled: [%v]", c.name, ctx.Err())
+		}
+
+		failed := err != nil || resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500
+		c.breaker.record(!failed)
+
+		if !failed {
+			return resp, nil
+		}
+
+		c.metrics.Add("failures", 1)
+
+		//the caller handles the status code of the last response
+		if err == nil && attempt == attempts-1 {
+			return resp, nil
+		}
+
+		if err == nil {
+			resp.Body.Close()
+			err = fmt.Errorf("status code [%d]", resp.StatusCode)
+		}
+
+		lastErr = fmt.Errorf("Request to [%s] failed: [%v]", c.name, err)
+	}
+
+	return nil, lastErr
+}
+
+func (c *Client) attempt(ctx context.Context, method string, url string, body []byte) (*http.Response, error) {
+	var reader io.Reader
+	if body != nil {
+		reader = bytes.NewReader(body)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, url, reader)
+
+	if err != nil {
+		return nil, err
+	}
+
+	c.metrics.Add("requests", 1)
+
+	start := time.Now()
+	resp, err := c.http.Do(req)
+	c.metrics.Add("latencyMs", time.Since(start).Milliseconds())
+
+	return resp, err
+}
+
+//backoff returns a random wait before the attempt, up to the exponentially growing limit
+func (c *Client) backoff(attempt int) time.Duration {
+	limit := c.config.BaseBackoff << uint(attempt-1)
+
+	if limit > c.config.MaxBackoff || limit <= 0 {
+		limit = c.config.MaxBackoff
+	}
+
+	if limit <= 0 {
+		return 0
+	}
+
+	return time.Duration(rand.Int63n(int64(limit)))
+}
+
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+//circuitBreaker stops the calls to an upstream after consecutive failures. Once the timeout passes,
+//a single trial request is let through, closing the breaker if it succeeds
+type circuitBreaker struct {
+	threshold int
+	timeout   time.Duration
+	now       func() time.Time
+
+	mu       sync.Mutex
+	failures int
+	state    string
+	openedAt time.Time
+	trial    bool
+}
+
+func (b *circuitBreaker) allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case CircuitOpen:
+		if b.now().Sub(b.openedAt) < b.timeout {
+			return false
+		}
+		b.state = CircuitHalfOpen
+		b.trial = true
+		return true
+	case CircuitHalfOpen:
+		if b.trial {
+			return false
+		}
+		b.trial = true
+		return true
+	}
+
+	return true
+}
+
+func (b *circuitBreaker) record(success bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if success {
+		b.failures = 0
+		b.state = CircuitClosed
+		b.trial = false
+		return
+	}
+
+	b.failures++
+
+	if b.state == CircuitHalfOpen || (b.threshold > 0 && b.failures >= b.threshold) {
+		b.state = CircuitOpen
+		b.openedAt = b.now()
+		b.trial = false
+	}
+}
+
+//release lets the next trial request through, without recording the outcome of the current one
+func (b *circuitBreaker) release() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.trial = false
+}
+
+func (b *circuitBreaker) current() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state == "" {
+		return CircuitClosed
+	}
+
+	return b.state
+}
@@ -0,0 +1,206 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+)
+
+func testClient(name string, config ClientConfig) *Client {
+	client := NewClient(name, config)
+	client.sleep = func(ctx context.Context, d time.Duration) error { return ctx.Err() }
+	return client
+}
+
+func TestClient(t *testing.T) {
+	t.Run("retries GET on server errors", func(t *testing.T) {
+		attempts := 0
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			attempts++
+			if attempts < 3 {
+				w.WriteHeader(http.StatusBadGateway)
+				return
+			}
+			w.WriteHeader(http.StatusOK)
+		}))
+		defer server.Close()
+
+		client := testClient("retries", DefaultClientConfig())
+
+		resp, err := client.Do(context.Background(), http.MethodGet, server.URL, nil)
+
+		if err != nil || resp.StatusCode != http.StatusOK || attempts != 3 {
+			t.Fatalf("unexpected result [%v] after [%d] attempts", err, attempts)
+		}
+		resp.Body.Close()
+
+		if client.metrics.Get("retries").String() != "2" || client.metrics.Get("failures").String() != "2" {
+			t.Fatalf("unexpected metrics [%s]", client.metrics.String())
+		}
+	})
+	t.Run("returns the last response once retries are exhausted", func(t *testing.T) {
+		attempts := 0
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			attempts++
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}))
+		defer server.Close()
+
+		resp, err := testClient("exhausted", DefaultClientConfig()).Do(context.Background(), http.MethodGet, server.URL, nil)
+
+		if err != nil || resp.StatusCode != http.StatusServiceUnavailable || attempts != 3 {
+			t.Fatalf("unexpected result [%v] after [%d] attempts", err, attempts)
+		}
+		resp.Body.Close()
+	})
+	t.Run("does not retry POST and client errors", func(t *testing.T) {
+		attempts := 0
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			attempts++
+			if r.Method == http.MethodPost {
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+			w.WriteHeader(http.StatusNotFound)
+		}))
+		defer server.Close()
+
+		client := testClient("idempotent", DefaultClientConfig())
+
+		resp, _ := client.Do(context.Background(), http.MethodPost, server.URL, nil)
+		resp.Body.Close()
+		resp, _ = client.Do(context.Background(), http.MethodGet, server.URL, nil)
+		resp.Body.Close()
+
+		if attempts != 2 {
+			t.Fatalf("expected [2] attempts, got [%d]", attempts)
+		}
+	})
+	t.Run("opens the circuit after consecutive failures", func(t *testing.T) {
+		healthy := false
+		attempts := 0
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			attempts++
+			if !healthy {
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+			w.WriteHeader(http.StatusOK)
+		}))
+		defer server.Close()
+
+		now := time.Date(2021, 3, 1, 12, 0, 0, 0, time.UTC)
+
+		config := DefaultClientConfig()
+		config.MaxRetries = 0
+		config.FailureThreshold = 2
+
+		client := testClient("breaker", config)
+		client.breaker.now = func() time.Time { return now }
+
+		for i := 0; i < 2; i++ {
+			resp, _ := client.Do(context.Background(), http.MethodGet, server.URL, nil)
+			resp.Body.Close()
+		}
+
+		if _, err := client.Do(context.Background(), http.MethodGet, server.URL, nil); !errors.Is(err, ErrCircuitOpen) || attempts != 2 {
+			t.Fatalf("expected open circuit, got [%v] after [%d] attempts", err, attempts)
+		}
+
+		if client.breaker.current() != CircuitOpen || client.metrics.Get("rejected").String() != "1" {
+			t.Fatalf("unexpected state [%s] [%s]", client.breaker.current(), client.metrics.String())
+		}
+
+		now = now.Add(config.OpenTimeout)
+		healthy = true
+
+		resp, err := client.Do(context.Background(), http.MethodGet, server.URL, nil)
+
+		if err != nil || resp.StatusCode != http.StatusOK || client.breaker.current() != CircuitClosed {
+			t.Fatalf("expected closed circuit after trial, got [%v] [%s]", err, client.breaker.current())
+		}
+		resp.Body.Close()
+	})
+	t.Run("reopens the circuit if the trial fails", func(t *testing.T) {
+		now := time.Date(2021, 3, 1, 12, 0, 0, 0, time.UTC)
+		breaker := &circuitBreaker{threshold: 1, timeout: time.Minute, now: func() time.Time { return now }}
+
+		breaker.record(false)
+		now = now.Add(time.Minute)
+
+		if !breaker.allow() || breaker.allow() {
+			t.Fatal("expected single trial request")
+		}
+
+		breaker.record(false)
+
+		if breaker.current() != CircuitOpen || breaker.allow() {
+			t.Fatalf("expected open circuit, got [%s]", breaker.current())
+		}
+	})
+	t.Run("stops on cancelled context", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusOK)
+		}))
+		defer server.Close()
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		client := testClient("cancelled", DefaultClientConfig())
+
+		if _, err := client.Do(ctx, http.MethodGet, server.URL, nil); err == nil {
+			t.Fatal("expected error")
+		}
+
+		if client.breaker.current() != CircuitClosed || client.metrics.Get("failures") != nil {
+			t.Fatalf("unexpected state [%s] [%s]", client.breaker.current(), client.metrics.String())
+		}
+	})
+	t.Run("times out hung requests", func(t *testing.T) {
+		release := make(chan struct{})
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			<-release
+		}))
+		defer server.Close()
+		defer close(release)
+
+		config := DefaultClientConfig()
+		config.Timeout = 20 * time.Millisecond
+		config.MaxRetries = 0
+
+		start := time.Now()
+		_, err := testClient("timeout", config).Do(context.Background(), http.MethodGet, server.URL, nil)
+
+		if err == nil || time.Since(start) > time.Second {
+			t.Fatalf("expected timeout, got [%v] after [%v]", err, time.Since(start))
+		}
+	})
+}
+
+func TestLoadClientConfig(t *testing.T) {
+	t.Run("reads the environment", func(t *testing.T) {
+		os.Setenv("TEST_UPSTREAM_TIMEOUT", "2s")
+		os.Setenv("TEST_UPSTREAM_RETRIES", "0")
+		defer os.Unsetenv("TEST_UPSTREAM_TIMEOUT")
+		defer os.Unsetenv("TEST_UPSTREAM_RETRIES")
+
+		config, err := LoadClientConfig("TEST_UPSTREAM")
+
+		if err != nil || config.Timeout != 2*time.Second || config.MaxRetries != 0 || config.FailureThreshold != DefaultClientConfig().FailureThreshold {
+			t.Fatalf("unexpected config [%+v] [%v]", config, err)
+		}
+	})
+	t.Run("fails on invalid values", func(t *testing.T) {
+		os.Setenv("TEST_UPSTREAM_BREAKER_TIMEOUT", "soon")
+		defer os.Unsetenv("TEST_UPSTREAM_BREAKER_TIMEOUT")
+
+		if _, err := LoadClientConfig("TEST_UPSTREAM"); err == nil {
+			t.Fatal("expected error")
+		}
+	})
+}
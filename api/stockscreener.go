@@ -1,6 +1,7 @@
 package api
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"log"
@@ -14,18 +15,21 @@ import (
 )
 
 type StockClient struct {
-	host string
+	host        string
+	client      *Client
+	sp500Client *Client
 }
 
-func NewStockClient(h string) *StockClient {
+func NewStockClient(h string, config ClientConfig) *StockClient {
 	return &StockClient{
-		host: h,
+		host:        h,
+		client:      NewClient("stockScreener", config),
+		sp500Client: NewClient("sp500", config),
 	}
 }
 
-func (sc *StockClient) RegisterStock(symbol string) error {
-
-	resp, err := http.Post(sc.host+symbol, "", nil)
+func (sc *StockClient) RegisterStock(ctx context.Context, symbol string) error {
+	resp, err := sc.client.Do(ctx, http.MethodPost, sc.host+symbol, nil)
 
 	if err != nil {
 		return fmt.Errorf("Failed to register stock [%s] with error [%v]", symbol, err)
@@ -42,13 +46,13 @@ func (sc *StockClient) RegisterStock(symbol string) error {
 	return nil
 }
 
-func (sc *StockClient) Get(symbol string) (model.StockData, error) {
-	resp, err := http.Get(sc.host + symbol)
+func (sc *StockClient) Get(ctx context.Context, symbol string) (model.StockData, error) {
+	resp, err := sc.client.Do(ctx, http.MethodGet, sc.host+symbol, nil)
 
 	stockData := model.StockData{}
 
 	if err != nil {
-		return stockData, fmt.Errorf("Failed to get stock [%s] with error [%v]", symbol, err)
+		return stockData, fmt.Errorf("Failed to get stock [%s] with error [%w]", symbol, err)
 	}
 
 	defer resp.Body.Close()
@@ -68,8 +72,8 @@ func (sc *StockClient) Get(symbol string) (model.StockData, error) {
 	return stockData, nil
 }
 
-func (sc *StockClient) GetAll() ([]model.StockData, error) {
-	resp, err := http.Get(strings.Trim(sc.host, "/"))
+func (sc *StockClient) GetAll(ctx context.Context) ([]model.StockData, error) {
+	resp, err := sc.client.Do(ctx, http.MethodGet, strings.Trim(sc.host, "/"), nil)
 
 	if err != nil {
 		return nil, fmt.Errorf("Failed to get stocks, error [%v]", err)
@@ -105,6 +109,11 @@ var sp500 sp500DivYield
 
 //TODO move this logic to stock-screener service
 func (sc *StockClient) GetSP500DivYield() float64 {
+	return sc.RefreshSP500DivYield(context.Background())
+}
+
+//RefreshSP500DivYield updates the S&P500 dividend yield within ctx if it is outdated, and returns it
+func (sc *StockClient) RefreshSP500DivYield(ctx context.Context) float64 {
 	now := time.Now()
 	if sp500.NextUpdate.Before(now) {
 		sp500.Mux.Lock()
@@ -112,7 +121,7 @@ func (sc *StockClient) GetSP500DivYield() float64 {
 		defer sp500.Mux.Unlock()
 
 		if sp500.NextUpdate.Before(now) {
-			yield, err := getSp500DivYield()
+			yield, err := sc.getSp500DivYield(ctx)
 			if err != nil {
 				log.Printf("Failed to update sp500 dividend yield: [%v]\n", err)
 				log.Println("Using old sp500 dividend yield")
@@ -132,10 +141,10 @@ func (sc *StockClient) GetSP500DivYield() float64 {
 	return sp500.Yield
 }
 
-func getSp500DivYield() (float64, error) {
+func (sc *StockClient) getSp500DivYield(ctx context.Context) (float64, error) {
 	host := os.Getenv("SP500_URL")
 
-	resp, err := http.Get(host)
+	resp, err := sc.sp500Client.Do(ctx, http.MethodGet, host, nil)
 
 	if err != nil {
 		return 0, fmt.Errorf("Failed to get SP500 div yield: [%v]", err)
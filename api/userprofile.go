@@ -1,6 +1,7 @@
 package api
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
@@ -9,20 +10,35 @@ import (
 )
 
 type UserprofileClient struct {
-	host string
+	host   string
+	client *Client
 }
 
-func NewUserprofileClient(h string) *UserprofileClient {
+func NewUserprofileClient(h string, config ClientConfig) *UserprofileClient {
 	return &UserprofileClient{
-		host: h,
+		host:   h,
+		client: NewClient("userprofile", config),
 	}
 }
 
-func (uc *UserprofileClient) GetUserprofile(userId string) (userprofileModel.Userprofile, error) {
-	resp, err := http.Get(uc.host + userId)
+//localizedUserprofile is the userprofile with the preferred locale of the user, which the userprofile model does not carry
+type localizedUserprofile struct {
+	userprofileModel.Userprofile
+	Locale string `json:"locale"`
+}
+
+func (uc *UserprofileClient) GetUserprofile(ctx context.Context, userId string) (userprofileModel.Userprofile, error) {
+	userprofile, _, err := uc.GetLocalizedUserprofile(ctx, userId)
+
+	return userprofile, err
+}
+
+//GetLocalizedUserprofile returns the userprofile and the preferred locale of the user, empty if the user has none
+func (uc *UserprofileClient) GetLocalizedUserprofile(ctx context.Context, userId string) (userprofileModel.Userprofile, string, error) {
+	resp, err := uc.client.Do(ctx, http.MethodGet, uc.host+userId, nil)
 
 	if err != nil {
-		return userprofileModel.Userprofile{}, fmt.Errorf("Failed to get userprofile [%s] with error [%v]", userId, err)
+		return userprofileModel.Userprofile{}, "", fmt.Errorf("Failed to get userprofile [%s] with error [%v]", userId, err)
 	}
 
 	defer resp.Body.Close()
@@ -30,16 +46,16 @@ func (uc *UserprofileClient) GetUserprofile(userId string) (userprofileModel.Use
 	if resp.StatusCode >= 299 {
 		var response string
 		fmt.Fscan(resp.Body, &response)
-		return userprofileModel.Userprofile{}, fmt.Errorf("Failed to get [%s], status code [%d], response [%v]", userId, resp.StatusCode, response)
+		return userprofileModel.Userprofile{}, "", fmt.Errorf("Failed to get [%s], status code [%d], response [%v]", userId, resp.StatusCode, response)
 	}
 
-	userprofile := userprofileModel.Userprofile{}
+	userprofile := localizedUserprofile{}
 
 	err = json.NewDecoder(resp.Body).Decode(&userprofile)
 
 	if err != nil {
-		return userprofileModel.Userprofile{}, fmt.Errorf("Failed to deserialize data for [%s], error: [%v]", userId, err)
+		return userprofileModel.Userprofile{}, "", fmt.Errorf("Failed to deserialize data for [%s], error: [%v]", userId, err)
 	}
 
-	return userprofile, nil
+	return userprofile.Userprofile, userprofile.Locale, nil
 }
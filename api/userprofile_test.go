@@ -0,0 +1,37 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestUserprofileClient(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/localized":
+			w.Write([]byte(`{"userId":"localized","email":"alice@example.com","locale":"hu-HU"}`))
+		default:
+			w.Write([]byte(`{"userId":"plain","email":"bob@example.com"}`))
+		}
+	}))
+	defer server.Close()
+
+	client := NewUserprofileClient(server.URL+"/", DefaultClientConfig())
+
+	t.Run("returns the locale of the user", func(t *testing.T) {
+		userprofile, locale, err := client.GetLocalizedUserprofile(context.Background(), "localized")
+
+		if err != nil || userprofile.Email != "alice@example.com" || locale != "hu-HU" {
+			t.Fatalf("unexpected userprofile [%+v] [%s] [%v]", userprofile, locale, err)
+		}
+	})
+	t.Run("returns empty locale if the user has none", func(t *testing.T) {
+		userprofile, locale, err := client.GetLocalizedUserprofile(context.Background(), "plain")
+
+		if err != nil || userprofile.Email != "bob@example.com" || locale != "" {
+			t.Fatalf("unexpected userprofile [%+v] [%s] [%v]", userprofile, locale, err)
+		}
+	})
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"os"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/nagymarci/stock-watchlist/api"
+	"github.com/nagymarci/stock-watchlist/database"
+	"github.com/nagymarci/stock-watchlist/model"
+	"github.com/nagymarci/stock-watchlist/service"
+
+	log "github.com/sirupsen/logrus"
+)
+
+func main() {
+	watchlistFlag := flag.String("watchlist", "", "id of the watchlist to backtest")
+	fromFlag := flag.String("from", time.Now().AddDate(-1, 0, 0).Format("2006-01-02"), "first day of the backtest (YYYY-MM-DD)")
+	toFlag := flag.String("to", time.Now().Format("2006-01-02"), "last day of the backtest (YYYY-MM-DD)")
+	numReqs := flag.Int("num-reqs", 2, "number of green signals required to hold a stock")
+	priceGreen := flag.Bool("price-green", true, "require green price to hold a stock")
+	flag.Parse()
+
+	watchlistID, err := primitive.ObjectIDFromHex(*watchlistFlag)
+	if err != nil {
+		log.Fatalf("Invalid watchlist id [%s]: [%v]", *watchlistFlag, err)
+	}
+
+	from, err := time.Parse("2006-01-02", *fromFlag)
+	if err != nil {
+		log.Fatalf("Invalid from date [%v]", err)
+	}
+
+	to, err := time.Parse("2006-01-02", *toFlag)
+	if err != nil {
+		log.Fatalf("Invalid to date [%v]", err)
+	}
+
+	db := database.New(os.Getenv("DB_CONNECTION_URI"))
+	wDb := database.NewWatchlists(db)
+	sDb := database.NewSnapshots(db)
+
+	userprofileConfig, err := api.LoadClientConfig("USERPROFILE")
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	upC := api.NewUserprofileClient(os.Getenv("USERPROFILE_URL"), userprofileConfig)
+
+	watchlist, err := wDb.Get(watchlistID)
+	if err != nil {
+		log.Fatalf("Failed to get watchlist [%v]", err)
+	}
+
+	userprofile, err := upC.GetUserprofile(context.Background(), watchlist.UserID)
+	if err != nil {
+		log.Warnf("Using default expectations, failed to get userprofile [%v]", err)
+		userprofile = service.DefaultUserprofile()
+	}
+
+	strategy := model.BacktestStrategy{NumReqs: *numReqs, PriceGreen: *priceGreen}
+
+	result, err := service.NewBacktester(sDb).Run(watchlist.Stocks, from, to, strategy, &userprofile)
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	encoder.Encode(result)
+}
@@ -4,9 +4,11 @@ import (
 	"fmt"
 	"net/http"
 	"os"
+	"time"
 
 	"github.com/nagymarci/stock-watchlist/api"
 	"github.com/nagymarci/stock-watchlist/controllers"
+	"github.com/nagymarci/stock-watchlist/model"
 	"github.com/nagymarci/stock-watchlist/routes"
 	"github.com/nagymarci/stock-watchlist/service"
 	"github.com/robfig/cron/v3"
@@ -18,29 +20,139 @@ import (
 func main() {
 	log.SetFormatter(&log.JSONFormatter{})
 
+	//without a scope of its own, every user could reach the admin endpoints
+	adminScope := os.Getenv("WATCHLIST_ADMIN_SCOPE")
+	if adminScope == "" {
+		log.Fatal("WATCHLIST_ADMIN_SCOPE must be set")
+	}
+
 	db := database.New(os.Getenv("DB_CONNECTION_URI"))
 	rDb := database.NewRecommendations(db)
 	wDb := database.NewWatchlists(db)
+	if err := wDb.CreateIndexes(); err != nil {
+		log.Fatal(err)
+	}
+	sDb := database.NewSnapshots(db)
+	aDb := database.NewAlertRules(db)
+	cDb := database.NewChannels(db)
+	pDb := database.NewPreferences(db)
+	dDb := database.NewDigestEvents(db)
+	paDb := database.NewPriceAlerts(db)
+	nDb := database.NewNotifications(db)
+	oDb := database.NewOutbox(db)
+
+	stockScreenerConfig, err := api.LoadClientConfig("STOCK_SCREENER")
+	if err != nil {
+		log.Fatal(err)
+	}
 
-	sC := api.NewStockClient(os.Getenv("STOCK_SCREENER_URL"))
-	upC := api.NewUserprofileClient(os.Getenv("USERPROFILE_URL"))
+	userprofileConfig, err := api.LoadClientConfig("USERPROFILE")
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	sC := api.NewStockClient(os.Getenv("STOCK_SCREENER_URL"), stockScreenerConfig)
+	upC := api.NewUserprofileClient(os.Getenv("USERPROFILE_URL"), userprofileConfig)
 
 	sS := service.NewStockService(sC)
 
-	wC := controllers.NewWatchlistController(wDb, sC, upC, sS)
-	stockController := controllers.NewStockController(sC, upC, sS)
+	calendar := service.NewUSCalendar()
+	if path := os.Getenv("MARKET_CALENDAR_FILE"); path != "" {
+		calendar, err = service.LoadCalendar(path)
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	wC := controllers.NewWatchlistController(wDb, sC, upC, sS, calendar)
+	stockController := controllers.NewStockController(sC, upC, sS, sDb)
+
+	backtestController := controllers.NewBacktestController(wDb, upC, service.NewBacktester(sDb))
+
+	alertController := controllers.NewAlertController(wDb, aDb)
+	channelController := controllers.NewChannelController(cDb, wDb)
+	preferencesController := controllers.NewPreferencesController(pDb, wDb, cDb)
+	priceAlertController := controllers.NewPriceAlertController(paDb, sC)
+	notificationController := controllers.NewNotificationController(nDb)
+
+	renderer, err := service.NewRenderer(os.Getenv("NOTIFICATION_TEMPLATE_DIR"))
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	//the emails only carry unsubscribe links if the tokens can be signed
+	var unsubscribeTokens *service.UnsubscribeTokens
+	if secret := os.Getenv("UNSUBSCRIBE_SECRET"); secret != "" && os.Getenv("PUBLIC_URL") != "" {
+		unsubscribeTokens = service.NewUnsubscribeTokens(secret, os.Getenv("PUBLIC_URL"))
+	} else {
+		log.Warnln("UNSUBSCRIBE_SECRET or PUBLIC_URL is not set, emails will not contain unsubscribe links")
+	}
+
+	channels := service.NewChannels(cDb, renderer, nDb, unsubscribeTokens)
+	mailConfig, err := service.LoadMailConfig()
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	//with a mail directory the emails are written to files instead of being sent
+	if mailConfig.Dir != "" {
+		mailer, err := service.NewFileMailer(mailConfig)
+		if err != nil {
+			log.Fatal(err)
+		}
+		channels.Register(model.ChannelEmail, mailer)
+	} else {
+		mailer, err := service.NewSMTPMailer(mailConfig)
+		if err != nil {
+			log.Fatal(err)
+		}
+		channels.Register(model.ChannelEmail, mailer)
+	}
+	channels.Register(model.ChannelWebhook, service.NewWebhook())
+	channels.Register(model.ChannelChat, service.NewChatWebhook())
+
+	digests := service.NewDigests(pDb, dDb, channels, upC)
+	outbox := service.NewOutbox(oDb, digests)
 
-	router := routes.Route(wC, stockController)
+	//only the instance holding the lease runs the scheduled jobs
+	scheduler := service.NewLeader(database.NewLeases(db), "scheduler", service.InstanceID(), 30*time.Second)
+	scheduler.Start()
 
-	mC := service.NewMail()
 	c := cron.New()
-	n := service.NewNotifier(rDb, wDb, sC, sS, upC, mC)
-	_, err := c.AddFunc("CRON_TZ=America/New_York 0 8-18 * * MON-FRI", n.NotifyChanges)
+	n := service.NewNotifier(rDb, wDb, aDb, sC, sS, upC, outbox, pDb, paDb)
+	//every watchlist is checked on its own schedule, the scheduler looks for due checks every minute
+	checks := service.NewCheckScheduler(wDb, n, calendar)
+	_, err = c.AddFunc("@every 1m", scheduler.Guard(checks.Tick))
+	if err != nil {
+		log.Errorln(err)
+	}
+
+	//the snapshots are taken after the close, skipping the holidays of the exchange
+	snapshotter := service.NewSnapshotter(sDb, sC, sC, sS)
+	_, err = c.AddFunc(fmt.Sprintf("CRON_TZ=%s 0,30 * * * MON-FRI", calendar.Location()), scheduler.Guard(calendar.AfterClose(30*time.Minute, 30*time.Minute, snapshotter.TakeSnapshots)))
+	if err != nil {
+		log.Errorln(err)
+	}
+
+	_, err = c.AddFunc("0 * * * *", scheduler.Guard(digests.SendDigests))
+	if err != nil {
+		log.Errorln(err)
+	}
+
+	_, err = c.AddFunc("@every 1m", scheduler.Guard(outbox.Process))
 	if err != nil {
 		log.Errorln(err)
 	}
 
 	c.Start()
 
+	statusController := controllers.NewStatusController(scheduler, n)
+	outboxController := controllers.NewOutboxController(oDb)
+	unsubscribeController := controllers.NewUnsubscribeController(pDb, unsubscribeTokens)
+	notifierController := controllers.NewNotifierController(wDb, n, renderer, scheduler)
+	calendarController := controllers.NewCalendarController(calendar)
+
+	router := routes.Route(wC, stockController, backtestController, alertController, channelController, preferencesController, priceAlertController, notificationController, statusController, outboxController, unsubscribeController, notifierController, calendarController, adminScope)
+
 	log.Fatal(http.ListenAndServe(fmt.Sprintf(":%s", os.Getenv("PORT")), router))
 }
@@ -0,0 +1,112 @@
+package controllers
+
+import (
+	"strings"
+
+	"github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	stockHttp "github.com/nagymarci/stock-commons/http"
+	"github.com/nagymarci/stock-watchlist/database"
+	"github.com/nagymarci/stock-watchlist/model"
+	"github.com/nagymarci/stock-watchlist/service"
+)
+
+type AlertController struct {
+	watchlists *database.Watchlists
+	alertRules *database.AlertRules
+}
+
+func NewAlertController(w *database.Watchlists, a *database.AlertRules) *AlertController {
+	return &AlertController{
+		watchlists: w,
+		alertRules: a,
+	}
+}
+
+//Create saves a new alert rule for the watchlist
+func (ac *AlertController) Create(log *logrus.Entry, watchlistID primitive.ObjectID, userID string, request *model.AlertRuleRequest) (*model.AlertRule, error) {
+	_, err := getWatchlistOfUser(ac.watchlists, watchlistID, userID)
+
+	if err != nil {
+		message := "Cannot read watchlist " + err.Error()
+		log.Errorln(message)
+		return nil, stockHttp.NewBadRequestError(message)
+	}
+
+	if strings.TrimSpace(request.Name) == "" {
+		return nil, stockHttp.NewBadRequestError("Required value 'name' is missing")
+	}
+
+	_, err = service.CompileAlert(request.Expression)
+
+	if err != nil {
+		return nil, stockHttp.NewBadRequestError("Invalid expression: " + err.Error())
+	}
+
+	rule := model.AlertRule{
+		WatchlistID: watchlistID,
+		UserID:      userID,
+		Name:        request.Name,
+		Expression:  request.Expression,
+		Matching:    []string{},
+	}
+
+	rule.ID, err = ac.alertRules.Create(rule)
+
+	if err != nil {
+		return nil, stockHttp.NewInternalServerError(err.Error())
+	}
+
+	return &rule, nil
+}
+
+//GetAll returns the alert rules of the watchlist
+func (ac *AlertController) GetAll(log *logrus.Entry, watchlistID primitive.ObjectID, userID string) ([]model.AlertRule, error) {
+	_, err := getWatchlistOfUser(ac.watchlists, watchlistID, userID)
+
+	if err != nil {
+		message := "Cannot read watchlist " + err.Error()
+		log.Errorln(message)
+		return nil, stockHttp.NewBadRequestError(message)
+	}
+
+	rules, err := ac.alertRules.GetByWatchlist(watchlistID)
+
+	if err != nil {
+		return nil, stockHttp.NewInternalServerError(err.Error())
+	}
+
+	if rules == nil {
+		rules = []model.AlertRule{}
+	}
+
+	return rules, nil
+}
+
+//Delete deletes the alert rule if that belongs to the watchlist of the user
+func (ac *AlertController) Delete(log *logrus.Entry, watchlistID primitive.ObjectID, id primitive.ObjectID, userID string) error {
+	_, err := getWatchlistOfUser(ac.watchlists, watchlistID, userID)
+
+	if err != nil {
+		return stockHttp.NewBadRequestError(err.Error())
+	}
+
+	rule, err := ac.alertRules.Get(id)
+
+	if err != nil || rule.WatchlistID != watchlistID {
+		return stockHttp.NewNotFoundError("Alert rule not found")
+	}
+
+	result, err := ac.alertRules.Delete(id)
+
+	if err != nil {
+		return stockHttp.NewInternalServerError(err.Error())
+	}
+
+	if result != 1 {
+		return stockHttp.NewInternalServerError("No object were removed from database")
+	}
+
+	return nil
+}
@@ -0,0 +1,59 @@
+package controllers
+
+import (
+	"context"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	stockHttp "github.com/nagymarci/stock-commons/http"
+	"github.com/nagymarci/stock-watchlist/database"
+	"github.com/nagymarci/stock-watchlist/model"
+	"github.com/nagymarci/stock-watchlist/service"
+)
+
+type BacktestController struct {
+	watchlists        *database.Watchlists
+	userprofileClient userprofileClient
+	backtester        *service.Backtester
+}
+
+func NewBacktestController(w *database.Watchlists, upc userprofileClient, b *service.Backtester) *BacktestController {
+	return &BacktestController{
+		watchlists:        w,
+		userprofileClient: upc,
+		backtester:        b,
+	}
+}
+
+//Run backtests the strategy on the stored history of the watchlist's stocks
+func (bc *BacktestController) Run(ctx context.Context, log *logrus.Entry, id primitive.ObjectID, userID string, from, to time.Time, strategy model.BacktestStrategy) (model.BacktestResult, error) {
+	if to.Before(from) {
+		return model.BacktestResult{}, stockHttp.NewBadRequestError("'from' must not be after 'to'")
+	}
+
+	watchlist, err := getWatchlistOfUser(bc.watchlists, id, userID)
+
+	if err != nil {
+		message := "Cannot read watchlist " + err.Error()
+		log.Errorln(message)
+		return model.BacktestResult{}, stockHttp.NewBadRequestError(message)
+	}
+
+	userprofile, err := bc.userprofileClient.GetUserprofile(ctx, userID)
+
+	if err != nil {
+		log.Errorln(err)
+		userprofile = service.DefaultUserprofile()
+	}
+
+	result, err := bc.backtester.Run(watchlist.Stocks, from, to, strategy, &userprofile)
+
+	if err != nil {
+		log.Errorln(err)
+		return model.BacktestResult{}, stockHttp.NewInternalServerError(err.Error())
+	}
+
+	return result, nil
+}
@@ -0,0 +1,41 @@
+package controllers
+
+import (
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	stockHttp "github.com/nagymarci/stock-commons/http"
+	"github.com/nagymarci/stock-watchlist/model"
+	"github.com/nagymarci/stock-watchlist/service"
+)
+
+//maxCalendarDays is the longest date range of one calendar request
+const maxCalendarDays = 366
+
+type CalendarController struct {
+	calendar *service.Calendar
+}
+
+func NewCalendarController(c *service.Calendar) *CalendarController {
+	return &CalendarController{
+		calendar: c,
+	}
+}
+
+//Get returns the sessions of the exchange between the dates, taken as days in the time zone of the exchange
+func (cc *CalendarController) Get(log *logrus.Entry, from time.Time, to time.Time) (model.MarketSchedule, error) {
+	location := cc.calendar.Location()
+	from = time.Date(from.Year(), from.Month(), from.Day(), 12, 0, 0, 0, location)
+	to = time.Date(to.Year(), to.Month(), to.Day(), 12, 0, 0, 0, location)
+
+	if to.Before(from) {
+		return model.MarketSchedule{}, stockHttp.NewBadRequestError("Invalid date range, 'to' is before 'from'")
+	}
+
+	if to.Sub(from) > maxCalendarDays*24*time.Hour {
+		return model.MarketSchedule{}, stockHttp.NewBadRequestError("Invalid date range, it must be at most 366 days")
+	}
+
+	return cc.calendar.Schedule(from, to), nil
+}
@@ -0,0 +1,113 @@
+package controllers
+
+import (
+	"github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	stockHttp "github.com/nagymarci/stock-commons/http"
+	"github.com/nagymarci/stock-watchlist/database"
+	"github.com/nagymarci/stock-watchlist/model"
+	"github.com/nagymarci/stock-watchlist/service"
+)
+
+type ChannelController struct {
+	channels   *database.Channels
+	watchlists *database.Watchlists
+}
+
+func NewChannelController(c *database.Channels, w *database.Watchlists) *ChannelController {
+	return &ChannelController{
+		channels:   c,
+		watchlists: w,
+	}
+}
+
+//Create registers a new notification channel for the user
+func (cc *ChannelController) Create(log *logrus.Entry, userID string, request *model.NotificationChannelRequest) (*model.NotificationChannel, error) {
+	err := service.ValidateChannel(request)
+
+	if err != nil {
+		return nil, stockHttp.NewBadRequestError(err.Error())
+	}
+
+	channel := model.NotificationChannel{
+		UserID:  userID,
+		Type:    request.Type,
+		Name:    request.Name,
+		Address: request.Address,
+		Secret:  request.Secret,
+	}
+
+	channel.ID, err = cc.channels.Create(channel)
+
+	if err != nil {
+		return nil, stockHttp.NewInternalServerError(err.Error())
+	}
+
+	return &channel, nil
+}
+
+//GetAll returns the notification channels of the user
+func (cc *ChannelController) GetAll(log *logrus.Entry, userID string) ([]model.NotificationChannel, error) {
+	channels, err := cc.channels.GetAll(userID)
+
+	if err != nil {
+		return nil, stockHttp.NewInternalServerError(err.Error())
+	}
+
+	if channels == nil {
+		channels = []model.NotificationChannel{}
+	}
+
+	return channels, nil
+}
+
+//Delete deletes the notification channel if that belongs to the user
+func (cc *ChannelController) Delete(log *logrus.Entry, id primitive.ObjectID, userID string) error {
+	channel, err := cc.channels.Get(id)
+
+	if err != nil || channel.UserID != userID {
+		return stockHttp.NewNotFoundError("Channel not found")
+	}
+
+	result, err := cc.channels.Delete(id)
+
+	if err != nil {
+		return stockHttp.NewInternalServerError(err.Error())
+	}
+
+	if result != 1 {
+		return stockHttp.NewInternalServerError("No object were removed from database")
+	}
+
+	return nil
+}
+
+//SetWatchlistChannels chooses the channels the notifications of the watchlist are sent to
+func (cc *ChannelController) SetWatchlistChannels(log *logrus.Entry, watchlistID primitive.ObjectID, userID string, channels []primitive.ObjectID) (model.Watchlist, error) {
+	watchlist, err := getWatchlistOfUser(cc.watchlists, watchlistID, userID)
+
+	if err != nil {
+		message := "Cannot read watchlist " + err.Error()
+		log.Errorln(message)
+		return model.Watchlist{}, stockHttp.NewBadRequestError(message)
+	}
+
+	for _, id := range channels {
+		channel, err := cc.channels.Get(id)
+
+		if err != nil || channel.UserID != userID {
+			return model.Watchlist{}, stockHttp.NewBadRequestError("Unknown channel [" + id.Hex() + "]")
+		}
+	}
+
+	err = cc.watchlists.UpdateChannels(watchlistID, channels)
+
+	if err != nil {
+		return model.Watchlist{}, stockHttp.NewInternalServerError(err.Error())
+	}
+
+	watchlist.Channels = channels
+
+	return watchlist, nil
+}
@@ -0,0 +1,62 @@
+package controllers
+
+import (
+	"github.com/sirupsen/logrus"
+
+	stockHttp "github.com/nagymarci/stock-commons/http"
+	"github.com/nagymarci/stock-watchlist/database"
+	"github.com/nagymarci/stock-watchlist/model"
+)
+
+const (
+	defaultNotificationPageSize = 20
+	maxNotificationPageSize     = 100
+)
+
+type NotificationController struct {
+	notifications *database.Notifications
+}
+
+func NewNotificationController(n *database.Notifications) *NotificationController {
+	return &NotificationController{
+		notifications: n,
+	}
+}
+
+//GetAll returns the notifications sent to the user, newest first
+func (nc *NotificationController) GetAll(log *logrus.Entry, userID string, query model.NotificationQuery) (model.NotificationPage, error) {
+	query.UserID = userID
+
+	return nc.Find(log, query)
+}
+
+//Find returns the notifications matching the query, newest first
+func (nc *NotificationController) Find(log *logrus.Entry, query model.NotificationQuery) (model.NotificationPage, error) {
+	if query.Page == 0 {
+		query.Page = 1
+	}
+
+	if query.PageSize == 0 {
+		query.PageSize = defaultNotificationPageSize
+	}
+
+	if query.Page < 0 || query.PageSize < 0 || query.PageSize > maxNotificationPageSize {
+		return model.NotificationPage{}, stockHttp.NewBadRequestError("Invalid paging, page must be positive and pageSize at most 100")
+	}
+
+	if query.Status != "" && query.Status != model.NotificationSent && query.Status != model.NotificationFailed {
+		return model.NotificationPage{}, stockHttp.NewBadRequestError("Unknown status [" + query.Status + "]")
+	}
+
+	items, total, err := nc.notifications.Find(query)
+
+	if err != nil {
+		return model.NotificationPage{}, stockHttp.NewInternalServerError(err.Error())
+	}
+
+	if items == nil {
+		items = []model.NotificationRecord{}
+	}
+
+	return model.NotificationPage{Items: items, Page: query.Page, PageSize: query.PageSize, Total: total}, nil
+}
@@ -0,0 +1,72 @@
+package controllers
+
+import (
+	"github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	stockHttp "github.com/nagymarci/stock-commons/http"
+	"github.com/nagymarci/stock-watchlist/database"
+	"github.com/nagymarci/stock-watchlist/model"
+	"github.com/nagymarci/stock-watchlist/service"
+)
+
+type NotifierController struct {
+	watchlists *database.Watchlists
+	notifier   *service.Notifier
+	renderer   *service.Renderer
+	scheduler  *service.Leader
+}
+
+func NewNotifierController(w *database.Watchlists, n *service.Notifier, r *service.Renderer, s *service.Leader) *NotifierController {
+	return &NotifierController{
+		watchlists: w,
+		notifier:   n,
+		renderer:   r,
+		scheduler:  s,
+	}
+}
+
+//Preview returns the notifications the notifier would send about the watchlist now, rendered but not sent
+func (nc *NotifierController) Preview(log *logrus.Entry, id primitive.ObjectID, userID string) (model.NotificationPreview, error) {
+	watchlist, err := getWatchlistOfUser(nc.watchlists, id, userID)
+
+	if err != nil {
+		message := "Cannot read watchlist " + err.Error()
+		log.Errorln(message)
+		return model.NotificationPreview{}, stockHttp.NewBadRequestError(message)
+	}
+
+	preview, err := nc.notifier.Preview(&watchlist)
+
+	if err != nil {
+		return preview, stockHttp.NewFailedDependencyError(err.Error())
+	}
+
+	for i := range preview.Notifications {
+		message, err := nc.renderer.Render(&preview.Notifications[i].Notification, model.UnsubscribeLinks{})
+
+		if err != nil {
+			return preview, stockHttp.NewInternalServerError(err.Error())
+		}
+
+		preview.Notifications[i].Message = message
+	}
+
+	if preview.Notifications == nil {
+		preview.Notifications = []model.PreviewedNotification{}
+	}
+
+	return preview, nil
+}
+
+//Run runs the notifier immediately, and returns its statistics. Only the instance holding the scheduler lease runs it,
+//so it cannot overlap with the scheduled checks of another instance
+func (nc *NotifierController) Run(log *logrus.Entry) (model.NotifierRunStats, error) {
+	if !nc.scheduler.IsLeader() {
+		return model.NotifierRunStats{}, service.ErrNotLeader
+	}
+
+	log.Infoln("Notifier run triggered")
+
+	return nc.notifier.RunNow()
+}
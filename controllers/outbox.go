@@ -0,0 +1,70 @@
+package controllers
+
+import (
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	stockHttp "github.com/nagymarci/stock-commons/http"
+	"github.com/nagymarci/stock-watchlist/database"
+	"github.com/nagymarci/stock-watchlist/model"
+)
+
+type OutboxController struct {
+	outbox *database.Outbox
+}
+
+func NewOutboxController(o *database.Outbox) *OutboxController {
+	return &OutboxController{
+		outbox: o,
+	}
+}
+
+//Find returns the outbox entries with the status, oldest first
+func (oc *OutboxController) Find(log *logrus.Entry, status string, page int64, pageSize int64) (model.OutboxPage, error) {
+	if page == 0 {
+		page = 1
+	}
+
+	if pageSize == 0 {
+		pageSize = defaultNotificationPageSize
+	}
+
+	if pageSize > maxNotificationPageSize {
+		return model.OutboxPage{}, stockHttp.NewBadRequestError("Invalid paging, pageSize must be at most 100")
+	}
+
+	if status != "" && status != model.OutboxPending && status != model.OutboxDead {
+		return model.OutboxPage{}, stockHttp.NewBadRequestError("Unknown status [" + status + "]")
+	}
+
+	items, total, err := oc.outbox.Find(status, page, pageSize)
+
+	if err != nil {
+		return model.OutboxPage{}, stockHttp.NewInternalServerError(err.Error())
+	}
+
+	if items == nil {
+		items = []model.OutboxEntry{}
+	}
+
+	return model.OutboxPage{Items: items, Page: page, PageSize: pageSize, Total: total}, nil
+}
+
+//Requeue schedules the dead-lettered entry for delivery again
+func (oc *OutboxController) Requeue(log *logrus.Entry, id primitive.ObjectID) error {
+	requeued, err := oc.outbox.Requeue(id, time.Now().UTC())
+
+	if err != nil {
+		return stockHttp.NewInternalServerError(err.Error())
+	}
+
+	if requeued != 1 {
+		return stockHttp.NewNotFoundError("Dead-lettered outbox entry not found")
+	}
+
+	log.Infoln("Outbox entry requeued ", id.Hex())
+
+	return nil
+}
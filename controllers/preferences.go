@@ -0,0 +1,138 @@
+package controllers
+
+import (
+	"errors"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	stockHttp "github.com/nagymarci/stock-commons/http"
+	"github.com/nagymarci/stock-watchlist/database"
+	"github.com/nagymarci/stock-watchlist/model"
+	"github.com/nagymarci/stock-watchlist/service"
+)
+
+type PreferencesController struct {
+	preferences *database.Preferences
+	watchlists  *database.Watchlists
+	channels    *database.Channels
+}
+
+func NewPreferencesController(p *database.Preferences, w *database.Watchlists, c *database.Channels) *PreferencesController {
+	return &PreferencesController{
+		preferences: p,
+		watchlists:  w,
+		channels:    c,
+	}
+}
+
+//Get returns the notification preferences of the user, the defaults if not set yet
+func (pc *PreferencesController) Get(log *logrus.Entry, userID string) (model.NotificationPreferences, error) {
+	preferences, err := pc.preferences.Get(userID)
+
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return service.DefaultNotificationPreferences(userID), nil
+	}
+
+	if err != nil {
+		return preferences, stockHttp.NewInternalServerError(err.Error())
+	}
+
+	return preferences, nil
+}
+
+//Set validates and stores the notification preferences of the user
+func (pc *PreferencesController) Set(log *logrus.Entry, userID string, request *model.NotificationPreferencesRequest) (model.NotificationPreferences, error) {
+	err := service.ValidateNotificationPreferences(request)
+
+	if err != nil {
+		return model.NotificationPreferences{}, stockHttp.NewBadRequestError(err.Error())
+	}
+
+	for _, id := range request.Channels {
+		channel, err := pc.channels.Get(id)
+
+		if err != nil || channel.UserID != userID {
+			return model.NotificationPreferences{}, stockHttp.NewBadRequestError("Unknown channel [" + id.Hex() + "]")
+		}
+	}
+
+	for _, watchlist := range request.Watchlists {
+		_, err := getWatchlistOfUser(pc.watchlists, watchlist.WatchlistID, userID)
+
+		if err != nil {
+			return model.NotificationPreferences{}, stockHttp.NewBadRequestError("Unknown watchlist [" + watchlist.WatchlistID.Hex() + "]")
+		}
+	}
+
+	preferences, err := pc.Get(log, userID)
+
+	if err != nil {
+		return preferences, err
+	}
+
+	preferences.Disabled = request.Disabled
+	preferences.Delivery = request.Delivery
+	preferences.DigestHour = request.DigestHour
+	preferences.DigestWeekday = request.DigestWeekday
+	preferences.TimeZone = request.TimeZone
+	preferences.Locale = request.Locale
+	preferences.QuietHours = request.QuietHours
+	preferences.Channels = request.Channels
+	preferences.Watchlists = request.Watchlists
+
+	//the first digest covers the changes from now on
+	if preferences.LastDigest.IsZero() {
+		preferences.LastDigest = time.Now().UTC()
+	}
+
+	err = pc.preferences.Save(preferences)
+
+	if err != nil {
+		return preferences, stockHttp.NewInternalServerError(err.Error())
+	}
+
+	return preferences, nil
+}
+
+//SetWatchlist stores the preferences of one watchlist of the user
+func (pc *PreferencesController) SetWatchlist(log *logrus.Entry, userID string, watchlistID primitive.ObjectID, request *model.WatchlistPreferencesRequest) (model.NotificationPreferences, error) {
+	_, err := getWatchlistOfUser(pc.watchlists, watchlistID, userID)
+
+	if err != nil {
+		message := "Cannot read watchlist " + err.Error()
+		log.Errorln(message)
+		return model.NotificationPreferences{}, stockHttp.NewBadRequestError(message)
+	}
+
+	watchlist := model.WatchlistPreferences{
+		WatchlistID:  watchlistID,
+		Disabled:     request.Disabled,
+		MutedSymbols: request.MutedSymbols,
+		SnoozeUntil:  request.SnoozeUntil,
+	}
+
+	err = service.ValidateWatchlistPreferences(&watchlist)
+
+	if err != nil {
+		return model.NotificationPreferences{}, stockHttp.NewBadRequestError(err.Error())
+	}
+
+	preferences, err := pc.Get(log, userID)
+
+	if err != nil {
+		return preferences, err
+	}
+
+	service.SetWatchlistPreferences(&preferences, watchlist)
+
+	err = pc.preferences.Save(preferences)
+
+	if err != nil {
+		return preferences, stockHttp.NewInternalServerError(err.Error())
+	}
+
+	return preferences, nil
+}
@@ -0,0 +1,94 @@
+package controllers
+
+import (
+	"context"
+
+	"github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	stockHttp "github.com/nagymarci/stock-commons/http"
+	"github.com/nagymarci/stock-watchlist/database"
+	"github.com/nagymarci/stock-watchlist/model"
+	"github.com/nagymarci/stock-watchlist/service"
+)
+
+type PriceAlertController struct {
+	priceAlerts *database.PriceAlerts
+	stockClient stockClient
+}
+
+func NewPriceAlertController(p *database.PriceAlerts, sc stockClient) *PriceAlertController {
+	return &PriceAlertController{
+		priceAlerts: p,
+		stockClient: sc,
+	}
+}
+
+//Create validates and stores a new price alert of the user. The symbol is registered at the stock screener,
+//so it is fetched by the notifier even if it is in no watchlist
+func (pc *PriceAlertController) Create(ctx context.Context, log *logrus.Entry, userID string, request *model.PriceAlertRequest) (*model.PriceAlert, error) {
+	err := service.ValidatePriceAlert(request)
+
+	if err != nil {
+		return nil, stockHttp.NewBadRequestError(err.Error())
+	}
+
+	err = pc.stockClient.RegisterStock(ctx, request.Symbol)
+
+	if err != nil {
+		return nil, stockHttp.NewFailedDependencyError(err.Error())
+	}
+
+	alert := model.PriceAlert{
+		UserID:    userID,
+		Symbol:    request.Symbol,
+		Condition: request.Condition,
+		Threshold: request.Threshold,
+		Recurring: request.Recurring,
+		Active:    true,
+	}
+
+	alert.ID, err = pc.priceAlerts.Create(alert)
+
+	if err != nil {
+		return nil, stockHttp.NewInternalServerError(err.Error())
+	}
+
+	return &alert, nil
+}
+
+//GetAll returns the price alerts of the user with their firings
+func (pc *PriceAlertController) GetAll(log *logrus.Entry, userID string) ([]model.PriceAlert, error) {
+	alerts, err := pc.priceAlerts.GetAll(userID)
+
+	if err != nil {
+		return nil, stockHttp.NewInternalServerError(err.Error())
+	}
+
+	if alerts == nil {
+		alerts = []model.PriceAlert{}
+	}
+
+	return alerts, nil
+}
+
+//Delete deletes the price alert if that belongs to the user
+func (pc *PriceAlertController) Delete(log *logrus.Entry, id primitive.ObjectID, userID string) error {
+	alert, err := pc.priceAlerts.Get(id)
+
+	if err != nil || alert.UserID != userID {
+		return stockHttp.NewNotFoundError("Price alert not found")
+	}
+
+	result, err := pc.priceAlerts.Delete(id)
+
+	if err != nil {
+		return stockHttp.NewInternalServerError(err.Error())
+	}
+
+	if result != 1 {
+		return stockHttp.NewInternalServerError("No object were removed from database")
+	}
+
+	return nil
+}
@@ -0,0 +1,34 @@
+package controllers
+
+import (
+	"github.com/sirupsen/logrus"
+
+	stockHttp "github.com/nagymarci/stock-commons/http"
+	"github.com/nagymarci/stock-watchlist/model"
+	"github.com/nagymarci/stock-watchlist/service"
+)
+
+type StatusController struct {
+	scheduler *service.Leader
+	notifier  *service.Notifier
+}
+
+func NewStatusController(s *service.Leader, n *service.Notifier) *StatusController {
+	return &StatusController{
+		scheduler: s,
+		notifier:  n,
+	}
+}
+
+//Get returns which instance runs the scheduled jobs, and the last notifier run of this instance
+func (sc *StatusController) Get(log *logrus.Entry) (model.SchedulerStatus, error) {
+	status, err := sc.scheduler.Status()
+
+	if err != nil {
+		return status, stockHttp.NewInternalServerError(err.Error())
+	}
+
+	status.LastRun = sc.notifier.LastRun()
+
+	return status, nil
+}
@@ -1,8 +1,12 @@
 package controllers
 
 import (
+	"context"
+	"time"
+
 	userprofileModel "github.com/nagymarci/stock-user-profile/model"
 	"github.com/nagymarci/stock-watchlist/api"
+	"github.com/nagymarci/stock-watchlist/database"
 	"github.com/nagymarci/stock-watchlist/model"
 	"github.com/nagymarci/stock-watchlist/service"
 	"github.com/sirupsen/logrus"
@@ -14,18 +18,20 @@ type StockController struct {
 	stockClient       *api.StockClient
 	userprofileClient *api.UserprofileClient
 	stockService      *service.StockService
+	snapshots         *database.Snapshots
 }
 
-func NewStockController(sc *api.StockClient, upC *api.UserprofileClient, ss *service.StockService) *StockController {
+func NewStockController(sc *api.StockClient, upC *api.UserprofileClient, ss *service.StockService, s *database.Snapshots) *StockController {
 	return &StockController{
 		stockClient:       sc,
 		userprofileClient: upC,
 		stockService:      ss,
+		snapshots:         s,
 	}
 }
 
-func (sc *StockController) GetAllCalculated(log *logrus.Entry, userID string) ([]model.CalculatedStockInfo, error) {
-	stocks, err := sc.stockClient.GetAll()
+func (sc *StockController) GetAllCalculated(ctx context.Context, log *logrus.Entry, userID string) ([]model.CalculatedStockInfo, error) {
+	stocks, err := sc.stockClient.GetAll(ctx)
 
 	if err != nil {
 		log.Errorln(err)
@@ -34,7 +40,7 @@ func (sc *StockController) GetAllCalculated(log *logrus.Entry, userID string) ([
 
 	var userprofile userprofileModel.Userprofile
 	if userID != "" {
-		userprofile, err = sc.userprofileClient.GetUserprofile(userID)
+		userprofile, err = sc.userprofileClient.GetUserprofile(ctx, userID)
 
 		if err != nil {
 			log.Errorln(err)
@@ -61,3 +67,37 @@ func (sc *StockController) GetAllCalculated(log *logrus.Entry, userID string) ([
 
 	return stockInfos, nil
 }
+
+//GetHistory returns the daily snapshots of the symbol between from and to
+func (sc *StockController) GetHistory(log *logrus.Entry, symbol string, from, to time.Time) ([]model.StockSnapshot, error) {
+	if to.Before(from) {
+		return nil, stockHttp.NewBadRequestError("'from' must not be after 'to'")
+	}
+
+	snapshots, err := sc.snapshots.Get(symbol, from, to)
+
+	if err != nil {
+		log.Errorln(err)
+		return nil, stockHttp.NewInternalServerError(err.Error())
+	}
+
+	if snapshots == nil {
+		snapshots = []model.StockSnapshot{}
+	}
+
+	return snapshots, nil
+}
+
+//GetSensitivity returns the sensitivity analysis of the symbol calculated with the default expectations
+func (sc *StockController) GetSensitivity(ctx context.Context, log *logrus.Entry, symbol string) (model.StockSensitivity, error) {
+	stock, err := sc.stockClient.Get(ctx, symbol)
+
+	if err != nil {
+		log.Errorln(err)
+		return model.StockSensitivity{}, stockHttp.NewFailedDependencyError(err.Error())
+	}
+
+	userprofile := service.DefaultUserprofile()
+
+	return sc.stockService.Sensitivity(&stock, userprofile.GetExpectation(symbol), *userprofile.ExpectedReturn), nil
+}
@@ -0,0 +1,89 @@
+package controllers
+
+import (
+	"errors"
+
+	"github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	stockHttp "github.com/nagymarci/stock-commons/http"
+	"github.com/nagymarci/stock-watchlist/database"
+	"github.com/nagymarci/stock-watchlist/model"
+	"github.com/nagymarci/stock-watchlist/service"
+)
+
+type UnsubscribeController struct {
+	preferences *database.Preferences
+	tokens      *service.UnsubscribeTokens
+}
+
+func NewUnsubscribeController(p *database.Preferences, t *service.UnsubscribeTokens) *UnsubscribeController {
+	return &UnsubscribeController{
+		preferences: p,
+		tokens:      t,
+	}
+}
+
+//Check verifies the token, and returns what it would disable without changing anything
+func (uc *UnsubscribeController) Check(log *logrus.Entry, token string) (model.UnsubscribeResult, error) {
+	_, watchlistID, err := uc.parse(token)
+
+	if err != nil {
+		return model.UnsubscribeResult{}, err
+	}
+
+	return model.UnsubscribeResult{WatchlistID: watchlistID}, nil
+}
+
+//Unsubscribe verifies the token, and disables the notifications of the watchlist it was issued for,
+//or all notifications of the user
+func (uc *UnsubscribeController) Unsubscribe(log *logrus.Entry, token string) (model.UnsubscribeResult, error) {
+	userID, watchlistID, err := uc.parse(token)
+
+	if err != nil {
+		return model.UnsubscribeResult{}, err
+	}
+
+	log = log.WithField("userId", userID)
+
+	preferences, err := uc.preferences.Get(userID)
+
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		preferences = service.DefaultNotificationPreferences(userID)
+	} else if err != nil {
+		return model.UnsubscribeResult{}, stockHttp.NewInternalServerError(err.Error())
+	}
+
+	if watchlistID.IsZero() {
+		preferences.Disabled = true
+	} else {
+		watchlist := service.WatchlistPreferencesOf(&preferences, watchlistID)
+		watchlist.Disabled = true
+		service.SetWatchlistPreferences(&preferences, watchlist)
+	}
+
+	err = uc.preferences.Save(preferences)
+
+	if err != nil {
+		return model.UnsubscribeResult{}, stockHttp.NewInternalServerError(err.Error())
+	}
+
+	log.Infof("Unsubscribed from notifications of [%s]", watchlistID.Hex())
+
+	return model.UnsubscribeResult{WatchlistID: watchlistID, Disabled: true}, nil
+}
+
+func (uc *UnsubscribeController) parse(token string) (string, primitive.ObjectID, error) {
+	if uc.tokens == nil {
+		return "", primitive.NilObjectID, stockHttp.NewNotFoundError("Unsubscribe links are not enabled")
+	}
+
+	userID, watchlistID, err := uc.tokens.Parse(token)
+
+	if err != nil {
+		return "", primitive.NilObjectID, stockHttp.NewBadRequestError(err.Error())
+	}
+
+	return userID, watchlistID, nil
+}
@@ -1,12 +1,16 @@
 package controllers
 
 import (
+	"context"
 	"errors"
+	"sync"
+	"time"
 
 	"github.com/sirupsen/logrus"
 
 	"go.mongodb.org/mongo-driver/bson/primitive"
 
+	"github.com/nagymarci/stock-watchlist/api"
 	"github.com/nagymarci/stock-watchlist/database"
 
 	"github.com/nagymarci/stock-watchlist/model"
@@ -16,37 +20,52 @@ import (
 	userprofileModel "github.com/nagymarci/stock-user-profile/model"
 )
 
+const (
+	//calculateTimeout is the deadline of fetching the stocks of a watchlist
+	calculateTimeout = 10 * time.Second
+	//calculateWorkers is the number of stocks fetched at the same time
+	calculateWorkers = 8
+)
+
 type WatchlistController struct {
 	watchlists        *database.Watchlists
 	stockClient       stockClient
 	userprofileClient userprofileClient
 	stockService      *service.StockService
+	calendar          *service.Calendar
 }
 
 type stockClient interface {
-	RegisterStock(symbol string) error
-	Get(symbol string) (model.StockData, error)
+	RegisterStock(ctx context.Context, symbol string) error
+	Get(ctx context.Context, symbol string) (model.StockData, error)
 }
 
 type userprofileClient interface {
-	GetUserprofile(userId string) (userprofileModel.Userprofile, error)
+	GetUserprofile(ctx context.Context, userId string) (userprofileModel.Userprofile, error)
 }
 
-func NewWatchlistController(w *database.Watchlists, sc stockClient, upc userprofileClient, ss *service.StockService) *WatchlistController {
+func NewWatchlistController(w *database.Watchlists, sc stockClient, upc userprofileClient, ss *service.StockService, c *service.Calendar) *WatchlistController {
 	return &WatchlistController{
 		watchlists:        w,
 		stockClient:       sc,
 		userprofileClient: upc,
 		stockService:      ss,
+		calendar:          c,
 	}
 }
 
 //Create creates a new watchlist
-func (wl *WatchlistController) Create(log *logrus.Entry, request *model.WatchlistRequest) (*model.Watchlist, error) {
+func (wl *WatchlistController) Create(ctx context.Context, log *logrus.Entry, request *model.WatchlistRequest) (*model.Watchlist, error) {
+	if request.Rule != nil {
+		if err := service.ValidateNotificationRule(request.Rule); err != nil {
+			return nil, stockHttp.NewBadRequestError(err.Error())
+		}
+	}
+
 	var addedStocks []string
 
 	for _, symbol := range request.Stocks {
-		err := wl.stockClient.RegisterStock(symbol)
+		err := wl.stockClient.RegisterStock(ctx, symbol)
 
 		if err != nil {
 			log.WithField("symbol", symbol).Warnln(err)
@@ -67,7 +86,8 @@ func (wl *WatchlistController) Create(log *logrus.Entry, request *model.Watchlis
 		ID:     id,
 		Name:   request.Name,
 		Stocks: request.Stocks,
-		UserID: request.UserID}
+		UserID: request.UserID,
+		Rule:   request.Rule}
 
 	return &watchlistResponse, err
 }
@@ -117,7 +137,97 @@ func (wl *WatchlistController) GetAll(log *logrus.Entry, userID string) ([]model
 	return watchlists, nil
 }
 
-func (wl *WatchlistController) GetCalculated(log *logrus.Entry, id primitive.ObjectID, userID string) ([]model.CalculatedStockInfo, error) {
+//GetCalculated returns the calculated stocks of the watchlist, and the stocks whose data could not be loaded
+func (wl *WatchlistController) GetCalculated(ctx context.Context, log *logrus.Entry, id primitive.ObjectID, userID string) (model.CalculatedWatchlist, error) {
+	watchlist, err := wl.getAndValidateUserAuthorization(id, userID)
+
+	if err != nil {
+		message := "Cannot read watchlist " + err.Error()
+		log.Errorln(message)
+		return model.CalculatedWatchlist{}, stockHttp.NewBadRequestError(message)
+	}
+
+	return wl.calculate(ctx, log, &watchlist, userID), nil
+}
+
+//SetRule sets the notification rule of the watchlist
+func (wl *WatchlistController) SetRule(log *logrus.Entry, id primitive.ObjectID, userID string, rule *model.NotificationRule) (model.Watchlist, error) {
+	watchlist, err := wl.getAndValidateUserAuthorization(id, userID)
+
+	if err != nil {
+		message := "Cannot read watchlist " + err.Error()
+		log.Errorln(message)
+		return model.Watchlist{}, stockHttp.NewBadRequestError(message)
+	}
+
+	if err := service.ValidateNotificationRule(rule); err != nil {
+		return model.Watchlist{}, stockHttp.NewBadRequestError(err.Error())
+	}
+
+	err = wl.watchlists.UpdateRule(id, *rule)
+
+	if err != nil {
+		return model.Watchlist{}, stockHttp.NewInternalServerError(err.Error())
+	}
+
+	watchlist.Rule = rule
+
+	return watchlist, nil
+}
+
+//SetSubscriptions sets the signal transitions the user is notified about
+func (wl *WatchlistController) SetSubscriptions(log *logrus.Entry, id primitive.ObjectID, userID string, subscriptions []model.SignalSubscription) (model.Watchlist, error) {
+	watchlist, err := wl.getAndValidateUserAuthorization(id, userID)
+
+	if err != nil {
+		message := "Cannot read watchlist " + err.Error()
+		log.Errorln(message)
+		return model.Watchlist{}, stockHttp.NewBadRequestError(message)
+	}
+
+	if err := service.ValidateSignalSubscriptions(subscriptions); err != nil {
+		return model.Watchlist{}, stockHttp.NewBadRequestError(err.Error())
+	}
+
+	err = wl.watchlists.UpdateSubscriptions(id, subscriptions)
+
+	if err != nil {
+		return model.Watchlist{}, stockHttp.NewInternalServerError(err.Error())
+	}
+
+	watchlist.Subscriptions = subscriptions
+
+	return watchlist, nil
+}
+
+//SetSchedule sets when the notifier checks the watchlist
+func (wl *WatchlistController) SetSchedule(log *logrus.Entry, id primitive.ObjectID, userID string, schedule *model.CheckSchedule) (model.Watchlist, error) {
+	watchlist, err := wl.getAndValidateUserAuthorization(id, userID)
+
+	if err != nil {
+		message := "Cannot read watchlist " + err.Error()
+		log.Errorln(message)
+		return model.Watchlist{}, stockHttp.NewBadRequestError(message)
+	}
+
+	if err := wl.calendar.ValidateCheckSchedule(schedule); err != nil {
+		return model.Watchlist{}, stockHttp.NewBadRequestError(err.Error())
+	}
+
+	err = wl.watchlists.UpdateSchedule(id, *schedule)
+
+	if err != nil {
+		return model.Watchlist{}, stockHttp.NewInternalServerError(err.Error())
+	}
+
+	watchlist.Schedule = schedule
+	watchlist.NextCheckAt = nil
+
+	return watchlist, nil
+}
+
+//PreviewRule returns the stocks of the watchlist currently matching its notification rule
+func (wl *WatchlistController) PreviewRule(ctx context.Context, log *logrus.Entry, id primitive.ObjectID, userID string) ([]model.CalculatedStockInfo, error) {
 	watchlist, err := wl.getAndValidateUserAuthorization(id, userID)
 
 	if err != nil {
@@ -126,22 +236,61 @@ func (wl *WatchlistController) GetCalculated(log *logrus.Entry, id primitive.Obj
 		return nil, stockHttp.NewBadRequestError(message)
 	}
 
-	var stockInfos []model.CalculatedStockInfo
+	rule := service.NotificationRuleOf(&watchlist)
+
+	result := service.FilterByRule(wl.calculate(ctx, log, &watchlist, userID).Items, &rule)
 
-	userprofile, err := wl.userprofileClient.GetUserprofile(userID)
+	if result == nil {
+		result = []model.CalculatedStockInfo{}
+	}
+
+	return result, nil
+}
+
+//calculate fetches the stocks of the watchlist concurrently within calculateTimeout, and calculates them
+//with the expectations of the user. The stocks that could not be fetched are listed with the reason
+func (wl *WatchlistController) calculate(ctx context.Context, log *logrus.Entry, watchlist *model.Watchlist, userID string) model.CalculatedWatchlist {
+	ctx, cancel := context.WithTimeout(ctx, calculateTimeout)
+	defer cancel()
+
+	userprofile, err := wl.userprofileClient.GetUserprofile(ctx, userID)
 
 	if err != nil {
 		log.Errorln(err)
-		defaultExpectation := 9.0
-		defaultExpectedReturn := 9.0
-		userprofile = userprofileModel.Userprofile{DefaultExpectation: &defaultExpectation, ExpectedReturn: &defaultExpectedReturn}
+		userprofile = service.DefaultUserprofile()
 	}
 
-	for _, symbol := range watchlist.Stocks {
-		result, err := wl.stockClient.Get(symbol)
+	stocks := make([]model.StockData, len(watchlist.Stocks))
+	errs := make([]error, len(watchlist.Stocks))
+	reasons := make([]string, len(watchlist.Stocks))
 
-		if err != nil {
-			log.Warnf("Failed to get stock [%s]: [%v]\n", symbol, err)
+	var wg sync.WaitGroup
+	workers := make(chan struct{}, calculateWorkers)
+
+	for i := range watchlist.Stocks {
+		wg.Add(1)
+		workers <- struct{}{}
+
+		go func(i int) {
+			defer wg.Done()
+			defer func() { <-workers }()
+
+			stocks[i], errs[i] = wl.stockClient.Get(ctx, watchlist.Stocks[i])
+
+			if errs[i] != nil {
+				reasons[i] = unavailableReason(ctx, errs[i])
+			}
+		}(i)
+	}
+
+	wg.Wait()
+
+	result := model.CalculatedWatchlist{Items: []model.CalculatedStockInfo{}, Unavailable: []model.UnavailableStock{}}
+
+	for i, symbol := range watchlist.Stocks {
+		if errs[i] != nil {
+			log.Warnf("Failed to get stock [%s]: [%v]\n", symbol, errs[i])
+			result.Unavailable = append(result.Unavailable, model.UnavailableStock{Symbol: symbol, Reason: reasons[i], Error: errs[i].Error()})
 			continue
 		}
 
@@ -149,16 +298,63 @@ func (wl *WatchlistController) GetCalculated(log *logrus.Entry, id primitive.Obj
 
 		log.Debugf("Symbol [%s] expectation [%f]\n", symbol, expectation)
 
-		calculatedStockInfo := wl.stockService.Calculate(&result, expectation, *userprofile.ExpectedReturn)
+		result.Items = append(result.Items, wl.stockService.Calculate(&stocks[i], expectation, *userprofile.ExpectedReturn))
+	}
 
-		stockInfos = append(stockInfos, calculatedStockInfo)
+	return result
+}
+
+//unavailableReason tells why the stock could not be fetched
+func unavailableReason(ctx context.Context, err error) string {
+	switch {
+	case errors.Is(err, api.ErrCircuitOpen):
+		return model.UnavailableCircuitOpen
+	case errors.Is(ctx.Err(), context.DeadlineExceeded):
+		return model.UnavailableTimeout
+	default:
+		return model.UnavailableUpstream
+	}
+}
+
+//GetSensitivity returns the sensitivity analysis of the stocks in the watchlist
+func (wl *WatchlistController) GetSensitivity(ctx context.Context, log *logrus.Entry, id primitive.ObjectID, userID string) ([]model.StockSensitivity, error) {
+	watchlist, err := wl.getAndValidateUserAuthorization(id, userID)
+
+	if err != nil {
+		message := "Cannot read watchlist " + err.Error()
+		log.Errorln(message)
+		return nil, stockHttp.NewBadRequestError(message)
+	}
+
+	userprofile, err := wl.userprofileClient.GetUserprofile(ctx, userID)
+
+	if err != nil {
+		log.Errorln(err)
+		userprofile = service.DefaultUserprofile()
+	}
+
+	var result []model.StockSensitivity
+
+	for _, symbol := range watchlist.Stocks {
+		stock, err := wl.stockClient.Get(ctx, symbol)
+
+		if err != nil {
+			log.Warnf("Failed to get stock [%s]: [%v]\n", symbol, err)
+			continue
+		}
+
+		result = append(result, wl.stockService.Sensitivity(&stock, userprofile.GetExpectation(symbol), *userprofile.ExpectedReturn))
 	}
 
-	return stockInfos, nil
+	return result, nil
 }
 
 func (w *WatchlistController) getAndValidateUserAuthorization(id primitive.ObjectID, userID string) (model.Watchlist, error) {
-	watchlist, err := w.watchlists.Get(id)
+	return getWatchlistOfUser(w.watchlists, id, userID)
+}
+
+func getWatchlistOfUser(watchlists *database.Watchlists, id primitive.ObjectID, userID string) (model.Watchlist, error) {
+	watchlist, err := watchlists.Get(id)
 	if err != nil {
 		return watchlist, err
 	}
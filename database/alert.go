@@ -0,0 +1,79 @@
+package database
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"github.com/nagymarci/stock-watchlist/model"
+)
+
+type AlertRules struct {
+	collection *mongo.Collection
+}
+
+func NewAlertRules(db *mongo.Database) *AlertRules {
+	return &AlertRules{
+		collection: db.Collection("alertRules"),
+	}
+}
+
+func (a *AlertRules) Create(rule model.AlertRule) (primitive.ObjectID, error) {
+	rule.ID = primitive.NewObjectID()
+
+	_, err := a.collection.InsertOne(context.TODO(), rule)
+
+	if err != nil {
+		return primitive.NilObjectID, err
+	}
+
+	return rule.ID, nil
+}
+
+func (a *AlertRules) Get(id primitive.ObjectID) (model.AlertRule, error) {
+	var result model.AlertRule
+
+	filter := bson.D{primitive.E{Key: "_id", Value: id}}
+
+	err := a.collection.FindOne(context.TODO(), filter).Decode(&result)
+
+	return result, err
+}
+
+func (a *AlertRules) GetByWatchlist(watchlistID primitive.ObjectID) ([]model.AlertRule, error) {
+	filter := bson.D{{Key: "watchlistId", Value: watchlistID}}
+
+	cursor, err := a.collection.Find(context.TODO(), filter)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var result []model.AlertRule
+	for cursor.Next(context.TODO()) {
+		var data model.AlertRule
+		cursor.Decode(&data)
+		result = append(result, data)
+	}
+
+	return result, err
+}
+
+func (a *AlertRules) UpdateMatching(id primitive.ObjectID, matching []string) error {
+	filter := bson.D{{Key: "_id", Value: id}}
+	update := bson.D{{Key: "$set", Value: bson.D{{Key: "matching", Value: matching}}}}
+
+	_, err := a.collection.UpdateOne(context.TODO(), filter, update)
+
+	return err
+}
+
+func (a *AlertRules) Delete(id primitive.ObjectID) (int64, error) {
+	filter := bson.D{{Key: "_id", Value: id}}
+
+	result, err := a.collection.DeleteOne(context.TODO(), filter)
+
+	return result.DeletedCount, err
+}
@@ -0,0 +1,70 @@
+package database
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"github.com/nagymarci/stock-watchlist/model"
+)
+
+type Channels struct {
+	collection *mongo.Collection
+}
+
+func NewChannels(db *mongo.Database) *Channels {
+	return &Channels{
+		collection: db.Collection("channels"),
+	}
+}
+
+func (c *Channels) Create(channel model.NotificationChannel) (primitive.ObjectID, error) {
+	channel.ID = primitive.NewObjectID()
+
+	_, err := c.collection.InsertOne(context.TODO(), channel)
+
+	if err != nil {
+		return primitive.NilObjectID, err
+	}
+
+	return channel.ID, nil
+}
+
+func (c *Channels) Get(id primitive.ObjectID) (model.NotificationChannel, error) {
+	var result model.NotificationChannel
+
+	filter := bson.D{primitive.E{Key: "_id", Value: id}}
+
+	err := c.collection.FindOne(context.TODO(), filter).Decode(&result)
+
+	return result, err
+}
+
+func (c *Channels) GetAll(userID string) ([]model.NotificationChannel, error) {
+	filter := bson.D{{Key: "userId", Value: userID}}
+
+	cursor, err := c.collection.Find(context.TODO(), filter)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var result []model.NotificationChannel
+	for cursor.Next(context.TODO()) {
+		var data model.NotificationChannel
+		cursor.Decode(&data)
+		result = append(result, data)
+	}
+
+	return result, err
+}
+
+func (c *Channels) Delete(id primitive.ObjectID) (int64, error) {
+	filter := bson.D{{Key: "_id", Value: id}}
+
+	result, err := c.collection.DeleteOne(context.TODO(), filter)
+
+	return result.DeletedCount, err
+}
@@ -0,0 +1,67 @@
+package database
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/nagymarci/stock-watchlist/model"
+)
+
+type DigestEvents struct {
+	collection *mongo.Collection
+}
+
+func NewDigestEvents(db *mongo.Database) *DigestEvents {
+	return &DigestEvents{
+		collection: db.Collection("digestEvents"),
+	}
+}
+
+func (d *DigestEvents) Add(event model.DigestEvent) error {
+	event.ID = primitive.NewObjectID()
+
+	_, err := d.collection.InsertOne(context.TODO(), event)
+
+	return err
+}
+
+//GetByUser returns the events of the user queued until the given time, oldest first
+func (d *DigestEvents) GetByUser(userID string, until time.Time) ([]model.DigestEvent, error) {
+	filter := bson.D{
+		{Key: "userId", Value: userID},
+		{Key: "createdAt", Value: bson.D{{Key: "$lte", Value: until}}},
+	}
+	opts := options.Find().SetSort(bson.D{{Key: "createdAt", Value: 1}, {Key: "_id", Value: 1}})
+
+	cursor, err := d.collection.Find(context.TODO(), filter, opts)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var result []model.DigestEvent
+	for cursor.Next(context.TODO()) {
+		var data model.DigestEvent
+		cursor.Decode(&data)
+		result = append(result, data)
+	}
+
+	return result, err
+}
+
+//DeleteByUser removes the events of the user queued until the given time
+func (d *DigestEvents) DeleteByUser(userID string, until time.Time) error {
+	filter := bson.D{
+		{Key: "userId", Value: userID},
+		{Key: "createdAt", Value: bson.D{{Key: "$lte", Value: until}}},
+	}
+
+	_, err := d.collection.DeleteMany(context.TODO(), filter)
+
+	return err
+}
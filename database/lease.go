@@ -0,0 +1,103 @@
+package database
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/nagymarci/stock-watchlist/model"
+)
+
+type Leases struct {
+	collection *mongo.Collection
+}
+
+func NewLeases(db *mongo.Database) *Leases {
+	return &Leases{
+		collection: db.Collection("leases"),
+	}
+}
+
+//TryAcquire takes the lease if it is free or expired, or renews it if the holder already has it.
+//It returns false if another holder has the lease
+func (l *Leases) TryAcquire(name string, holder string, now time.Time, ttl time.Duration) (bool, error) {
+	filter := bson.D{
+		{Key: "_id", Value: name},
+		{Key: "$or", Value: bson.A{
+			bson.D{{Key: "holder", Value: holder}},
+			bson.D{{Key: "expiresAt", Value: bson.D{{Key: "$lt", Value: now}}}},
+		}},
+	}
+	update := bson.D{{Key: "$set", Value: bson.D{
+		{Key: "holder", Value: holder},
+		{Key: "renewedAt", Value: now},
+		{Key: "expiresAt", Value: now.Add(ttl)},
+	}}}
+	opts := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.Before)
+
+	var previous model.Lease
+	err := l.collection.FindOneAndUpdate(context.TODO(), filter, update, opts).Decode(&previous)
+
+	//the upsert fails on the unique _id if the lease is held by someone else
+	if isDuplicateKey(err) {
+		return false, nil
+	}
+
+	if err != nil && err != mongo.ErrNoDocuments {
+		return false, err
+	}
+
+	if err == mongo.ErrNoDocuments || previous.Holder != holder {
+		acquired := bson.D{{Key: "$set", Value: bson.D{{Key: "acquiredAt", Value: now}}}}
+
+		_, err = l.collection.UpdateOne(context.TODO(), bson.D{primitive.E{Key: "_id", Value: name}}, acquired)
+
+		if err != nil {
+			return true, err
+		}
+	}
+
+	return true, nil
+}
+
+//Release gives up the lease if the holder has it
+func (l *Leases) Release(name string, holder string) error {
+	filter := bson.D{{Key: "_id", Value: name}, {Key: "holder", Value: holder}}
+
+	_, err := l.collection.DeleteOne(context.TODO(), filter)
+
+	return err
+}
+
+//Get returns the lease, mongo.ErrNoDocuments if nobody holds it
+func (l *Leases) Get(name string) (model.Lease, error) {
+	var result model.Lease
+
+	filter := bson.D{primitive.E{Key: "_id", Value: name}}
+
+	err := l.collection.FindOne(context.TODO(), filter).Decode(&result)
+
+	return result, err
+}
+
+//duplicateKeyCode is the server error code of unique index violations
+const duplicateKeyCode = 11000
+
+func isDuplicateKey(err error) bool {
+	switch e := err.(type) {
+	case mongo.CommandError:
+		return e.Code == duplicateKeyCode
+	case mongo.WriteException:
+		for _, we := range e.WriteErrors {
+			if we.Code == duplicateKeyCode {
+				return true
+			}
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,73 @@
+package database
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/nagymarci/stock-watchlist/model"
+)
+
+type Notifications struct {
+	collection *mongo.Collection
+}
+
+func NewNotifications(db *mongo.Database) *Notifications {
+	return &Notifications{
+		collection: db.Collection("notifications"),
+	}
+}
+
+func (n *Notifications) Add(record model.NotificationRecord) error {
+	record.ID = primitive.NewObjectID()
+
+	_, err := n.collection.InsertOne(context.TODO(), record)
+
+	return err
+}
+
+//Find returns the page of the records matching the query, and the number of all matching records
+func (n *Notifications) Find(query model.NotificationQuery) ([]model.NotificationRecord, int64, error) {
+	filter := bson.D{}
+
+	if query.UserID != "" {
+		filter = append(filter, bson.E{Key: "userId", Value: query.UserID})
+	}
+
+	if !query.WatchlistID.IsZero() {
+		filter = append(filter, bson.E{Key: "watchlistId", Value: query.WatchlistID})
+	}
+
+	if query.Status != "" {
+		filter = append(filter, bson.E{Key: "status", Value: query.Status})
+	}
+
+	total, err := n.collection.CountDocuments(context.TODO(), filter)
+
+	if err != nil {
+		return nil, 0, err
+	}
+
+	opts := options.Find().
+		SetSort(bson.D{{Key: "createdAt", Value: -1}, {Key: "_id", Value: -1}}).
+		SetSkip((query.Page - 1) * query.PageSize).
+		SetLimit(query.PageSize)
+
+	cursor, err := n.collection.Find(context.TODO(), filter, opts)
+
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var result []model.NotificationRecord
+	for cursor.Next(context.TODO()) {
+		var data model.NotificationRecord
+		cursor.Decode(&data)
+		result = append(result, data)
+	}
+
+	return result, total, err
+}
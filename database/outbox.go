@@ -0,0 +1,126 @@
+package database
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/nagymarci/stock-watchlist/model"
+)
+
+type Outbox struct {
+	collection *mongo.Collection
+}
+
+func NewOutbox(db *mongo.Database) *Outbox {
+	return &Outbox{
+		collection: db.Collection("outbox"),
+	}
+}
+
+func (o *Outbox) Add(entry model.OutboxEntry) error {
+	entry.ID = primitive.NewObjectID()
+
+	_, err := o.collection.InsertOne(context.TODO(), entry)
+
+	return err
+}
+
+//Claim returns the pending entry due the longest, and postpones its next attempt
+//until claimedUntil, so no other worker picks it up meanwhile.
+//It returns mongo.ErrNoDocuments if no entry is due
+func (o *Outbox) Claim(now time.Time, claimedUntil time.Time) (model.OutboxEntry, error) {
+	var result model.OutboxEntry
+
+	filter := bson.D{
+		{Key: "status", Value: model.OutboxPending},
+		{Key: "nextAttemptAt", Value: bson.D{{Key: "$lte", Value: now}}},
+	}
+	update := bson.D{{Key: "$set", Value: bson.D{{Key: "nextAttemptAt", Value: claimedUntil}}}}
+	opts := options.FindOneAndUpdate().SetSort(bson.D{{Key: "nextAttemptAt", Value: 1}}).SetReturnDocument(options.After)
+
+	err := o.collection.FindOneAndUpdate(context.TODO(), filter, update, opts).Decode(&result)
+
+	return result, err
+}
+
+//MarkFailed stores the result of a failed attempt, and the channels to retry the delivery through
+func (o *Outbox) MarkFailed(id primitive.ObjectID, attempts int, lastError string, status string, nextAttemptAt time.Time, channels []primitive.ObjectID) error {
+	filter := bson.D{primitive.E{Key: "_id", Value: id}}
+	update := bson.D{{Key: "$set", Value: bson.D{
+		{Key: "attempts", Value: attempts},
+		{Key: "lastError", Value: lastError},
+		{Key: "status", Value: status},
+		{Key: "nextAttemptAt", Value: nextAttemptAt},
+		{Key: "watchlist.channels", Value: channels},
+	}}}
+
+	_, err := o.collection.UpdateOne(context.TODO(), filter, update)
+
+	return err
+}
+
+//Requeue resets a dead-lettered entry, it returns the number of entries requeued
+func (o *Outbox) Requeue(id primitive.ObjectID, now time.Time) (int64, error) {
+	filter := bson.D{{Key: "_id", Value: id}, {Key: "status", Value: model.OutboxDead}}
+	update := bson.D{{Key: "$set", Value: bson.D{
+		{Key: "attempts", Value: 0},
+		{Key: "status", Value: model.OutboxPending},
+		{Key: "nextAttemptAt", Value: now},
+	}}}
+
+	result, err := o.collection.UpdateOne(context.TODO(), filter, update)
+
+	if err != nil {
+		return 0, err
+	}
+
+	return result.ModifiedCount, nil
+}
+
+//Find returns the page of the entries with the status (every entry if empty), and the number of all of them
+func (o *Outbox) Find(status string, page int64, pageSize int64) ([]model.OutboxEntry, int64, error) {
+	filter := bson.D{}
+
+	if status != "" {
+		filter = append(filter, bson.E{Key: "status", Value: status})
+	}
+
+	total, err := o.collection.CountDocuments(context.TODO(), filter)
+
+	if err != nil {
+		return nil, 0, err
+	}
+
+	opts := options.Find().
+		SetSort(bson.D{{Key: "createdAt", Value: 1}, {Key: "_id", Value: 1}}).
+		SetSkip((page - 1) * pageSize).
+		SetLimit(pageSize)
+
+	cursor, err := o.collection.Find(context.TODO(), filter, opts)
+
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var result []model.OutboxEntry
+	for cursor.Next(context.TODO()) {
+		var data model.OutboxEntry
+		cursor.Decode(&data)
+		result = append(result, data)
+	}
+
+	return result, total, err
+}
+
+func (o *Outbox) Delete(id primitive.ObjectID) error {
+	filter := bson.D{{Key: "_id", Value: id}}
+
+	_, err := o.collection.DeleteOne(context.TODO(), filter)
+
+	return err
+}
@@ -0,0 +1,69 @@
+package database
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/nagymarci/stock-watchlist/model"
+)
+
+type Preferences struct {
+	collection *mongo.Collection
+}
+
+func NewPreferences(db *mongo.Database) *Preferences {
+	return &Preferences{
+		collection: db.Collection("notificationPreferences"),
+	}
+}
+
+//Get returns the preferences of the user, mongo.ErrNoDocuments if the user has not set them
+func (p *Preferences) Get(userID string) (model.NotificationPreferences, error) {
+	var result model.NotificationPreferences
+
+	filter := bson.D{primitive.E{Key: "_id", Value: userID}}
+
+	err := p.collection.FindOne(context.TODO(), filter).Decode(&result)
+
+	return result, err
+}
+
+func (p *Preferences) List() ([]model.NotificationPreferences, error) {
+	cursor, err := p.collection.Find(context.TODO(), bson.D{})
+
+	if err != nil {
+		return nil, err
+	}
+
+	var result []model.NotificationPreferences
+	for cursor.Next(context.TODO()) {
+		var data model.NotificationPreferences
+		cursor.Decode(&data)
+		result = append(result, data)
+	}
+
+	return result, err
+}
+
+func (p *Preferences) Save(preferences model.NotificationPreferences) error {
+	filter := bson.D{primitive.E{Key: "_id", Value: preferences.UserID}}
+	opts := options.Replace().SetUpsert(true)
+
+	_, err := p.collection.ReplaceOne(context.TODO(), filter, preferences, opts)
+
+	return err
+}
+
+func (p *Preferences) UpdateLastDigest(userID string, sent time.Time) error {
+	filter := bson.D{primitive.E{Key: "_id", Value: userID}}
+	update := bson.D{{Key: "$set", Value: bson.D{{Key: "lastDigest", Value: sent}}}}
+
+	_, err := p.collection.UpdateOne(context.TODO(), filter, update)
+
+	return err
+}
@@ -0,0 +1,109 @@
+package database
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"github.com/nagymarci/stock-watchlist/model"
+)
+
+//maxPriceAlertFirings is the number of firings kept for each alert
+const maxPriceAlertFirings = 20
+
+type PriceAlerts struct {
+	collection *mongo.Collection
+}
+
+func NewPriceAlerts(db *mongo.Database) *PriceAlerts {
+	return &PriceAlerts{
+		collection: db.Collection("priceAlerts"),
+	}
+}
+
+func (p *PriceAlerts) Create(alert model.PriceAlert) (primitive.ObjectID, error) {
+	alert.ID = primitive.NewObjectID()
+
+	_, err := p.collection.InsertOne(context.TODO(), alert)
+
+	if err != nil {
+		return primitive.NilObjectID, err
+	}
+
+	return alert.ID, nil
+}
+
+func (p *PriceAlerts) Get(id primitive.ObjectID) (model.PriceAlert, error) {
+	var result model.PriceAlert
+
+	filter := bson.D{primitive.E{Key: "_id", Value: id}}
+
+	err := p.collection.FindOne(context.TODO(), filter).Decode(&result)
+
+	return result, err
+}
+
+func (p *PriceAlerts) GetAll(userID string) ([]model.PriceAlert, error) {
+	return p.find(bson.D{{Key: "userId", Value: userID}})
+}
+
+//GetActive returns the alerts of every user that can still fire
+func (p *PriceAlerts) GetActive() ([]model.PriceAlert, error) {
+	return p.find(bson.D{{Key: "active", Value: true}})
+}
+
+func (p *PriceAlerts) find(filter bson.D) ([]model.PriceAlert, error) {
+	cursor, err := p.collection.Find(context.TODO(), filter)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var result []model.PriceAlert
+	for cursor.Next(context.TODO()) {
+		var data model.PriceAlert
+		cursor.Decode(&data)
+		result = append(result, data)
+	}
+
+	return result, err
+}
+
+func (p *PriceAlerts) UpdateTriggered(id primitive.ObjectID, triggered bool) error {
+	filter := bson.D{primitive.E{Key: "_id", Value: id}}
+	update := bson.D{{Key: "$set", Value: bson.D{{Key: "triggered", Value: triggered}}}}
+
+	_, err := p.collection.UpdateOne(context.TODO(), filter, update)
+
+	return err
+}
+
+//RecordFiring stores the firing of the alert, keeping the latest ones only
+func (p *PriceAlerts) RecordFiring(id primitive.ObjectID, firing model.PriceAlertFiring, active bool) error {
+	filter := bson.D{primitive.E{Key: "_id", Value: id}}
+	update := bson.D{
+		{Key: "$set", Value: bson.D{
+			{Key: "triggered", Value: true},
+			{Key: "active", Value: active},
+			{Key: "lastFired", Value: firing.At},
+		}},
+		{Key: "$push", Value: bson.D{{Key: "firings", Value: bson.D{
+			{Key: "$each", Value: []model.PriceAlertFiring{firing}},
+			{Key: "$slice", Value: -maxPriceAlertFirings},
+		}}}},
+	}
+
+	_, err := p.collection.UpdateOne(context.TODO(), filter, update)
+
+	return err
+}
+
+func (p *PriceAlerts) Delete(id primitive.ObjectID) (int64, error) {
+	filter := bson.D{{Key: "_id", Value: id}}
+
+	result, err := p.collection.DeleteOne(context.TODO(), filter)
+
+	return result.DeletedCount, err
+}
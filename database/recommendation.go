@@ -2,6 +2,7 @@ package database
 
 import (
 	"context"
+	"sort"
 
 	"github.com/sirupsen/logrus"
 
@@ -9,15 +10,32 @@ import (
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/nagymarci/stock-watchlist/model"
 )
 
 type Recommendations struct {
 	collection *mongo.Collection
 }
 
+//recommendation holds the recommended stocks of a watchlist, the last seen signals of its stocks,
+//and the stocks waiting for the dwell time of the hysteresis. Signals and pending changes are stored
+//as arrays, as symbols like BRK.B can not be used as field names
 type recommendation struct {
-	ID     primitive.ObjectID `bson:"_id"`
-	Stocks []string           `bson:"stocks"`
+	ID      primitive.ObjectID `bson:"_id"`
+	Stocks  []string           `bson:"stocks"`
+	Signals []symbolSignals    `bson:"signals,omitempty"`
+	Pending []symbolPending    `bson:"pending,omitempty"`
+}
+
+type symbolSignals struct {
+	Symbol string            `bson:"symbol"`
+	State  model.SignalState `bson:"state"`
+}
+
+type symbolPending struct {
+	Symbol              string `bson:"symbol"`
+	model.PendingChange `bson:",inline"`
 }
 
 func NewRecommendations(db *mongo.Database) *Recommendations {
@@ -43,9 +61,10 @@ func (r *Recommendations) Get(id primitive.ObjectID) ([]string, error) {
 
 func (r *Recommendations) Update(log *logrus.Entry, id primitive.ObjectID, stocks []string) error {
 	filter := bson.D{primitive.E{Key: "_id", Value: id}}
-	opts := options.Replace().SetUpsert(true)
+	update := bson.D{{Key: "$set", Value: bson.D{{Key: "stocks", Value: stocks}}}}
+	opts := options.Update().SetUpsert(true)
 
-	_, err := r.collection.ReplaceOne(context.TODO(), filter, recommendation{ID: id, Stocks: stocks}, opts)
+	_, err := r.collection.UpdateOne(context.TODO(), filter, update, opts)
 
 	if err != nil {
 		log.Errorln(err)
@@ -55,3 +74,81 @@ func (r *Recommendations) Update(log *logrus.Entry, id primitive.ObjectID, stock
 	log.Infoln("recommendation inserted into DB")
 	return nil
 }
+
+//GetSignals returns the last seen signals of the stocks of the watchlist by symbol
+func (r *Recommendations) GetSignals(id primitive.ObjectID) (map[string]model.SignalState, error) {
+	filter := bson.D{primitive.E{Key: "_id", Value: id}}
+
+	var result recommendation
+	err := r.collection.FindOne(context.TODO(), filter).Decode(&result)
+
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+
+	if err != nil || result.Signals == nil {
+		return nil, err
+	}
+
+	signals := make(map[string]model.SignalState, len(result.Signals))
+	for _, signal := range result.Signals {
+		signals[signal.Symbol] = signal.State
+	}
+
+	return signals, nil
+}
+
+func (r *Recommendations) UpdateSignals(id primitive.ObjectID, signals map[string]model.SignalState) error {
+	stored := []symbolSignals{}
+	for symbol, state := range signals {
+		stored = append(stored, symbolSignals{Symbol: symbol, State: state})
+	}
+	sort.Slice(stored, func(i, j int) bool { return stored[i].Symbol < stored[j].Symbol })
+
+	filter := bson.D{primitive.E{Key: "_id", Value: id}}
+	update := bson.D{{Key: "$set", Value: bson.D{{Key: "signals", Value: stored}}}}
+	opts := options.Update().SetUpsert(true)
+
+	_, err := r.collection.UpdateOne(context.TODO(), filter, update, opts)
+
+	return err
+}
+
+//GetPending returns the stocks of the watchlist waiting for the dwell time to enter or leave the recommendations
+func (r *Recommendations) GetPending(id primitive.ObjectID) (map[string]model.PendingChange, error) {
+	filter := bson.D{primitive.E{Key: "_id", Value: id}}
+
+	var result recommendation
+	err := r.collection.FindOne(context.TODO(), filter).Decode(&result)
+
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+
+	if err != nil || result.Pending == nil {
+		return nil, err
+	}
+
+	pending := make(map[string]model.PendingChange, len(result.Pending))
+	for _, change := range result.Pending {
+		pending[change.Symbol] = change.PendingChange
+	}
+
+	return pending, nil
+}
+
+func (r *Recommendations) UpdatePending(id primitive.ObjectID, pending map[string]model.PendingChange) error {
+	stored := []symbolPending{}
+	for symbol, change := range pending {
+		stored = append(stored, symbolPending{Symbol: symbol, PendingChange: change})
+	}
+	sort.Slice(stored, func(i, j int) bool { return stored[i].Symbol < stored[j].Symbol })
+
+	filter := bson.D{primitive.E{Key: "_id", Value: id}}
+	update := bson.D{{Key: "$set", Value: bson.D{{Key: "pending", Value: stored}}}}
+	opts := options.Update().SetUpsert(true)
+
+	_, err := r.collection.UpdateOne(context.TODO(), filter, update, opts)
+
+	return err
+}
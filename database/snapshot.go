@@ -0,0 +1,59 @@
+package database
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+
+	"github.com/nagymarci/stock-watchlist/model"
+)
+
+type Snapshots struct {
+	collection *mongo.Collection
+}
+
+func NewSnapshots(db *mongo.Database) *Snapshots {
+	return &Snapshots{
+		collection: db.Collection("snapshots"),
+	}
+}
+
+//Save stores the snapshot, replacing the one already saved for the same symbol and day
+func (s *Snapshots) Save(snapshot model.StockSnapshot) error {
+	snapshot.ID = snapshot.Symbol + "-" + snapshot.Date.Format("2006-01-02")
+
+	filter := bson.D{primitive.E{Key: "_id", Value: snapshot.ID}}
+	opts := options.Replace().SetUpsert(true)
+
+	_, err := s.collection.ReplaceOne(context.TODO(), filter, snapshot, opts)
+
+	return err
+}
+
+//Get returns the snapshots of the symbol between from and to (inclusive), ordered by date
+func (s *Snapshots) Get(symbol string, from, to time.Time) ([]model.StockSnapshot, error) {
+	filter := bson.D{
+		{Key: "symbol", Value: symbol},
+		{Key: "date", Value: bson.D{{Key: "$gte", Value: from}, {Key: "$lte", Value: to}}},
+	}
+	opts := options.Find().SetSort(bson.D{{Key: "date", Value: 1}})
+
+	cursor, err := s.collection.Find(context.TODO(), filter, opts)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var result []model.StockSnapshot
+	for cursor.Next(context.TODO()) {
+		var data model.StockSnapshot
+		cursor.Decode(&data)
+		result = append(result, data)
+	}
+
+	return result, err
+}
@@ -2,6 +2,7 @@ package database
 
 import (
 	"context"
+	"time"
 
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
@@ -48,6 +49,55 @@ func (w *Watchlists) Delete(id primitive.ObjectID) (int64, error) {
 	return result.DeletedCount, err
 }
 
+func (w *Watchlists) UpdateRule(id primitive.ObjectID, rule model.NotificationRule) error {
+	filter := bson.D{{Key: "_id", Value: id}}
+	update := bson.D{{Key: "$set", Value: bson.D{{Key: "rule", Value: rule}}}}
+
+	_, err := w.collection.UpdateOne(context.TODO(), filter, update)
+
+	return err
+}
+
+func (w *Watchlists) UpdateChannels(id primitive.ObjectID, channels []primitive.ObjectID) error {
+	filter := bson.D{{Key: "_id", Value: id}}
+	update := bson.D{{Key: "$set", Value: bson.D{{Key: "channels", Value: channels}}}}
+
+	_, err := w.collection.UpdateOne(context.TODO(), filter, update)
+
+	return err
+}
+
+func (w *Watchlists) UpdateSubscriptions(id primitive.ObjectID, subscriptions []model.SignalSubscription) error {
+	filter := bson.D{{Key: "_id", Value: id}}
+	update := bson.D{{Key: "$set", Value: bson.D{{Key: "subscriptions", Value: subscriptions}}}}
+
+	_, err := w.collection.UpdateOne(context.TODO(), filter, update)
+
+	return err
+}
+
+//UpdateSchedule sets the check schedule of the watchlist, its next check is computed again by the scheduler
+func (w *Watchlists) UpdateSchedule(id primitive.ObjectID, schedule model.CheckSchedule) error {
+	filter := bson.D{{Key: "_id", Value: id}}
+	update := bson.D{
+		{Key: "$set", Value: bson.D{{Key: "schedule", Value: schedule}}},
+		{Key: "$unset", Value: bson.D{{Key: "nextCheckAt", Value: ""}}},
+	}
+
+	_, err := w.collection.UpdateOne(context.TODO(), filter, update)
+
+	return err
+}
+
+func (w *Watchlists) UpdateNextCheck(id primitive.ObjectID, next time.Time) error {
+	filter := bson.D{{Key: "_id", Value: id}}
+	update := bson.D{{Key: "$set", Value: bson.D{{Key: "nextCheckAt", Value: next}}}}
+
+	_, err := w.collection.UpdateOne(context.TODO(), filter, update)
+
+	return err
+}
+
 func (w *Watchlists) GetAll(userID string) ([]model.Watchlist, error) {
 	filter := bson.D{{Key: "userId", Value: userID}}
 
@@ -68,6 +118,38 @@ func (w *Watchlists) GetAll(userID string) ([]model.Watchlist, error) {
 	return result, err
 }
 
+//CreateIndexes creates the index of the next check, used to look for the due watchlists
+func (w *Watchlists) CreateIndexes() error {
+	_, err := w.collection.Indexes().CreateOne(context.TODO(), mongo.IndexModel{
+		Keys: bson.D{{Key: "nextCheckAt", Value: 1}},
+	})
+
+	return err
+}
+
+//ListDue returns the watchlists whose next check is not after now, and the ones without next check
+func (w *Watchlists) ListDue(now time.Time) ([]model.Watchlist, error) {
+	filter := bson.D{{Key: "$or", Value: bson.A{
+		bson.D{{Key: "nextCheckAt", Value: bson.D{{Key: "$lte", Value: now}}}},
+		bson.D{{Key: "nextCheckAt", Value: nil}},
+	}}}
+
+	cursor, err := w.collection.Find(context.TODO(), filter)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var result []model.Watchlist
+	for cursor.Next(context.TODO()) {
+		var data model.Watchlist
+		cursor.Decode(&data)
+		result = append(result, data)
+	}
+
+	return result, err
+}
+
 func (w *Watchlists) List() ([]model.Watchlist, error) {
 	cursor, err := w.collection.Find(context.TODO(), bson.M{})
 
@@ -0,0 +1,147 @@
+package expr
+
+import (
+	"fmt"
+)
+
+//Env holds the values of the identifiers, a value is a float64, a string or a bool
+type Env map[string]interface{}
+
+//Eval evaluates the expression, the result has to be a bool
+func (e *Expression) Eval(env Env) (bool, error) {
+	value, err := eval(e.root, env)
+
+	if err != nil {
+		return false, err
+	}
+
+	result, ok := value.(bool)
+
+	if !ok {
+		return false, fmt.Errorf("Expression [%s] is not a condition", e.source)
+	}
+
+	return result, nil
+}
+
+func eval(n node, env Env) (interface{}, error) {
+	switch n := n.(type) {
+	case numberNode:
+		return float64(n), nil
+	case stringNode:
+		return string(n), nil
+	case boolNode:
+		return bool(n), nil
+	case identNode:
+		value, ok := env[string(n)]
+		if !ok {
+			return nil, fmt.Errorf("Missing value of [%s]", string(n))
+		}
+		return value, nil
+	case unaryNode:
+		return evalUnary(n, env)
+	case binaryNode:
+		return evalBinary(n, env)
+	}
+
+	return nil, fmt.Errorf("Unknown expression [%v]", n)
+}
+
+func evalUnary(n unaryNode, env Env) (interface{}, error) {
+	operand, err := eval(n.operand, env)
+	if err != nil {
+		return nil, err
+	}
+
+	switch n.operator {
+	case "not":
+		value, ok := operand.(bool)
+		if !ok {
+			return nil, fmt.Errorf("'not' needs a condition, got [%v]", operand)
+		}
+		return !value, nil
+	case "-":
+		value, ok := operand.(float64)
+		if !ok {
+			return nil, fmt.Errorf("'-' needs a number, got [%v]", operand)
+		}
+		return -value, nil
+	}
+
+	return nil, fmt.Errorf("Unknown operator [%s]", n.operator)
+}
+
+func evalBinary(n binaryNode, env Env) (interface{}, error) {
+	left, err := eval(n.left, env)
+	if err != nil {
+		return nil, err
+	}
+
+	//short circuit the logical operators
+	if n.operator == "and" || n.operator == "or" {
+		l, ok := left.(bool)
+		if !ok {
+			return nil, fmt.Errorf("'%s' needs conditions, got [%v]", n.operator, left)
+		}
+
+		if (n.operator == "and" && !l) || (n.operator == "or" && l) {
+			return l, nil
+		}
+
+		right, err := eval(n.right, env)
+		if err != nil {
+			return nil, err
+		}
+
+		r, ok := right.(bool)
+		if !ok {
+			return nil, fmt.Errorf("'%s' needs conditions, got [%v]", n.operator, right)
+		}
+
+		return r, nil
+	}
+
+	right, err := eval(n.right, env)
+	if err != nil {
+		return nil, err
+	}
+
+	if n.operator == "==" || n.operator == "!=" {
+		if fmt.Sprintf("%T", left) != fmt.Sprintf("%T", right) {
+			return nil, fmt.Errorf("Cannot compare [%v] and [%v]", left, right)
+		}
+
+		return (left == right) == (n.operator == "=="), nil
+	}
+
+	l, lok := left.(float64)
+	r, rok := right.(float64)
+
+	if !lok || !rok {
+		return nil, fmt.Errorf("'%s' needs numbers, got [%v] and [%v]", n.operator, left, right)
+	}
+
+	switch n.operator {
+	case "+":
+		return l + r, nil
+	case "-":
+		return l - r, nil
+	case "*":
+		return l * r, nil
+	case "/":
+		if r == 0 {
+			return nil, fmt.Errorf("Division by zero")
+		}
+		return l / r, nil
+	case "<":
+		return l < r, nil
+	case "<=":
+		return l <= r, nil
+	case ">":
+		return l > r, nil
+	case ">=":
+		return l >= r, nil
+	}
+
+	return nil, fmt.Errorf("Unknown operator [%s]", n.operator)
+}
@@ -0,0 +1,99 @@
+package expr
+
+import (
+	"testing"
+)
+
+var fields = []string{"price", "optInPrice", "dividendYield", "currentPe", "optInPe", "priceColor"}
+
+func TestEval(t *testing.T) {
+	env := Env{"price": 90.0, "optInPrice": 110.0, "dividendYield": 4.5, "currentPe": 12.0, "optInPe": 14.0, "priceColor": "green"}
+
+	cases := []struct {
+		source   string
+		expected bool
+	}{
+		{"dividendYield > 4 and currentPe < optInPe", true},
+		{"price < 0.9 * optInPrice", true},
+		{"price < 0.8 * optInPrice", false},
+		{"priceColor == 'green' && !(currentPe >= optInPe)", true},
+		{"priceColor != \"green\" or price - -10 == 100", true},
+		{"not (price > 100) and (optInPrice - price) / price > 0.2", true},
+		{"false or dividendYield <= 4.5", true},
+	}
+
+	for _, c := range cases {
+		e, err := Compile(c.source, fields)
+
+		if err != nil {
+			t.Fatalf("failed to compile [%s]: [%v]", c.source, err)
+		}
+
+		result, err := e.Eval(env)
+
+		if err != nil {
+			t.Fatalf("failed to evaluate [%s]: [%v]", c.source, err)
+		}
+
+		if result != c.expected {
+			t.Errorf("expected [%s] to be [%v], got [%v]", c.source, c.expected, result)
+		}
+	}
+}
+
+func TestCompileErrors(t *testing.T) {
+	cases := []string{
+		"",
+		"price <",
+		"unknownField > 1",
+		"price < 1 < 2",
+		"(price > 1",
+		"price > 1)",
+		"price; optInPrice",
+		"os.Exit(1)",
+		"'unterminated",
+	}
+
+	for _, source := range cases {
+		if _, err := Compile(source, fields); err == nil {
+			t.Errorf("expected error compiling [%s]", source)
+		}
+	}
+
+	deep := ""
+	for i := 0; i < maxDepth+1; i++ {
+		deep += "("
+	}
+	deep += "price"
+	for i := 0; i < maxDepth+1; i++ {
+		deep += ")"
+	}
+
+	if _, err := Compile(deep+" > 1", fields); err == nil {
+		t.Error("expected error for deeply nested expression")
+	}
+}
+
+func TestEvalErrors(t *testing.T) {
+	env := Env{"price": 90.0, "optInPrice": 0.0, "priceColor": "green"}
+
+	cases := []string{
+		"price / optInPrice > 1",
+		"price + 1",
+		"priceColor > 1",
+		"priceColor == 1",
+		"price and true",
+	}
+
+	for _, source := range cases {
+		e, err := Compile(source, fields)
+
+		if err != nil {
+			t.Fatalf("failed to compile [%s]: [%v]", source, err)
+		}
+
+		if _, err := e.Eval(env); err == nil {
+			t.Errorf("expected error evaluating [%s]", source)
+		}
+	}
+}
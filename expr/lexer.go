@@ -0,0 +1,88 @@
+package expr
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenNumber
+	tokenString
+	tokenIdent
+	tokenOperator
+	tokenLeftParen
+	tokenRightParen
+)
+
+type token struct {
+	kind   tokenKind
+	text   string
+	number float64
+	pos    int
+}
+
+var operators = []string{"<=", ">=", "==", "!=", "&&", "||", "<", ">", "+", "-", "*", "/", "!"}
+
+func tokenize(source string) ([]token, error) {
+	var result []token
+
+	for pos := 0; pos < len(source); {
+		c := rune(source[pos])
+
+		switch {
+		case unicode.IsSpace(c):
+			pos++
+		case c == '(':
+			result = append(result, token{kind: tokenLeftParen, text: "(", pos: pos})
+			pos++
+		case c == ')':
+			result = append(result, token{kind: tokenRightParen, text: ")", pos: pos})
+			pos++
+		case c == '"' || c == '\'':
+			end := strings.IndexRune(source[pos+1:], c)
+			if end < 0 {
+				return nil, fmt.Errorf("Unterminated string at position [%d]", pos)
+			}
+			result = append(result, token{kind: tokenString, text: source[pos+1 : pos+1+end], pos: pos})
+			pos += end + 2
+		case unicode.IsDigit(c) || c == '.':
+			end := pos
+			for end < len(source) && (unicode.IsDigit(rune(source[end])) || source[end] == '.') {
+				end++
+			}
+			number, err := strconv.ParseFloat(source[pos:end], 64)
+			if err != nil {
+				return nil, fmt.Errorf("Invalid number [%s] at position [%d]", source[pos:end], pos)
+			}
+			result = append(result, token{kind: tokenNumber, text: source[pos:end], number: number, pos: pos})
+			pos = end
+		case unicode.IsLetter(c) || c == '_':
+			end := pos
+			for end < len(source) && (unicode.IsLetter(rune(source[end])) || unicode.IsDigit(rune(source[end])) || source[end] == '_') {
+				end++
+			}
+			result = append(result, token{kind: tokenIdent, text: source[pos:end], pos: pos})
+			pos = end
+		default:
+			operator := ""
+			for _, op := range operators {
+				if strings.HasPrefix(source[pos:], op) {
+					operator = op
+					break
+				}
+			}
+			if operator == "" {
+				return nil, fmt.Errorf("Unexpected character [%c] at position [%d]", c, pos)
+			}
+			result = append(result, token{kind: tokenOperator, text: operator, pos: pos})
+			pos += len(operator)
+		}
+	}
+
+	return append(result, token{kind: tokenEOF, pos: len(source)}), nil
+}
@@ -0,0 +1,295 @@
+package expr
+
+import (
+	"fmt"
+)
+
+const (
+	maxLength = 512
+	maxDepth  = 32
+	maxNodes  = 256
+)
+
+type node interface{}
+
+type numberNode float64
+
+type stringNode string
+
+type boolNode bool
+
+type identNode string
+
+type unaryNode struct {
+	operator string
+	operand  node
+}
+
+type binaryNode struct {
+	operator string
+	left     node
+	right    node
+}
+
+type parser struct {
+	tokens      []token
+	pos         int
+	depth       int
+	nodes       int
+	identifiers map[string]bool
+}
+
+//Expression is a compiled expression that can be evaluated against an Env
+type Expression struct {
+	source string
+	root   node
+}
+
+//Compile parses the source, allowing only the given identifiers. The language has numbers,
+//'single' or "double" quoted strings, true and false, arithmetic (+ - * /), comparisons
+//(< <= > >= == !=) and logical operators (and or not, && || !). There are no function
+//calls or assignments, and the size of the expression is limited.
+func Compile(source string, identifiers []string) (*Expression, error) {
+	if len(source) > maxLength {
+		return nil, fmt.Errorf("Expression is longer than [%d] characters", maxLength)
+	}
+
+	tokens, err := tokenize(source)
+
+	if err != nil {
+		return nil, err
+	}
+
+	p := &parser{tokens: tokens, identifiers: make(map[string]bool)}
+	for _, identifier := range identifiers {
+		p.identifiers[identifier] = true
+	}
+
+	root, err := p.parseOr()
+
+	if err != nil {
+		return nil, err
+	}
+
+	if p.peek().kind != tokenEOF {
+		return nil, fmt.Errorf("Unexpected [%s] at position [%d]", p.peek().text, p.peek().pos)
+	}
+
+	return &Expression{source: source, root: root}, nil
+}
+
+//String returns the source of the expression
+func (e *Expression) String() string {
+	return e.source
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	t := p.tokens[p.pos]
+	if t.kind != tokenEOF {
+		p.pos++
+	}
+	return t
+}
+
+//match consumes the next token if it is one of the operators or keywords
+func (p *parser) match(operators ...string) (string, bool) {
+	t := p.peek()
+	if t.kind != tokenOperator && t.kind != tokenIdent {
+		return "", false
+	}
+
+	for _, op := range operators {
+		if t.text == op {
+			p.next()
+			return op, true
+		}
+	}
+
+	return "", false
+}
+
+func (p *parser) newNode() error {
+	p.nodes++
+	if p.nodes > maxNodes {
+		return fmt.Errorf("Expression has more than [%d] elements", maxNodes)
+	}
+	return nil
+}
+
+func (p *parser) enter() error {
+	p.depth++
+	if p.depth > maxDepth {
+		return fmt.Errorf("Expression is nested deeper than [%d]", maxDepth)
+	}
+	return nil
+}
+
+func (p *parser) leave() {
+	p.depth--
+}
+
+func (p *parser) binary(operand func() (node, error), normalize map[string]string, operators ...string) (node, error) {
+	left, err := operand()
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		op, ok := p.match(operators...)
+		if !ok {
+			return left, nil
+		}
+
+		right, err := operand()
+		if err != nil {
+			return nil, err
+		}
+
+		if err := p.newNode(); err != nil {
+			return nil, err
+		}
+
+		if normalized, ok := normalize[op]; ok {
+			op = normalized
+		}
+
+		left = binaryNode{operator: op, left: left, right: right}
+	}
+}
+
+func (p *parser) parseOr() (node, error) {
+	return p.binary(p.parseAnd, map[string]string{"||": "or"}, "or", "||")
+}
+
+func (p *parser) parseAnd() (node, error) {
+	return p.binary(p.parseNot, map[string]string{"&&": "and"}, "and", "&&")
+}
+
+func (p *parser) parseNot() (node, error) {
+	if _, ok := p.match("not", "!"); ok {
+		if err := p.enter(); err != nil {
+			return nil, err
+		}
+		defer p.leave()
+
+		operand, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+
+		if err := p.newNode(); err != nil {
+			return nil, err
+		}
+
+		return unaryNode{operator: "not", operand: operand}, nil
+	}
+
+	return p.parseComparison()
+}
+
+func (p *parser) parseComparison() (node, error) {
+	left, err := p.parseSum()
+	if err != nil {
+		return nil, err
+	}
+
+	op, ok := p.match("<", "<=", ">", ">=", "==", "!=")
+	if !ok {
+		return left, nil
+	}
+
+	right, err := p.parseSum()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := p.newNode(); err != nil {
+		return nil, err
+	}
+
+	return binaryNode{operator: op, left: left, right: right}, nil
+}
+
+func (p *parser) parseSum() (node, error) {
+	return p.binary(p.parseProduct, nil, "+", "-")
+}
+
+func (p *parser) parseProduct() (node, error) {
+	return p.binary(p.parseUnary, nil, "*", "/")
+}
+
+func (p *parser) parseUnary() (node, error) {
+	if _, ok := p.match("-"); ok {
+		if err := p.enter(); err != nil {
+			return nil, err
+		}
+		defer p.leave()
+
+		operand, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+
+		if err := p.newNode(); err != nil {
+			return nil, err
+		}
+
+		return unaryNode{operator: "-", operand: operand}, nil
+	}
+
+	return p.parsePrimary()
+}
+
+func (p *parser) parsePrimary() (node, error) {
+	if err := p.newNode(); err != nil {
+		return nil, err
+	}
+
+	t := p.next()
+
+	switch t.kind {
+	case tokenNumber:
+		return numberNode(t.number), nil
+	case tokenString:
+		return stringNode(t.text), nil
+	case tokenIdent:
+		switch t.text {
+		case "true":
+			return boolNode(true), nil
+		case "false":
+			return boolNode(false), nil
+		case "and", "or", "not":
+			return nil, fmt.Errorf("Unexpected [%s] at position [%d]", t.text, t.pos)
+		}
+
+		if !p.identifiers[t.text] {
+			return nil, fmt.Errorf("Unknown field [%s] at position [%d]", t.text, t.pos)
+		}
+
+		return identNode(t.text), nil
+	case tokenLeftParen:
+		if err := p.enter(); err != nil {
+			return nil, err
+		}
+		defer p.leave()
+
+		inner, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+
+		if closing := p.next(); closing.kind != tokenRightParen {
+			return nil, fmt.Errorf("Missing ')' at position [%d]", closing.pos)
+		}
+
+		return inner, nil
+	case tokenEOF:
+		return nil, fmt.Errorf("Unexpected end of expression")
+	}
+
+	return nil, fmt.Errorf("Unexpected [%s] at position [%d]", t.text, t.pos)
+}
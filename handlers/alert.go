@@ -0,0 +1,110 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	stockHttp "github.com/nagymarci/stock-commons/http"
+	"github.com/nagymarci/stock-commons/reqid"
+	"github.com/nagymarci/stock-watchlist/controllers"
+	"github.com/nagymarci/stock-watchlist/model"
+)
+
+func AlertCreateHandler(router *mux.Router, alert *controllers.AlertController, extractUserID func(*http.Request) string) {
+	router.HandleFunc("/{id}/alerts", func(w http.ResponseWriter, r *http.Request) {
+		userID := extractUserID(r)
+		watchlistID, err := extractWatchlistID(r)
+
+		log := logrus.WithFields(logrus.Fields{"userId": userID, "requestId": reqid.GetRequestId(r), "watchlistId": watchlistID})
+
+		if err != nil {
+			log.Errorln(err)
+			stockHttp.HandleError(err, w)
+			return
+		}
+
+		var request model.AlertRuleRequest
+
+		err = json.NewDecoder(r.Body).Decode(&request)
+
+		if err != nil {
+			message := "Failed to deserialize payload: " + err.Error()
+			stockHttp.HandleErrorResponse(message, w, http.StatusBadRequest)
+			log.Errorln(message)
+			return
+		}
+
+		result, err := alert.Create(log, watchlistID, userID, &request)
+
+		if err != nil {
+			log.Errorln(err)
+			stockHttp.HandleError(err, w)
+			return
+		}
+
+		stockHttp.HandleJSONResponse(result, w, http.StatusCreated)
+	}).Methods(http.MethodPost, http.MethodOptions)
+}
+
+func AlertGetAllHandler(router *mux.Router, alert *controllers.AlertController, extractUserID func(*http.Request) string) {
+	router.HandleFunc("/{id}/alerts", func(w http.ResponseWriter, r *http.Request) {
+		userID := extractUserID(r)
+		watchlistID, err := extractWatchlistID(r)
+
+		log := logrus.WithFields(logrus.Fields{"userId": userID, "requestId": reqid.GetRequestId(r), "watchlistId": watchlistID})
+
+		if err != nil {
+			log.Errorln(err)
+			stockHttp.HandleError(err, w)
+			return
+		}
+
+		result, err := alert.GetAll(log, watchlistID, userID)
+
+		if err != nil {
+			log.Errorln(err)
+			stockHttp.HandleError(err, w)
+			return
+		}
+
+		stockHttp.HandleJSONResponse(result, w, http.StatusOK)
+	}).Methods(http.MethodGet)
+}
+
+func AlertDeleteHandler(router *mux.Router, alert *controllers.AlertController, extractUserID func(*http.Request) string) {
+	router.HandleFunc("/{id}/alerts/{alertId}", func(w http.ResponseWriter, r *http.Request) {
+		userID := extractUserID(r)
+		watchlistID, err := extractWatchlistID(r)
+
+		log := logrus.WithFields(logrus.Fields{"userId": userID, "requestId": reqid.GetRequestId(r), "watchlistId": watchlistID})
+
+		if err != nil {
+			log.Errorln(err)
+			stockHttp.HandleError(err, w)
+			return
+		}
+
+		alertID, err := primitive.ObjectIDFromHex(mux.Vars(r)["alertId"])
+
+		if err != nil {
+			message := "Invalid alert id: " + err.Error()
+			log.Errorln(message)
+			stockHttp.HandleErrorResponse(message, w, http.StatusBadRequest)
+			return
+		}
+
+		err = alert.Delete(log, watchlistID, alertID, userID)
+
+		if err != nil {
+			log.Errorln(err)
+			stockHttp.HandleError(err, w)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}).Methods(http.MethodDelete, http.MethodOptions)
+}
@@ -0,0 +1,88 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/sirupsen/logrus"
+
+	stockHttp "github.com/nagymarci/stock-commons/http"
+	"github.com/nagymarci/stock-commons/reqid"
+	"github.com/nagymarci/stock-watchlist/controllers"
+	"github.com/nagymarci/stock-watchlist/model"
+)
+
+func WatchlistBacktestHandler(router *mux.Router, backtest *controllers.BacktestController, extractUserID func(*http.Request) string) {
+	router.HandleFunc("/{id}/backtest", func(w http.ResponseWriter, r *http.Request) {
+		userID := extractUserID(r)
+		watchlistID, err := extractWatchlistID(r)
+
+		log := logrus.WithFields(logrus.Fields{"userId": userID, "requestId": reqid.GetRequestId(r), "watchlistId": watchlistID})
+
+		if err != nil {
+			log.Errorln(err)
+			stockHttp.HandleError(err, w)
+			return
+		}
+
+		to, err := parseDateParam(r, "to", time.Now().UTC())
+
+		if err != nil {
+			log.Errorln(err)
+			stockHttp.HandleErrorResponse(err.Error(), w, http.StatusBadRequest)
+			return
+		}
+
+		from, err := parseDateParam(r, "from", to.AddDate(-1, 0, 0))
+
+		if err != nil {
+			log.Errorln(err)
+			stockHttp.HandleErrorResponse(err.Error(), w, http.StatusBadRequest)
+			return
+		}
+
+		strategy, err := parseBacktestStrategy(r)
+
+		if err != nil {
+			log.Errorln(err)
+			stockHttp.HandleErrorResponse(err.Error(), w, http.StatusBadRequest)
+			return
+		}
+
+		result, err := backtest.Run(r.Context(), log, watchlistID, userID, from, to, strategy)
+
+		if err != nil {
+			log.Errorln(err)
+			stockHttp.HandleError(err, w)
+			return
+		}
+
+		stockHttp.HandleJSONResponse(result, w, http.StatusOK)
+	}).Methods(http.MethodGet)
+}
+
+//parseBacktestStrategy reads the strategy from the query, defaults to the notifier's "2 of 3 green and price green"
+func parseBacktestStrategy(r *http.Request) (model.BacktestStrategy, error) {
+	strategy := model.BacktestStrategy{NumReqs: 2, PriceGreen: true}
+	var err error
+
+	if value := r.URL.Query().Get("numReqs"); value != "" {
+		strategy.NumReqs, err = strconv.Atoi(value)
+
+		if err != nil || strategy.NumReqs < 0 || strategy.NumReqs > 3 {
+			return strategy, stockHttp.NewBadRequestError("Invalid 'numReqs', expected a number between 0 and 3")
+		}
+	}
+
+	if value := r.URL.Query().Get("priceGreen"); value != "" {
+		strategy.PriceGreen, err = strconv.ParseBool(value)
+
+		if err != nil {
+			return strategy, stockHttp.NewBadRequestError("Invalid 'priceGreen', expected true or false")
+		}
+	}
+
+	return strategy, nil
+}
@@ -0,0 +1,45 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/sirupsen/logrus"
+
+	stockHttp "github.com/nagymarci/stock-commons/http"
+	"github.com/nagymarci/stock-commons/reqid"
+	"github.com/nagymarci/stock-watchlist/controllers"
+)
+
+func CalendarGetHandler(router *mux.Router, calendar *controllers.CalendarController) {
+	router.HandleFunc("", func(w http.ResponseWriter, r *http.Request) {
+		log := logrus.WithField("requestId", reqid.GetRequestId(r))
+
+		from, err := parseDateParam(r, "from", time.Now())
+
+		if err != nil {
+			log.Errorln(err)
+			stockHttp.HandleErrorResponse(err.Error(), w, http.StatusBadRequest)
+			return
+		}
+
+		to, err := parseDateParam(r, "to", from.AddDate(0, 0, 30))
+
+		if err != nil {
+			log.Errorln(err)
+			stockHttp.HandleErrorResponse(err.Error(), w, http.StatusBadRequest)
+			return
+		}
+
+		result, err := calendar.Get(log, from, to)
+
+		if err != nil {
+			log.Errorln(err)
+			stockHttp.HandleError(err, w)
+			return
+		}
+
+		stockHttp.HandleJSONResponse(result, w, http.StatusOK)
+	}).Methods(http.MethodGet)
+}
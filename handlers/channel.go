@@ -0,0 +1,125 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	stockHttp "github.com/nagymarci/stock-commons/http"
+	"github.com/nagymarci/stock-commons/reqid"
+	"github.com/nagymarci/stock-watchlist/controllers"
+	"github.com/nagymarci/stock-watchlist/model"
+)
+
+func ChannelCreateHandler(router *mux.Router, channel *controllers.ChannelController, extractUserID func(*http.Request) string) {
+	router.HandleFunc("", func(w http.ResponseWriter, r *http.Request) {
+		userID := extractUserID(r)
+
+		log := logrus.WithFields(logrus.Fields{"userId": userID, "requestId": reqid.GetRequestId(r)})
+
+		var request model.NotificationChannelRequest
+
+		err := json.NewDecoder(r.Body).Decode(&request)
+
+		if err != nil {
+			message := "Failed to deserialize payload: " + err.Error()
+			stockHttp.HandleErrorResponse(message, w, http.StatusBadRequest)
+			log.Errorln(message)
+			return
+		}
+
+		result, err := channel.Create(log, userID, &request)
+
+		if err != nil {
+			log.Errorln(err)
+			stockHttp.HandleError(err, w)
+			return
+		}
+
+		stockHttp.HandleJSONResponse(result, w, http.StatusCreated)
+	}).Methods(http.MethodPost, http.MethodOptions)
+}
+
+func ChannelGetAllHandler(router *mux.Router, channel *controllers.ChannelController, extractUserID func(*http.Request) string) {
+	router.HandleFunc("", func(w http.ResponseWriter, r *http.Request) {
+		userID := extractUserID(r)
+
+		log := logrus.WithFields(logrus.Fields{"userId": userID, "requestId": reqid.GetRequestId(r)})
+
+		result, err := channel.GetAll(log, userID)
+
+		if err != nil {
+			log.Errorln(err)
+			stockHttp.HandleError(err, w)
+			return
+		}
+
+		stockHttp.HandleJSONResponse(result, w, http.StatusOK)
+	}).Methods(http.MethodGet)
+}
+
+func ChannelDeleteHandler(router *mux.Router, channel *controllers.ChannelController, extractUserID func(*http.Request) string) {
+	router.HandleFunc("/{id}", func(w http.ResponseWriter, r *http.Request) {
+		userID := extractUserID(r)
+
+		log := logrus.WithFields(logrus.Fields{"userId": userID, "requestId": reqid.GetRequestId(r)})
+
+		id, err := primitive.ObjectIDFromHex(mux.Vars(r)["id"])
+
+		if err != nil {
+			message := "Invalid channel id: " + err.Error()
+			log.Errorln(message)
+			stockHttp.HandleErrorResponse(message, w, http.StatusBadRequest)
+			return
+		}
+
+		err = channel.Delete(log, id, userID)
+
+		if err != nil {
+			log.Errorln(err)
+			stockHttp.HandleError(err, w)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}).Methods(http.MethodDelete, http.MethodOptions)
+}
+
+func WatchlistSetChannelsHandler(router *mux.Router, channel *controllers.ChannelController, extractUserID func(*http.Request) string) {
+	router.HandleFunc("/{id}/channels", func(w http.ResponseWriter, r *http.Request) {
+		userID := extractUserID(r)
+		watchlistID, err := extractWatchlistID(r)
+
+		log := logrus.WithFields(logrus.Fields{"userId": userID, "requestId": reqid.GetRequestId(r), "watchlistId": watchlistID})
+
+		if err != nil {
+			log.Errorln(err)
+			stockHttp.HandleError(err, w)
+			return
+		}
+
+		var request model.WatchlistChannelsRequest
+
+		err = json.NewDecoder(r.Body).Decode(&request)
+
+		if err != nil {
+			message := "Failed to deserialize payload: " + err.Error()
+			stockHttp.HandleErrorResponse(message, w, http.StatusBadRequest)
+			log.Errorln(message)
+			return
+		}
+
+		result, err := channel.SetWatchlistChannels(log, watchlistID, userID, request.Channels)
+
+		if err != nil {
+			log.Errorln(err)
+			stockHttp.HandleError(err, w)
+			return
+		}
+
+		stockHttp.HandleJSONResponse(result, w, http.StatusOK)
+	}).Methods(http.MethodPut, http.MethodOptions)
+}
@@ -0,0 +1,13 @@
+package handlers
+
+import (
+	"expvar"
+	"net/http"
+
+	"github.com/gorilla/mux"
+)
+
+//MetricsGetHandler serves the published metrics, e.g. the calls to the upstream services, as JSON
+func MetricsGetHandler(router *mux.Router) {
+	router.Handle("", expvar.Handler()).Methods(http.MethodGet)
+}
@@ -0,0 +1,116 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	stockHttp "github.com/nagymarci/stock-commons/http"
+	"github.com/nagymarci/stock-commons/reqid"
+	"github.com/nagymarci/stock-watchlist/controllers"
+	"github.com/nagymarci/stock-watchlist/model"
+)
+
+func NotificationGetAllHandler(router *mux.Router, notification *controllers.NotificationController, extractUserID func(*http.Request) string) {
+	router.HandleFunc("", func(w http.ResponseWriter, r *http.Request) {
+		userID := extractUserID(r)
+
+		log := logrus.WithFields(logrus.Fields{"userId": userID, "requestId": reqid.GetRequestId(r)})
+
+		query, err := parseNotificationQuery(r)
+
+		if err != nil {
+			log.Errorln(err)
+			stockHttp.HandleErrorResponse(err.Error(), w, http.StatusBadRequest)
+			return
+		}
+
+		result, err := notification.GetAll(log, userID, query)
+
+		if err != nil {
+			log.Errorln(err)
+			stockHttp.HandleError(err, w)
+			return
+		}
+
+		stockHttp.HandleJSONResponse(result, w, http.StatusOK)
+	}).Methods(http.MethodGet)
+}
+
+//AdminNotificationGetAllHandler lists the notifications of every user for the support staff
+func AdminNotificationGetAllHandler(router *mux.Router, notification *controllers.NotificationController, extractUserID func(*http.Request) string) {
+	router.HandleFunc("/notifications", func(w http.ResponseWriter, r *http.Request) {
+		log := logrus.WithFields(logrus.Fields{"adminId": extractUserID(r), "requestId": reqid.GetRequestId(r)})
+
+		query, err := parseNotificationQuery(r)
+
+		if err != nil {
+			log.Errorln(err)
+			stockHttp.HandleErrorResponse(err.Error(), w, http.StatusBadRequest)
+			return
+		}
+
+		query.UserID = r.URL.Query().Get("userId")
+
+		result, err := notification.Find(log, query)
+
+		if err != nil {
+			log.Errorln(err)
+			stockHttp.HandleError(err, w)
+			return
+		}
+
+		stockHttp.HandleJSONResponse(result, w, http.StatusOK)
+	}).Methods(http.MethodGet)
+}
+
+//parseNotificationQuery reads the page, pageSize, watchlistId and status filters from the query
+func parseNotificationQuery(r *http.Request) (model.NotificationQuery, error) {
+	var query model.NotificationQuery
+	var err error
+
+	query.Page, query.PageSize, err = parsePaging(r)
+
+	if err != nil {
+		return query, err
+	}
+
+	if value := r.URL.Query().Get("watchlistId"); value != "" {
+		query.WatchlistID, err = primitive.ObjectIDFromHex(value)
+
+		if err != nil {
+			return query, stockHttp.NewBadRequestError("Invalid 'watchlistId': " + err.Error())
+		}
+	}
+
+	query.Status = r.URL.Query().Get("status")
+
+	return query, nil
+}
+
+//parsePaging reads the page and pageSize from the query, zero if not set
+func parsePaging(r *http.Request) (int64, int64, error) {
+	var page, pageSize int64
+	var err error
+
+	if value := r.URL.Query().Get("page"); value != "" {
+		page, err = strconv.ParseInt(value, 10, 64)
+
+		if err != nil || page < 1 {
+			return 0, 0, stockHttp.NewBadRequestError("Invalid 'page', expected a positive number")
+		}
+	}
+
+	if value := r.URL.Query().Get("pageSize"); value != "" {
+		pageSize, err = strconv.ParseInt(value, 10, 64)
+
+		if err != nil || pageSize < 1 {
+			return 0, 0, stockHttp.NewBadRequestError("Invalid 'pageSize', expected a positive number")
+		}
+	}
+
+	return page, pageSize, nil
+}
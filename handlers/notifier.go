@@ -0,0 +1,61 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/sirupsen/logrus"
+
+	stockHttp "github.com/nagymarci/stock-commons/http"
+	"github.com/nagymarci/stock-commons/reqid"
+	"github.com/nagymarci/stock-watchlist/controllers"
+	"github.com/nagymarci/stock-watchlist/service"
+)
+
+func WatchlistNotifyPreviewHandler(router *mux.Router, notifier *controllers.NotifierController, extractUserID func(*http.Request) string) {
+	router.HandleFunc("/{id}/notify/preview", func(w http.ResponseWriter, r *http.Request) {
+		userID := extractUserID(r)
+		watchlistID, err := extractWatchlistID(r)
+
+		log := logrus.WithFields(logrus.Fields{"userId": userID, "requestId": reqid.GetRequestId(r), "watchlistId": watchlistID})
+
+		if err != nil {
+			log.Errorln(err)
+			stockHttp.HandleError(err, w)
+			return
+		}
+
+		result, err := notifier.Preview(log, watchlistID, userID)
+
+		if err != nil {
+			log.Errorln(err)
+			stockHttp.HandleError(err, w)
+			return
+		}
+
+		stockHttp.HandleJSONResponse(result, w, http.StatusOK)
+	}).Methods(http.MethodPost, http.MethodOptions)
+}
+
+func AdminNotifierRunHandler(router *mux.Router, notifier *controllers.NotifierController, extractUserID func(*http.Request) string) {
+	router.HandleFunc("/notifier/run", func(w http.ResponseWriter, r *http.Request) {
+		log := logrus.WithFields(logrus.Fields{"adminId": extractUserID(r), "requestId": reqid.GetRequestId(r)})
+
+		result, err := notifier.Run(log)
+
+		if errors.Is(err, service.ErrNotifierRunning) || errors.Is(err, service.ErrNotLeader) {
+			log.Warnln(err)
+			stockHttp.HandleErrorResponse(err.Error(), w, http.StatusConflict)
+			return
+		}
+
+		if err != nil {
+			log.Errorln(err)
+			stockHttp.HandleError(err, w)
+			return
+		}
+
+		stockHttp.HandleJSONResponse(result, w, http.StatusOK)
+	}).Methods(http.MethodPost, http.MethodOptions)
+}
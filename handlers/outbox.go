@@ -0,0 +1,62 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	stockHttp "github.com/nagymarci/stock-commons/http"
+	"github.com/nagymarci/stock-commons/reqid"
+	"github.com/nagymarci/stock-watchlist/controllers"
+)
+
+func AdminOutboxGetAllHandler(router *mux.Router, outbox *controllers.OutboxController, extractUserID func(*http.Request) string) {
+	router.HandleFunc("/outbox", func(w http.ResponseWriter, r *http.Request) {
+		log := logrus.WithFields(logrus.Fields{"adminId": extractUserID(r), "requestId": reqid.GetRequestId(r)})
+
+		page, pageSize, err := parsePaging(r)
+
+		if err != nil {
+			log.Errorln(err)
+			stockHttp.HandleErrorResponse(err.Error(), w, http.StatusBadRequest)
+			return
+		}
+
+		result, err := outbox.Find(log, r.URL.Query().Get("status"), page, pageSize)
+
+		if err != nil {
+			log.Errorln(err)
+			stockHttp.HandleError(err, w)
+			return
+		}
+
+		stockHttp.HandleJSONResponse(result, w, http.StatusOK)
+	}).Methods(http.MethodGet)
+}
+
+func AdminOutboxRequeueHandler(router *mux.Router, outbox *controllers.OutboxController, extractUserID func(*http.Request) string) {
+	router.HandleFunc("/outbox/{id}/requeue", func(w http.ResponseWriter, r *http.Request) {
+		log := logrus.WithFields(logrus.Fields{"adminId": extractUserID(r), "requestId": reqid.GetRequestId(r)})
+
+		id, err := primitive.ObjectIDFromHex(mux.Vars(r)["id"])
+
+		if err != nil {
+			message := "Invalid outbox entry id: " + err.Error()
+			log.Errorln(message)
+			stockHttp.HandleErrorResponse(message, w, http.StatusBadRequest)
+			return
+		}
+
+		err = outbox.Requeue(log, id)
+
+		if err != nil {
+			log.Errorln(err)
+			stockHttp.HandleError(err, w)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}).Methods(http.MethodPost, http.MethodOptions)
+}
@@ -0,0 +1,97 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/sirupsen/logrus"
+
+	stockHttp "github.com/nagymarci/stock-commons/http"
+	"github.com/nagymarci/stock-commons/reqid"
+	"github.com/nagymarci/stock-watchlist/controllers"
+	"github.com/nagymarci/stock-watchlist/model"
+)
+
+func PreferencesGetHandler(router *mux.Router, preferences *controllers.PreferencesController, extractUserID func(*http.Request) string) {
+	router.HandleFunc("", func(w http.ResponseWriter, r *http.Request) {
+		userID := extractUserID(r)
+
+		log := logrus.WithFields(logrus.Fields{"userId": userID, "requestId": reqid.GetRequestId(r)})
+
+		result, err := preferences.Get(log, userID)
+
+		if err != nil {
+			log.Errorln(err)
+			stockHttp.HandleError(err, w)
+			return
+		}
+
+		stockHttp.HandleJSONResponse(result, w, http.StatusOK)
+	}).Methods(http.MethodGet)
+}
+
+func PreferencesSetHandler(router *mux.Router, preferences *controllers.PreferencesController, extractUserID func(*http.Request) string) {
+	router.HandleFunc("", func(w http.ResponseWriter, r *http.Request) {
+		userID := extractUserID(r)
+
+		log := logrus.WithFields(logrus.Fields{"userId": userID, "requestId": reqid.GetRequestId(r)})
+
+		var request model.NotificationPreferencesRequest
+
+		err := json.NewDecoder(r.Body).Decode(&request)
+
+		if err != nil {
+			message := "Failed to deserialize payload: " + err.Error()
+			stockHttp.HandleErrorResponse(message, w, http.StatusBadRequest)
+			log.Errorln(message)
+			return
+		}
+
+		result, err := preferences.Set(log, userID, &request)
+
+		if err != nil {
+			log.Errorln(err)
+			stockHttp.HandleError(err, w)
+			return
+		}
+
+		stockHttp.HandleJSONResponse(result, w, http.StatusOK)
+	}).Methods(http.MethodPut, http.MethodOptions)
+}
+
+func PreferencesSetWatchlistHandler(router *mux.Router, preferences *controllers.PreferencesController, extractUserID func(*http.Request) string) {
+	router.HandleFunc("/watchlists/{id}", func(w http.ResponseWriter, r *http.Request) {
+		userID := extractUserID(r)
+		watchlistID, err := extractWatchlistID(r)
+
+		log := logrus.WithFields(logrus.Fields{"userId": userID, "requestId": reqid.GetRequestId(r), "watchlistId": watchlistID})
+
+		if err != nil {
+			log.Errorln(err)
+			stockHttp.HandleError(err, w)
+			return
+		}
+
+		var request model.WatchlistPreferencesRequest
+
+		err = json.NewDecoder(r.Body).Decode(&request)
+
+		if err != nil {
+			message := "Failed to deserialize payload: " + err.Error()
+			stockHttp.HandleErrorResponse(message, w, http.StatusBadRequest)
+			log.Errorln(message)
+			return
+		}
+
+		result, err := preferences.SetWatchlist(log, userID, watchlistID, &request)
+
+		if err != nil {
+			log.Errorln(err)
+			stockHttp.HandleError(err, w)
+			return
+		}
+
+		stockHttp.HandleJSONResponse(result, w, http.StatusOK)
+	}).Methods(http.MethodPut, http.MethodOptions)
+}
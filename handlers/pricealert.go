@@ -0,0 +1,89 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	stockHttp "github.com/nagymarci/stock-commons/http"
+	"github.com/nagymarci/stock-commons/reqid"
+	"github.com/nagymarci/stock-watchlist/controllers"
+	"github.com/nagymarci/stock-watchlist/model"
+)
+
+func PriceAlertCreateHandler(router *mux.Router, priceAlert *controllers.PriceAlertController, extractUserID func(*http.Request) string) {
+	router.HandleFunc("", func(w http.ResponseWriter, r *http.Request) {
+		userID := extractUserID(r)
+
+		log := logrus.WithFields(logrus.Fields{"userId": userID, "requestId": reqid.GetRequestId(r)})
+
+		var request model.PriceAlertRequest
+
+		err := json.NewDecoder(r.Body).Decode(&request)
+
+		if err != nil {
+			message := "Failed to deserialize payload: " + err.Error()
+			stockHttp.HandleErrorResponse(message, w, http.StatusBadRequest)
+			log.Errorln(message)
+			return
+		}
+
+		result, err := priceAlert.Create(r.Context(), log, userID, &request)
+
+		if err != nil {
+			log.Errorln(err)
+			stockHttp.HandleError(err, w)
+			return
+		}
+
+		stockHttp.HandleJSONResponse(result, w, http.StatusCreated)
+	}).Methods(http.MethodPost, http.MethodOptions)
+}
+
+func PriceAlertGetAllHandler(router *mux.Router, priceAlert *controllers.PriceAlertController, extractUserID func(*http.Request) string) {
+	router.HandleFunc("", func(w http.ResponseWriter, r *http.Request) {
+		userID := extractUserID(r)
+
+		log := logrus.WithFields(logrus.Fields{"userId": userID, "requestId": reqid.GetRequestId(r)})
+
+		result, err := priceAlert.GetAll(log, userID)
+
+		if err != nil {
+			log.Errorln(err)
+			stockHttp.HandleError(err, w)
+			return
+		}
+
+		stockHttp.HandleJSONResponse(result, w, http.StatusOK)
+	}).Methods(http.MethodGet)
+}
+
+func PriceAlertDeleteHandler(router *mux.Router, priceAlert *controllers.PriceAlertController, extractUserID func(*http.Request) string) {
+	router.HandleFunc("/{id}", func(w http.ResponseWriter, r *http.Request) {
+		userID := extractUserID(r)
+
+		log := logrus.WithFields(logrus.Fields{"userId": userID, "requestId": reqid.GetRequestId(r)})
+
+		id, err := primitive.ObjectIDFromHex(mux.Vars(r)["id"])
+
+		if err != nil {
+			message := "Invalid price alert id: " + err.Error()
+			log.Errorln(message)
+			stockHttp.HandleErrorResponse(message, w, http.StatusBadRequest)
+			return
+		}
+
+		err = priceAlert.Delete(log, id, userID)
+
+		if err != nil {
+			log.Errorln(err)
+			stockHttp.HandleError(err, w)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}).Methods(http.MethodDelete, http.MethodOptions)
+}
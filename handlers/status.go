@@ -0,0 +1,28 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/sirupsen/logrus"
+
+	stockHttp "github.com/nagymarci/stock-commons/http"
+	"github.com/nagymarci/stock-commons/reqid"
+	"github.com/nagymarci/stock-watchlist/controllers"
+)
+
+func StatusGetHandler(router *mux.Router, status *controllers.StatusController) {
+	router.HandleFunc("", func(w http.ResponseWriter, r *http.Request) {
+		log := logrus.WithField("requestId", reqid.GetRequestId(r))
+
+		result, err := status.Get(log)
+
+		if err != nil {
+			log.Errorln(err)
+			stockHttp.HandleError(err, w)
+			return
+		}
+
+		stockHttp.HandleJSONResponse(result, w, http.StatusOK)
+	}).Methods(http.MethodGet)
+}
@@ -1,10 +1,13 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
+	"time"
 
 	"github.com/gorilla/mux"
 	stockHttp "github.com/nagymarci/stock-commons/http"
+	"github.com/nagymarci/stock-commons/reqid"
 	"github.com/nagymarci/stock-watchlist/controllers"
 	"github.com/urfave/negroni"
 
@@ -15,7 +18,7 @@ func StockGetAllCalculatedHandler(mux *mux.Router, stockController *controllers.
 	mux.HandleFunc("", func(w http.ResponseWriter, r *http.Request) {
 		log := logrus.WithField("userId", "")
 
-		result, err := stockController.GetAllCalculated(log, "")
+		result, err := stockController.GetAllCalculated(r.Context(), log, "")
 
 		if err != nil {
 			stockHttp.HandleError(err, w)
@@ -41,7 +44,7 @@ func StockGetAllCalculatedForUserHandler(router *mux.Router, auth *negroni.Negro
 
 		log := logrus.WithField("userId", userID)
 
-		result, err := stockController.GetAllCalculated(log, userID)
+		result, err := stockController.GetAllCalculated(r.Context(), log, userID)
 
 		if err != nil {
 			stockHttp.HandleError(err, w)
@@ -52,3 +55,71 @@ func StockGetAllCalculatedForUserHandler(router *mux.Router, auth *negroni.Negro
 		stockHttp.HandleJSONResponse(result, w, http.StatusOK)
 	}))).Methods(http.MethodGet)
 }
+
+func StockGetHistoryHandler(router *mux.Router, stockController *controllers.StockController) {
+	router.HandleFunc("/{symbol}/history", func(w http.ResponseWriter, r *http.Request) {
+		symbol := mux.Vars(r)["symbol"]
+
+		log := logrus.WithFields(logrus.Fields{"symbol": symbol, "requestId": reqid.GetRequestId(r)})
+
+		to, err := parseDateParam(r, "to", time.Now().UTC())
+
+		if err != nil {
+			log.Errorln(err)
+			stockHttp.HandleErrorResponse(err.Error(), w, http.StatusBadRequest)
+			return
+		}
+
+		from, err := parseDateParam(r, "from", to.AddDate(-1, 0, 0))
+
+		if err != nil {
+			log.Errorln(err)
+			stockHttp.HandleErrorResponse(err.Error(), w, http.StatusBadRequest)
+			return
+		}
+
+		result, err := stockController.GetHistory(log, symbol, from, to)
+
+		if err != nil {
+			stockHttp.HandleError(err, w)
+			log.Errorln(err)
+			return
+		}
+
+		stockHttp.HandleJSONResponse(result, w, http.StatusOK)
+	}).Methods(http.MethodGet)
+}
+
+func parseDateParam(r *http.Request, name string, defaultValue time.Time) (time.Time, error) {
+	value := r.URL.Query().Get(name)
+
+	if value == "" {
+		return defaultValue, nil
+	}
+
+	date, err := time.Parse("2006-01-02", value)
+
+	if err != nil {
+		return time.Time{}, errors.New("Invalid '" + name + "' date, expected format YYYY-MM-DD: " + err.Error())
+	}
+
+	return date, nil
+}
+
+func StockGetSensitivityHandler(router *mux.Router, stockController *controllers.StockController) {
+	router.HandleFunc("/{symbol}/sensitivity", func(w http.ResponseWriter, r *http.Request) {
+		symbol := mux.Vars(r)["symbol"]
+
+		log := logrus.WithFields(logrus.Fields{"symbol": symbol, "requestId": reqid.GetRequestId(r)})
+
+		result, err := stockController.GetSensitivity(r.Context(), log, symbol)
+
+		if err != nil {
+			stockHttp.HandleError(err, w)
+			log.Errorln(err)
+			return
+		}
+
+		stockHttp.HandleJSONResponse(result, w, http.StatusOK)
+	}).Methods(http.MethodGet)
+}
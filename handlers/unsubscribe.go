@@ -0,0 +1,45 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/sirupsen/logrus"
+
+	stockHttp "github.com/nagymarci/stock-commons/http"
+	"github.com/nagymarci/stock-commons/reqid"
+	"github.com/nagymarci/stock-watchlist/controllers"
+)
+
+//UnsubscribeHandler serves the unsubscribe links of the emails. GET is a click on the link, or a link scanner
+//prefetching it, so it only shows what would be disabled. POST confirms it, it is also the one-click
+//unsubscribe of the mail clients (RFC 8058)
+func UnsubscribeHandler(router *mux.Router, unsubscribe *controllers.UnsubscribeController) {
+	router.HandleFunc("", func(w http.ResponseWriter, r *http.Request) {
+		log := logrus.WithField("requestId", reqid.GetRequestId(r))
+
+		result, err := unsubscribe.Check(log, r.URL.Query().Get("token"))
+
+		if err != nil {
+			log.Errorln(err)
+			stockHttp.HandleError(err, w)
+			return
+		}
+
+		stockHttp.HandleJSONResponse(result, w, http.StatusOK)
+	}).Methods(http.MethodGet)
+
+	router.HandleFunc("", func(w http.ResponseWriter, r *http.Request) {
+		log := logrus.WithField("requestId", reqid.GetRequestId(r))
+
+		result, err := unsubscribe.Unsubscribe(log, r.URL.Query().Get("token"))
+
+		if err != nil {
+			log.Errorln(err)
+			stockHttp.HandleError(err, w)
+			return
+		}
+
+		stockHttp.HandleJSONResponse(result, w, http.StatusOK)
+	}).Methods(http.MethodPost)
+}
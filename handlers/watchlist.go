@@ -47,7 +47,7 @@ func WatchlistCreateHandler(mux *mux.Router, watchlist *controllers.WatchlistCon
 
 		watchlistRequest.UserID = userID
 
-		result, err := watchlist.Create(log, watchlistRequest)
+		result, err := watchlist.Create(r.Context(), log, watchlistRequest)
 
 		if err != nil {
 			message := "Watchlist creation failed: " + err.Error()
@@ -142,7 +142,165 @@ func WatchlistGetCalculatedHandler(router *mux.Router, watchlist *controllers.Wa
 			return
 		}
 
-		result, err := watchlist.GetCalculated(log, watchlistID, userID)
+		result, err := watchlist.GetCalculated(r.Context(), log, watchlistID, userID)
+
+		if err != nil {
+			log.Errorln(err)
+			stockHttp.HandleError(err, w)
+			return
+		}
+
+		stockHttp.HandleJSONResponse(result, w, http.StatusOK)
+	}).Methods(http.MethodGet)
+}
+
+func WatchlistGetSensitivityHandler(router *mux.Router, watchlist *controllers.WatchlistController, extractUserID func(*http.Request) string) {
+	router.HandleFunc("/{id}/sensitivity", func(w http.ResponseWriter, r *http.Request) {
+		userID := extractUserID(r)
+		watchlistID, err := extractWatchlistID(r)
+
+		log := logrus.WithFields(logrus.Fields{"userId": userID, "requestId": reqid.GetRequestId(r), "watchlistId": watchlistID})
+
+		if err != nil {
+			log.Errorln(err)
+			stockHttp.HandleError(err, w)
+			return
+		}
+
+		result, err := watchlist.GetSensitivity(r.Context(), log, watchlistID, userID)
+
+		if err != nil {
+			log.Errorln(err)
+			stockHttp.HandleError(err, w)
+			return
+		}
+
+		stockHttp.HandleJSONResponse(result, w, http.StatusOK)
+	}).Methods(http.MethodGet)
+}
+
+func WatchlistSetRuleHandler(router *mux.Router, watchlist *controllers.WatchlistController, extractUserID func(*http.Request) string) {
+	router.HandleFunc("/{id}/rule", func(w http.ResponseWriter, r *http.Request) {
+		userID := extractUserID(r)
+		watchlistID, err := extractWatchlistID(r)
+
+		log := logrus.WithFields(logrus.Fields{"userId": userID, "requestId": reqid.GetRequestId(r), "watchlistId": watchlistID})
+
+		if err != nil {
+			log.Errorln(err)
+			stockHttp.HandleError(err, w)
+			return
+		}
+
+		var rule model.NotificationRule
+
+		err = json.NewDecoder(r.Body).Decode(&rule)
+
+		if err != nil {
+			message := "Failed to deserialize payload: " + err.Error()
+			stockHttp.HandleErrorResponse(message, w, http.StatusBadRequest)
+			log.Errorln(message)
+			return
+		}
+
+		result, err := watchlist.SetRule(log, watchlistID, userID, &rule)
+
+		if err != nil {
+			log.Errorln(err)
+			stockHttp.HandleError(err, w)
+			return
+		}
+
+		stockHttp.HandleJSONResponse(result, w, http.StatusOK)
+	}).Methods(http.MethodPut, http.MethodOptions)
+}
+
+func WatchlistSetScheduleHandler(router *mux.Router, watchlist *controllers.WatchlistController, extractUserID func(*http.Request) string) {
+	router.HandleFunc("/{id}/schedule", func(w http.ResponseWriter, r *http.Request) {
+		userID := extractUserID(r)
+		watchlistID, err := extractWatchlistID(r)
+
+		log := logrus.WithFields(logrus.Fields{"userId": userID, "requestId": reqid.GetRequestId(r), "watchlistId": watchlistID})
+
+		if err != nil {
+			log.Errorln(err)
+			stockHttp.HandleError(err, w)
+			return
+		}
+
+		var schedule model.CheckSchedule
+
+		err = json.NewDecoder(r.Body).Decode(&schedule)
+
+		if err != nil {
+			message := "Failed to deserialize payload: " + err.Error()
+			stockHttp.HandleErrorResponse(message, w, http.StatusBadRequest)
+			log.Errorln(message)
+			return
+		}
+
+		result, err := watchlist.SetSchedule(log, watchlistID, userID, &schedule)
+
+		if err != nil {
+			log.Errorln(err)
+			stockHttp.HandleError(err, w)
+			return
+		}
+
+		stockHttp.HandleJSONResponse(result, w, http.StatusOK)
+	}).Methods(http.MethodPut, http.MethodOptions)
+}
+
+func WatchlistSetSubscriptionsHandler(router *mux.Router, watchlist *controllers.WatchlistController, extractUserID func(*http.Request) string) {
+	router.HandleFunc("/{id}/subscriptions", func(w http.ResponseWriter, r *http.Request) {
+		userID := extractUserID(r)
+		watchlistID, err := extractWatchlistID(r)
+
+		log := logrus.WithFields(logrus.Fields{"userId": userID, "requestId": reqid.GetRequestId(r), "watchlistId": watchlistID})
+
+		if err != nil {
+			log.Errorln(err)
+			stockHttp.HandleError(err, w)
+			return
+		}
+
+		var subscriptions []model.SignalSubscription
+
+		err = json.NewDecoder(r.Body).Decode(&subscriptions)
+
+		if err != nil {
+			message := "Failed to deserialize payload: " + err.Error()
+			stockHttp.HandleErrorResponse(message, w, http.StatusBadRequest)
+			log.Errorln(message)
+			return
+		}
+
+		result, err := watchlist.SetSubscriptions(log, watchlistID, userID, subscriptions)
+
+		if err != nil {
+			log.Errorln(err)
+			stockHttp.HandleError(err, w)
+			return
+		}
+
+		stockHttp.HandleJSONResponse(result, w, http.StatusOK)
+	}).Methods(http.MethodPut, http.MethodOptions)
+}
+
+func WatchlistPreviewRuleHandler(router *mux.Router, watchlist *controllers.WatchlistController, extractUserID func(*http.Request) string) {
+	router.HandleFunc("/{id}/rule/preview", func(w http.ResponseWriter, r *http.Request) {
+		userID := extractUserID(r)
+		watchlistID, err := extractWatchlistID(r)
+
+		log := logrus.WithFields(logrus.Fields{"userId": userID, "requestId": reqid.GetRequestId(r), "watchlistId": watchlistID})
+
+		if err != nil {
+			log.Errorln(err)
+			stockHttp.HandleError(err, w)
+			return
+		}
+
+		result, err := watchlist.PreviewRule(r.Context(), log, watchlistID, userID)
 
 		if err != nil {
 			log.Errorln(err)
@@ -5,11 +5,11 @@
 package mocks
 
 import (
-	reflect "reflect"
-
+	context "context"
 	gomock "github.com/golang/mock/gomock"
 	model "github.com/nagymarci/stock-user-profile/model"
 	model0 "github.com/nagymarci/stock-watchlist/model"
+	reflect "reflect"
 )
 
 // MockstockClient is a mock of stockClient interface
@@ -36,32 +36,32 @@ func (m *MockstockClient) EXPECT() *MockstockClientMockRecorder {
 }
 
 // RegisterStock mocks base method
-func (m *MockstockClient) RegisterStock(symbol string) error {
+func (m *MockstockClient) RegisterStock(ctx context.Context, symbol string) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "RegisterStock", symbol)
+	ret := m.ctrl.Call(m, "RegisterStock", ctx, symbol)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // RegisterStock indicates an expected call of RegisterStock
-func (mr *MockstockClientMockRecorder) RegisterStock(symbol interface{}) *gomock.Call {
+func (mr *MockstockClientMockRecorder) RegisterStock(ctx, symbol interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RegisterStock", reflect.TypeOf((*MockstockClient)(nil).RegisterStock), symbol)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RegisterStock", reflect.TypeOf((*MockstockClient)(nil).RegisterStock), ctx, symbol)
 }
 
 // Get mocks base method
-func (m *MockstockClient) Get(symbol string) (model0.StockData, error) {
+func (m *MockstockClient) Get(ctx context.Context, symbol string) (model0.StockData, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Get", symbol)
+	ret := m.ctrl.Call(m, "Get", ctx, symbol)
 	ret0, _ := ret[0].(model0.StockData)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // Get indicates an expected call of Get
-func (mr *MockstockClientMockRecorder) Get(symbol interface{}) *gomock.Call {
+func (mr *MockstockClientMockRecorder) Get(ctx, symbol interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Get", reflect.TypeOf((*MockstockClient)(nil).Get), symbol)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Get", reflect.TypeOf((*MockstockClient)(nil).Get), ctx, symbol)
 }
 
 // MockuserprofileClient is a mock of userprofileClient interface
@@ -88,16 +88,16 @@ func (m *MockuserprofileClient) EXPECT() *MockuserprofileClientMockRecorder {
 }
 
 // GetUserprofile mocks base method
-func (m *MockuserprofileClient) GetUserprofile(userId string) (model.Userprofile, error) {
+func (m *MockuserprofileClient) GetUserprofile(ctx context.Context, userId string) (model.Userprofile, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetUserprofile", userId)
+	ret := m.ctrl.Call(m, "GetUserprofile", ctx, userId)
 	ret0, _ := ret[0].(model.Userprofile)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetUserprofile indicates an expected call of GetUserprofile
-func (mr *MockuserprofileClientMockRecorder) GetUserprofile(userId interface{}) *gomock.Call {
+func (mr *MockuserprofileClientMockRecorder) GetUserprofile(ctx, userId interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserprofile", reflect.TypeOf((*MockuserprofileClient)(nil).GetUserprofile), userId)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserprofile", reflect.TypeOf((*MockuserprofileClient)(nil).GetUserprofile), ctx, userId)
 }
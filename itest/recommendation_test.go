@@ -0,0 +1,49 @@
+package itest
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/nagymarci/stock-watchlist/database"
+	"github.com/nagymarci/stock-watchlist/model"
+)
+
+func TestRecommendationsSignalsAndPending(t *testing.T) {
+	t.Run("stores symbols containing dots", func(t *testing.T) {
+		defer cleanup()
+
+		rDb := database.NewRecommendations(db)
+		watchlistID := primitive.NewObjectID()
+
+		signals := map[string]model.SignalState{
+			"BRK.B": {Price: "green", Dividend: "red", Pe: "yellow"},
+			"INTC":  {Price: "red", Dividend: "green", Pe: "green"},
+		}
+
+		if err := rDb.UpdateSignals(watchlistID, signals); err != nil {
+			t.Fatal(err)
+		}
+
+		since := time.Date(2021, 3, 1, 15, 0, 0, 0, time.UTC)
+		pending := map[string]model.PendingChange{"BRK.B": {Entering: true, Since: since}}
+
+		if err := rDb.UpdatePending(watchlistID, pending); err != nil {
+			t.Fatal(err)
+		}
+
+		storedSignals, err := rDb.GetSignals(watchlistID)
+
+		if err != nil || !reflect.DeepEqual(storedSignals, signals) {
+			t.Fatalf("unexpected signals [%v] [%v]", storedSignals, err)
+		}
+
+		storedPending, err := rDb.GetPending(watchlistID)
+
+		if err != nil || len(storedPending) != 1 || !storedPending["BRK.B"].Entering || !storedPending["BRK.B"].Since.Equal(since) {
+			t.Fatalf("unexpected pending changes [%v] [%v]", storedPending, err)
+		}
+	})
+}
@@ -0,0 +1,92 @@
+package itest
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+
+	"github.com/nagymarci/stock-watchlist/controllers"
+	"github.com/nagymarci/stock-watchlist/database"
+	"github.com/nagymarci/stock-watchlist/handlers"
+	"github.com/nagymarci/stock-watchlist/model"
+	"github.com/nagymarci/stock-watchlist/service"
+)
+
+func TestStockGetHistoryHandler(t *testing.T) {
+	defer cleanup()
+
+	sDb := database.NewSnapshots(db)
+
+	day := func(year int, month time.Month, d int) time.Time {
+		return time.Date(year, month, d, 0, 0, 0, 0, time.UTC)
+	}
+
+	today := service.SnapshotDate(time.Now(), time.UTC)
+
+	for _, date := range []time.Time{day(2021, 3, 1), day(2021, 3, 2), day(2021, 3, 3), today.AddDate(0, 0, -1)} {
+		sDb.Save(model.StockSnapshot{Symbol: "INTC", Date: date, Data: model.StockData{Ticker: "INTC", Price: 50}})
+	}
+	sDb.Save(model.StockSnapshot{Symbol: "XOM", Date: day(2021, 3, 2)})
+	// saving the same day again replaces the snapshot
+	sDb.Save(model.StockSnapshot{Symbol: "INTC", Date: day(2021, 3, 2), Data: model.StockData{Ticker: "INTC", Price: 51}})
+
+	stockController := controllers.NewStockController(nil, nil, service.NewStockService(&mockSp500Client{}), sDb)
+
+	router := mux.NewRouter().PathPrefix("/stock").Subrouter()
+	handlers.StockGetHistoryHandler(router, stockController)
+
+	cases := []struct {
+		name     string
+		query    string
+		status   int
+		expected []time.Time
+	}{
+		{"defaults to the last year", "/stock/INTC/history", http.StatusOK, []time.Time{today.AddDate(0, 0, -1)}},
+		{"returns the range inclusive", "/stock/INTC/history?from=2021-03-01&to=2021-03-02", http.StatusOK, []time.Time{day(2021, 3, 1), day(2021, 3, 2)}},
+		{"defaults from to a year before to", "/stock/INTC/history?to=2021-03-03", http.StatusOK, []time.Time{day(2021, 3, 1), day(2021, 3, 2), day(2021, 3, 3)}},
+		{"returns empty list without snapshots", "/stock/KO/history?from=2021-03-01&to=2021-03-03", http.StatusOK, []time.Time{}},
+		{"rejects invalid from", "/stock/INTC/history?from=03/01/2021", http.StatusBadRequest, nil},
+		{"rejects invalid to", "/stock/INTC/history?to=yesterday", http.StatusBadRequest, nil},
+		{"rejects from after to", "/stock/INTC/history?from=2021-03-03&to=2021-03-01", http.StatusBadRequest, nil},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, c.query, nil))
+
+			if rec.Code != c.status {
+				t.Fatalf("expected [%d], got [%d] [%s]", c.status, rec.Code, rec.Body.String())
+			}
+
+			if c.expected == nil {
+				return
+			}
+
+			var result []model.StockSnapshot
+			json.NewDecoder(rec.Body).Decode(&result)
+
+			if len(result) != len(c.expected) {
+				t.Fatalf("expected [%v], got [%v]", c.expected, result)
+			}
+
+			for i := range result {
+				if result[i].Symbol != "INTC" || !result[i].Date.Equal(c.expected[i]) {
+					t.Fatalf("expected [%v], got [%v]", c.expected, result)
+				}
+			}
+		})
+	}
+
+	t.Run("keeps one snapshot per symbol and day", func(t *testing.T) {
+		snapshots, err := sDb.Get("INTC", day(2021, 3, 2), day(2021, 3, 2))
+
+		if err != nil || len(snapshots) != 1 || snapshots[0].Data.Price != 51 {
+			t.Fatalf("unexpected snapshots [%v] [%v]", snapshots, err)
+		}
+	})
+}
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
@@ -20,6 +21,7 @@ import (
 	"github.com/nagymarci/stock-watchlist/handlers"
 
 	"github.com/golang/mock/gomock"
+	"github.com/nagymarci/stock-watchlist/api"
 	"github.com/nagymarci/stock-watchlist/controllers"
 	"github.com/nagymarci/stock-watchlist/database"
 	"github.com/nagymarci/stock-watchlist/itest/mocks"
@@ -94,12 +96,12 @@ func TestWatchlistCreateHandler(t *testing.T) {
 		userprofileClient := mocks.NewMockuserprofileClient(ctrl)
 		sp500Client := mockSp500Client{}
 		stockService := service.NewStockService(&sp500Client)
-		wlC := controllers.NewWatchlistController(wlDb, stockClient, userprofileClient, stockService)
+		wlC := controllers.NewWatchlistController(wlDb, stockClient, userprofileClient, stockService, service.NewUSCalendar())
 
 		router := mux.NewRouter().PathPrefix("/watchlist").Subrouter()
 		handlers.WatchlistCreateHandler(router, wlC, func(r *http.Request) string { return "userId" })
 
-		stockClient.EXPECT().RegisterStock("INTC").Return(nil)
+		stockClient.EXPECT().RegisterStock(gomock.Any(), "INTC").Return(nil)
 		watchlistRequest := model.WatchlistRequest{Name: "name", Stocks: []string{"INTC"}, UserID: "userId"}
 
 		body, _ := json.Marshal(watchlistRequest)
@@ -140,12 +142,12 @@ func TestWatchlistCreateHandler(t *testing.T) {
 		userprofileClient := mocks.NewMockuserprofileClient(ctrl)
 		sp500Client := mockSp500Client{}
 		stockService := service.NewStockService(&sp500Client)
-		wlC := controllers.NewWatchlistController(wlDb, stockClient, userprofileClient, stockService)
+		wlC := controllers.NewWatchlistController(wlDb, stockClient, userprofileClient, stockService, service.NewUSCalendar())
 
 		router := mux.NewRouter().PathPrefix("/watchlist").Subrouter()
 		handlers.WatchlistCreateHandler(router, wlC, func(r *http.Request) string { return "userId" })
 
-		stockClient.EXPECT().RegisterStock("INTC").Return(nil)
+		stockClient.EXPECT().RegisterStock(gomock.Any(), "INTC").Return(nil)
 		watchlistRequest := model.WatchlistRequest{Name: "name", Stocks: []string{"INTC"}, UserID: "userId"}
 
 		body, _ := json.Marshal(watchlistRequest)
@@ -198,7 +200,7 @@ func TestWatchlistDeleteHandler(t *testing.T) {
 		userprofileClient := mocks.NewMockuserprofileClient(ctrl)
 		sp500Client := mockSp500Client{}
 		stockService := service.NewStockService(&sp500Client)
-		wlC := controllers.NewWatchlistController(wlDb, stockClient, userprofileClient, stockService)
+		wlC := controllers.NewWatchlistController(wlDb, stockClient, userprofileClient, stockService, service.NewUSCalendar())
 
 		router := mux.NewRouter().PathPrefix("/watchlist").Subrouter()
 		handlers.WatchlistDeleteHandler(router, wlC, func(r *http.Request) string { return "userId" })
@@ -239,7 +241,7 @@ func TestWatchlistGetAllHandler(t *testing.T) {
 		userprofileClient := mocks.NewMockuserprofileClient(ctrl)
 		sp500Client := mockSp500Client{}
 		stockService := service.NewStockService(&sp500Client)
-		wlC := controllers.NewWatchlistController(wlDb, stockClient, userprofileClient, stockService)
+		wlC := controllers.NewWatchlistController(wlDb, stockClient, userprofileClient, stockService, service.NewUSCalendar())
 
 		router := mux.NewRouter().PathPrefix("/watchlist").Subrouter()
 		handlers.WatchlistGetAllHandler(router, wlC, func(r *http.Request) string { return "userId" })
@@ -286,7 +288,7 @@ func TestWatchlistGetHandler(t *testing.T) {
 		userprofileClient := mocks.NewMockuserprofileClient(ctrl)
 		sp500Client := mockSp500Client{}
 		stockService := service.NewStockService(&sp500Client)
-		wlC := controllers.NewWatchlistController(wlDb, stockClient, userprofileClient, stockService)
+		wlC := controllers.NewWatchlistController(wlDb, stockClient, userprofileClient, stockService, service.NewUSCalendar())
 
 		router := mux.NewRouter().PathPrefix("/watchlist").Subrouter()
 		handlers.WatchlistGetHandler(router, wlC, func(r *http.Request) string { return "userId" })
@@ -351,10 +353,14 @@ func TestWatchlistGetCalculatedHandler(t *testing.T) {
 		expectedResultINTC.DividendYield = 2.678571428571429
 		expectedResultINTC.OptInYield = 3.5
 		expectedResultINTC.DividendColor = "yellow"
+		expectedResultINTC.Status = model.StockDataValid
+		expectedResultINTC.PriceStatus = model.MetricOk
+		expectedResultINTC.DividendStatus = model.MetricOk
+		expectedResultINTC.PeStatus = model.MetricOk
 
 		stockClient := mocks.NewMockstockClient(ctrl)
 
-		stockClient.EXPECT().Get(gomock.Any()).Return(stockINTC, nil).Times(2)
+		stockClient.EXPECT().Get(gomock.Any(), gomock.Any()).Return(stockINTC, nil).Times(2)
 
 		userprofileClient := mocks.NewMockuserprofileClient(ctrl)
 
@@ -363,10 +369,10 @@ func TestWatchlistGetCalculatedHandler(t *testing.T) {
 		defaultExpectation := 5.5
 		userprofile := userprofileModel.Userprofile{Email: "alice@example.com", ExpectedReturn: &expectedReturn, Expectations: []userprofileModel.Expectation{userprofileModel.Expectation{Stock: "INTC", ExpectedRaise: &expectedRaise}}, DefaultExpectation: &defaultExpectation}
 
-		userprofileClient.EXPECT().GetUserprofile("userId").Return(userprofile, nil)
+		userprofileClient.EXPECT().GetUserprofile(gomock.Any(), "userId").Return(userprofile, nil)
 		sp500Client := mockSp500Client{}
 		stockService := service.NewStockService(&sp500Client)
-		wlC := controllers.NewWatchlistController(wlDb, stockClient, userprofileClient, stockService)
+		wlC := controllers.NewWatchlistController(wlDb, stockClient, userprofileClient, stockService, service.NewUSCalendar())
 
 		router := mux.NewRouter().PathPrefix("/watchlist").Subrouter()
 		handlers.WatchlistGetCalculatedHandler(router, wlC, func(r *http.Request) string { return "userId" })
@@ -378,21 +384,136 @@ func TestWatchlistGetCalculatedHandler(t *testing.T) {
 
 		res := rec.Result()
 
-		var result []model.CalculatedStockInfo
+		var result model.CalculatedWatchlist
 		json.NewDecoder(res.Body).Decode(&result)
 
 		if res.StatusCode != http.StatusOK {
 			t.Fatalf("expected [%d], got [%d]", http.StatusOK, res.StatusCode)
 		}
 
-		if result[0] != expectedResultINTC {
+		if len(result.Items) != 2 || len(result.Unavailable) != 0 {
+			t.Fatalf("unexpected result [%v]", result)
+		}
+
+		if result.Items[0] != expectedResultINTC {
 			t.Fatalf("expected [%v], got [%v]", expectedResultINTC, result)
 		}
 
-		if result[1] != expectedResultINTC {
+		if result.Items[1] != expectedResultINTC {
 			t.Fatalf("expected [%v], got [%v]", expectedResultINTC, result)
 		}
 	})
+	t.Run("reports the stocks that could not be loaded", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		defer cleanup()
+
+		wlDb := database.NewWatchlists(db)
+		watchlistID, _ := wlDb.Create(model.WatchlistRequest{Name: "name", Stocks: []string{"INTC", "XOM", "KO"}, UserID: "userId"})
+
+		stockClient := mocks.NewMockstockClient(ctrl)
+		stockClient.EXPECT().Get(gomock.Any(), "INTC").Return(model.StockData{Ticker: "INTC", Price: 49.28}, nil)
+		stockClient.EXPECT().Get(gomock.Any(), "XOM").Return(model.StockData{}, fmt.Errorf("Failed to get [XOM], status code [%d]", http.StatusBadGateway))
+		stockClient.EXPECT().Get(gomock.Any(), "KO").Return(model.StockData{}, fmt.Errorf("Failed to get stock [KO] with error [%w]", api.ErrCircuitOpen))
+
+		userprofileClient := mocks.NewMockuserprofileClient(ctrl)
+		userprofileClient.EXPECT().GetUserprofile(gomock.Any(), "userId").Return(userprofileModel.Userprofile{}, errors.New("unavailable"))
+
+		wlC := controllers.NewWatchlistController(wlDb, stockClient, userprofileClient, service.NewStockService(&mockSp500Client{}), service.NewUSCalendar())
+
+		router := mux.NewRouter().PathPrefix("/watchlist").Subrouter()
+		handlers.WatchlistGetCalculatedHandler(router, wlC, func(r *http.Request) string { return "userId" })
+
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/watchlist/"+watchlistID.Hex()+"/calculated", nil))
+
+		var result model.CalculatedWatchlist
+		json.NewDecoder(rec.Result().Body).Decode(&result)
+
+		if rec.Code != http.StatusOK || len(result.Items) != 1 || result.Items[0].Ticker != "INTC" {
+			t.Fatalf("unexpected result [%d] [%v]", rec.Code, result)
+		}
+
+		if len(result.Unavailable) != 2 || result.Unavailable[0].Symbol != "XOM" || result.Unavailable[0].Reason != model.UnavailableUpstream || result.Unavailable[1].Symbol != "KO" || result.Unavailable[1].Reason != model.UnavailableCircuitOpen {
+			t.Fatalf("unexpected unavailable stocks [%v]", result.Unavailable)
+		}
+	})
+}
+
+func TestWatchlistSetRuleHandler(t *testing.T) {
+	t.Run("saves the notification rule of the watchlist", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		defer cleanup()
+
+		wlDb := database.NewWatchlists(db)
+		watchlistRequest := model.WatchlistRequest{Name: "name", Stocks: []string{"INTC"}, UserID: "userId"}
+		watchlistID, _ := wlDb.Create(watchlistRequest)
+
+		stockClient := mocks.NewMockstockClient(ctrl)
+		userprofileClient := mocks.NewMockuserprofileClient(ctrl)
+		sp500Client := mockSp500Client{}
+		stockService := service.NewStockService(&sp500Client)
+		wlC := controllers.NewWatchlistController(wlDb, stockClient, userprofileClient, stockService, service.NewUSCalendar())
+
+		router := mux.NewRouter().PathPrefix("/watchlist").Subrouter()
+		handlers.WatchlistSetRuleHandler(router, wlC, func(r *http.Request) string { return "userId" })
+
+		rule := model.NotificationRule{MinGreen: 1, RequiredSignals: []string{model.SignalDividend}, MinScore: 1.5}
+		body, _ := json.Marshal(rule)
+
+		req := httptest.NewRequest(http.MethodPut, "/watchlist/"+watchlistID.Hex()+"/rule", bytes.NewReader(body))
+		rec := httptest.NewRecorder()
+
+		router.ServeHTTP(rec, req)
+
+		res := rec.Result()
+
+		if res.StatusCode != http.StatusOK {
+			t.Fatalf("expected [%d], got [%d]", http.StatusOK, res.StatusCode)
+		}
+
+		savedObject, err := wlDb.Get(watchlistID)
+
+		if err != nil {
+			t.Fatal("watchlist not found in Db ", err)
+		}
+
+		if savedObject.Rule == nil || savedObject.Rule.MinGreen != 1 || savedObject.Rule.MinScore != 1.5 || savedObject.Rule.RequiredSignals[0] != model.SignalDividend {
+			t.Fatalf("expected [%+v], got [%+v]", rule, savedObject.Rule)
+		}
+	})
+	t.Run("rejects invalid rule", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		defer cleanup()
+
+		wlDb := database.NewWatchlists(db)
+		watchlistRequest := model.WatchlistRequest{Name: "name", Stocks: []string{"INTC"}, UserID: "userId"}
+		watchlistID, _ := wlDb.Create(watchlistRequest)
+
+		stockClient := mocks.NewMockstockClient(ctrl)
+		userprofileClient := mocks.NewMockuserprofileClient(ctrl)
+		sp500Client := mockSp500Client{}
+		stockService := service.NewStockService(&sp500Client)
+		wlC := controllers.NewWatchlistController(wlDb, stockClient, userprofileClient, stockService, service.NewUSCalendar())
+
+		router := mux.NewRouter().PathPrefix("/watchlist").Subrouter()
+		handlers.WatchlistSetRuleHandler(router, wlC, func(r *http.Request) string { return "userId" })
+
+		body, _ := json.Marshal(model.NotificationRule{MinGreen: 4})
+
+		req := httptest.NewRequest(http.MethodPut, "/watchlist/"+watchlistID.Hex()+"/rule", bytes.NewReader(body))
+		rec := httptest.NewRecorder()
+
+		router.ServeHTTP(rec, req)
+
+		savedObject, _ := wlDb.Get(watchlistID)
+
+		if rec.Result().StatusCode == http.StatusOK || savedObject.Rule != nil {
+			t.Fatalf("expected rule to be rejected, got [%d] [%+v]", rec.Result().StatusCode, savedObject.Rule)
+		}
+	})
 }
 
 func cleanup() {
@@ -0,0 +1,18 @@
+package model
+
+import "go.mongodb.org/mongo-driver/bson/primitive"
+
+//AlertRule is a named expression evaluated for every stock of a watchlist
+type AlertRule struct {
+	ID          primitive.ObjectID `bson:"_id" json:"id"`
+	WatchlistID primitive.ObjectID `bson:"watchlistId" json:"watchlistId"`
+	UserID      string             `bson:"userId" json:"userId"`
+	Name        string             `bson:"name" json:"name"`
+	Expression  string             `bson:"expression" json:"expression"`
+	Matching    []string           `bson:"matching" json:"matching"`
+}
+
+type AlertRuleRequest struct {
+	Name       string `json:"name"`
+	Expression string `json:"expression"`
+}
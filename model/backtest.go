@@ -0,0 +1,34 @@
+package model
+
+import "time"
+
+//BacktestStrategy describes when a stock is held during the backtest
+type BacktestStrategy struct {
+	NumReqs    int  `json:"numReqs"`
+	PriceGreen bool `json:"priceGreen"`
+}
+
+//BacktestPerformance holds the results of a backtest, returns and drawdown are in percent
+type BacktestPerformance struct {
+	Trades      int     `json:"trades"`
+	Return      float64 `json:"return"`
+	HitRate     float64 `json:"hitRate"`
+	MaxDrawdown float64 `json:"maxDrawdown"`
+}
+
+//SymbolBacktest holds the backtest results of one symbol
+type SymbolBacktest struct {
+	Symbol string `json:"symbol"`
+	Days   int    `json:"days"`
+	BacktestPerformance
+}
+
+//BacktestResult holds the backtest results of a watchlist
+type BacktestResult struct {
+	From     time.Time           `json:"from"`
+	To       time.Time           `json:"to"`
+	Strategy BacktestStrategy    `json:"strategy"`
+	Days     int                 `json:"days"`
+	Symbols  []SymbolBacktest    `json:"symbols"`
+	Total    BacktestPerformance `json:"total"`
+}
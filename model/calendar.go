@@ -0,0 +1,37 @@
+package model
+
+import "time"
+
+//MarketCalendar describes the trading sessions of an exchange. Open and Close are the regular
+//session times in the time zone of the exchange, formatted as 15:04
+type MarketCalendar struct {
+	Exchange string          `json:"exchange"`
+	TimeZone string          `json:"timeZone"`
+	Open     string          `json:"open"`
+	Close    string          `json:"close"`
+	Holidays []MarketHoliday `json:"holidays"`
+}
+
+//MarketHoliday is a day the exchange is closed, or closes early if EarlyClose is set
+type MarketHoliday struct {
+	Date       string `json:"date"`
+	Name       string `json:"name"`
+	EarlyClose string `json:"earlyClose,omitempty"`
+}
+
+//MarketDay is the session of the exchange on a day, Opens and Closes are only set on trading days
+type MarketDay struct {
+	Date       string     `json:"date"`
+	Open       bool       `json:"open"`
+	Opens      *time.Time `json:"opens,omitempty"`
+	Closes     *time.Time `json:"closes,omitempty"`
+	EarlyClose bool       `json:"earlyClose"`
+	Holiday    string     `json:"holiday,omitempty"`
+}
+
+//MarketSchedule lists the sessions of the exchange in a date range
+type MarketSchedule struct {
+	Exchange string      `json:"exchange"`
+	TimeZone string      `json:"timeZone"`
+	Days     []MarketDay `json:"days"`
+}
@@ -0,0 +1,32 @@
+package model
+
+import "go.mongodb.org/mongo-driver/bson/primitive"
+
+//Types of notification channels
+const (
+	ChannelEmail   = "email"
+	ChannelWebhook = "webhook"
+	ChannelChat    = "chat"
+)
+
+//NotificationChannel is a destination of the notifications of a user.
+//Address is the email address, or the URL of the webhook
+type NotificationChannel struct {
+	ID      primitive.ObjectID `bson:"_id" json:"id"`
+	UserID  string             `bson:"userId" json:"userId"`
+	Type    string             `bson:"type" json:"type"`
+	Name    string             `bson:"name" json:"name"`
+	Address string             `bson:"address" json:"address"`
+	Secret  string             `bson:"secret,omitempty" json:"-"`
+}
+
+type NotificationChannelRequest struct {
+	Type    string `json:"type"`
+	Name    string `json:"name"`
+	Address string `json:"address"`
+	Secret  string `json:"secret"`
+}
+
+type WatchlistChannelsRequest struct {
+	Channels []primitive.ObjectID `json:"channels"`
+}
@@ -0,0 +1,16 @@
+package model
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+//DigestEvent is a notification queued for the next digest of the user
+type DigestEvent struct {
+	ID           primitive.ObjectID   `bson:"_id"`
+	UserID       string               `bson:"userId"`
+	Channels     []primitive.ObjectID `bson:"channels,omitempty"`
+	Notification Notification         `bson:"notification"`
+	CreatedAt    time.Time            `bson:"createdAt"`
+}
@@ -0,0 +1,46 @@
+package model
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+//Statuses of the notification records
+const (
+	NotificationSent   = "sent"
+	NotificationFailed = "failed"
+)
+
+//NotificationRecord is one attempt to deliver a notification through a channel
+type NotificationRecord struct {
+	ID           primitive.ObjectID `bson:"_id" json:"id"`
+	UserID       string             `bson:"userId" json:"userId"`
+	WatchlistID  primitive.ObjectID `bson:"watchlistId,omitempty" json:"watchlistId,omitempty"`
+	ChannelID    primitive.ObjectID `bson:"channelId,omitempty" json:"channelId,omitempty"`
+	ChannelType  string             `bson:"channelType" json:"channelType"`
+	ChannelName  string             `bson:"channelName" json:"channelName"`
+	Notification Notification       `bson:"notification" json:"notification"`
+	Message      RenderedMessage    `bson:"message" json:"message"`
+	Status       string             `bson:"status" json:"status"`
+	Error        string             `bson:"error,omitempty" json:"error,omitempty"`
+	CreatedAt    time.Time          `bson:"createdAt" json:"createdAt"`
+	SentAt       *time.Time         `bson:"sentAt,omitempty" json:"sentAt,omitempty"`
+}
+
+//NotificationQuery filters and pages the notification records, empty fields match everything
+type NotificationQuery struct {
+	UserID      string
+	WatchlistID primitive.ObjectID
+	Status      string
+	Page        int64
+	PageSize    int64
+}
+
+//NotificationPage is one page of the notification records, newest first
+type NotificationPage struct {
+	Items    []NotificationRecord `json:"items"`
+	Page     int64                `json:"page"`
+	PageSize int64                `json:"pageSize"`
+	Total    int64                `json:"total"`
+}
@@ -0,0 +1,20 @@
+package model
+
+import "time"
+
+//Lease is held by one instance until it expires, unless the holder renews it
+type Lease struct {
+	Name       string    `bson:"_id" json:"name"`
+	Holder     string    `bson:"holder" json:"holder"`
+	AcquiredAt time.Time `bson:"acquiredAt" json:"acquiredAt"`
+	RenewedAt  time.Time `bson:"renewedAt" json:"renewedAt"`
+	ExpiresAt  time.Time `bson:"expiresAt" json:"expiresAt"`
+}
+
+//SchedulerStatus shows which instance runs the scheduled jobs
+type SchedulerStatus struct {
+	Instance string            `json:"instance"`
+	Leader   bool              `json:"leader"`
+	Lease    *Lease            `json:"lease"`
+	LastRun  *NotifierRunStats `json:"lastRun,omitempty"`
+}
@@ -0,0 +1,38 @@
+package model
+
+import "go.mongodb.org/mongo-driver/bson/primitive"
+
+//Kinds of notifications
+const (
+	NotificationChange = "change"
+	NotificationAlert  = "alert"
+	NotificationDigest = "digest"
+	NotificationPrice  = "priceAlert"
+	NotificationSignal = "signal"
+)
+
+//Notification holds the changes of a watchlist a user is notified about.
+//A digest holds the net changes of the watchlists since the last digest in Changes.
+//A signal notification lists the changed signals in Transitions, and their symbols in Added.
+//It is rendered in Locale, taken from the preferences of the user
+type Notification struct {
+	Kind          string                `bson:"kind" json:"kind"`
+	WatchlistID   primitive.ObjectID    `bson:"watchlistId" json:"watchlistId"`
+	WatchlistName string                `bson:"watchlistName" json:"watchlistName"`
+	AlertName     string                `bson:"alertName,omitempty" json:"alertName,omitempty"`
+	Removed       []string              `bson:"removed" json:"removed"`
+	Added         []string              `bson:"added" json:"added"`
+	Current       []string              `bson:"current" json:"current"`
+	Stocks        []CalculatedStockInfo `bson:"stocks" json:"stocks"`
+	Changes       []Notification        `bson:"changes,omitempty" json:"changes,omitempty"`
+	Transitions   []SignalTransition    `bson:"transitions,omitempty" json:"transitions,omitempty"`
+	Locale        string                `bson:"locale,omitempty" json:"locale,omitempty"`
+}
+
+//RenderedMessage is a notification rendered for delivery
+type RenderedMessage struct {
+	Subject     string           `bson:"subject" json:"subject"`
+	Text        string           `bson:"text" json:"text"`
+	HTML        string           `bson:"html" json:"html,omitempty"`
+	Unsubscribe UnsubscribeLinks `bson:"-" json:"-"`
+}
@@ -0,0 +1,35 @@
+package model
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+//Statuses of the outbox entries
+const (
+	OutboxPending = "pending"
+	OutboxDead    = "dead"
+)
+
+//OutboxEntry is a notification waiting for delivery. Failed deliveries are retried
+//with backoff, and the entry is dead-lettered after the last attempt
+type OutboxEntry struct {
+	ID            primitive.ObjectID `bson:"_id" json:"id"`
+	Watchlist     Watchlist          `bson:"watchlist" json:"watchlist"`
+	Email         string             `bson:"email" json:"email"`
+	Notification  Notification       `bson:"notification" json:"notification"`
+	Status        string             `bson:"status" json:"status"`
+	Attempts      int                `bson:"attempts" json:"attempts"`
+	LastError     string             `bson:"lastError,omitempty" json:"lastError,omitempty"`
+	CreatedAt     time.Time          `bson:"createdAt" json:"createdAt"`
+	NextAttemptAt time.Time          `bson:"nextAttemptAt" json:"nextAttemptAt"`
+}
+
+//OutboxPage is one page of the outbox entries, oldest first
+type OutboxPage struct {
+	Items    []OutboxEntry `json:"items"`
+	Page     int64         `json:"page"`
+	PageSize int64         `json:"pageSize"`
+	Total    int64         `json:"total"`
+}
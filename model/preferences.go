@@ -0,0 +1,65 @@
+package model
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+//Delivery modes of the notifications
+const (
+	DeliveryImmediate = "immediate"
+	DeliveryDaily     = "daily"
+	DeliveryWeekly    = "weekly"
+)
+
+//NotificationPreferences holds how a user wants to receive the notifications.
+//Digests are sent at DigestHour in TimeZone, weekly ones on DigestWeekday (0 is Sunday).
+//Channels are used for the watchlists without chosen channels. The notifications are written in Locale,
+//e.g. en or hu-HU, English if it is empty
+type NotificationPreferences struct {
+	UserID        string                 `bson:"_id" json:"userId"`
+	Disabled      bool                   `bson:"disabled" json:"disabled"`
+	Delivery      string                 `bson:"delivery" json:"delivery"`
+	DigestHour    int                    `bson:"digestHour" json:"digestHour"`
+	DigestWeekday int                    `bson:"digestWeekday" json:"digestWeekday"`
+	TimeZone      string                 `bson:"timeZone" json:"timeZone"`
+	Locale        string                 `bson:"locale,omitempty" json:"locale"`
+	QuietHours    *QuietHours            `bson:"quietHours,omitempty" json:"quietHours,omitempty"`
+	Channels      []primitive.ObjectID   `bson:"channels,omitempty" json:"channels"`
+	Watchlists    []WatchlistPreferences `bson:"watchlists,omitempty" json:"watchlists"`
+	LastDigest    time.Time              `bson:"lastDigest" json:"lastDigest"`
+}
+
+//QuietHours is the period no immediate notification is sent in, from Start until End hour
+//in the time zone of the user. It wraps midnight if End is before Start
+type QuietHours struct {
+	Start int `bson:"start" json:"start"`
+	End   int `bson:"end" json:"end"`
+}
+
+//WatchlistPreferences holds the preferences of one watchlist of the user
+type WatchlistPreferences struct {
+	WatchlistID  primitive.ObjectID `bson:"watchlistId" json:"watchlistId"`
+	Disabled     bool               `bson:"disabled" json:"disabled"`
+	MutedSymbols []string           `bson:"mutedSymbols,omitempty" json:"mutedSymbols"`
+	SnoozeUntil  *time.Time         `bson:"snoozeUntil,omitempty" json:"snoozeUntil,omitempty"`
+}
+
+type NotificationPreferencesRequest struct {
+	Disabled      bool                   `json:"disabled"`
+	Delivery      string                 `json:"delivery"`
+	DigestHour    int                    `json:"digestHour"`
+	DigestWeekday int                    `json:"digestWeekday"`
+	TimeZone      string                 `json:"timeZone"`
+	Locale        string                 `json:"locale"`
+	QuietHours    *QuietHours            `json:"quietHours"`
+	Channels      []primitive.ObjectID   `json:"channels"`
+	Watchlists    []WatchlistPreferences `json:"watchlists"`
+}
+
+type WatchlistPreferencesRequest struct {
+	Disabled     bool       `json:"disabled"`
+	MutedSymbols []string   `json:"mutedSymbols"`
+	SnoozeUntil  *time.Time `json:"snoozeUntil"`
+}
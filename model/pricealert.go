@@ -0,0 +1,45 @@
+package model
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+//Conditions of the price alerts
+const (
+	PriceBelow = "priceBelow"
+	PriceAbove = "priceAbove"
+	YieldAbove = "yieldAbove"
+	YieldBelow = "yieldBelow"
+)
+
+//PriceAlert notifies the user when the price or the dividend yield of a symbol crosses the threshold.
+//A one-shot alert is deactivated after it fired, a recurring one fires again after the condition
+//stopped holding and holds again
+type PriceAlert struct {
+	ID        primitive.ObjectID `bson:"_id" json:"id"`
+	UserID    string             `bson:"userId" json:"userId"`
+	Symbol    string             `bson:"symbol" json:"symbol"`
+	Condition string             `bson:"condition" json:"condition"`
+	Threshold float64            `bson:"threshold" json:"threshold"`
+	Recurring bool               `bson:"recurring" json:"recurring"`
+	Active    bool               `bson:"active" json:"active"`
+	Triggered bool               `bson:"triggered" json:"triggered"`
+	LastFired *time.Time         `bson:"lastFired,omitempty" json:"lastFired,omitempty"`
+	Firings   []PriceAlertFiring `bson:"firings,omitempty" json:"firings"`
+}
+
+//PriceAlertFiring records when a price alert fired
+type PriceAlertFiring struct {
+	At            time.Time `bson:"at" json:"at"`
+	Price         float64   `bson:"price" json:"price"`
+	DividendYield float64   `bson:"dividendYield" json:"dividendYield"`
+}
+
+type PriceAlertRequest struct {
+	Symbol    string  `json:"symbol"`
+	Condition string  `json:"condition"`
+	Threshold float64 `json:"threshold"`
+	Recurring bool    `json:"recurring"`
+}
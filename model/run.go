@@ -0,0 +1,32 @@
+package model
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+//NotifierRunStats summarizes one run of the notifier
+type NotifierRunStats struct {
+	StartedAt            time.Time `json:"startedAt"`
+	DurationMs           int64     `json:"durationMs"`
+	Watchlists           int       `json:"watchlists"`
+	Symbols              int       `json:"symbols"`
+	Users                int       `json:"users"`
+	FetchFailures        int64     `json:"fetchFailures"`
+	NotificationsSent    int64     `json:"notificationsSent"`
+	NotificationFailures int64     `json:"notificationFailures"`
+}
+
+//NotificationPreview holds the notifications the notifier would send about the watchlist now
+type NotificationPreview struct {
+	WatchlistID   primitive.ObjectID      `json:"watchlistId"`
+	Enabled       bool                    `json:"enabled"`
+	Notifications []PreviewedNotification `json:"notifications"`
+}
+
+//PreviewedNotification is a notification and its rendered message, that was not sent
+type PreviewedNotification struct {
+	Notification Notification    `json:"notification"`
+	Message      RenderedMessage `json:"message"`
+}
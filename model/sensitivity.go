@@ -0,0 +1,29 @@
+package model
+
+//SignalTarget holds the price at which a signal turns green, and its distance from the current price
+type SignalTarget struct {
+	Status       string  `json:"status"`
+	TargetPrice  float64 `json:"targetPrice"`
+	Delta        float64 `json:"delta"`
+	DeltaPercent float64 `json:"deltaPercent"`
+}
+
+//OptInPriceShift holds the opt-in price after a change in the inputs, and its distance from the current opt-in price
+type OptInPriceShift struct {
+	OptInPrice float64 `json:"optInPrice"`
+	Delta      float64 `json:"delta"`
+}
+
+//StockSensitivity holds the sensitivity analysis of a stock
+type StockSensitivity struct {
+	Ticker          string          `json:"ticker"`
+	Price           float64         `json:"price"`
+	OptInPrice      float64         `json:"optInPrice"`
+	PriceTarget     SignalTarget    `json:"priceTarget"`
+	DividendTarget  SignalTarget    `json:"dividendTarget"`
+	PeTarget        SignalTarget    `json:"peTarget"`
+	Sp500Up         OptInPriceShift `json:"sp500Up"`
+	Sp500Down       OptInPriceShift `json:"sp500Down"`
+	ExpectationUp   OptInPriceShift `json:"expectationUp"`
+	ExpectationDown OptInPriceShift `json:"expectationDown"`
+}
@@ -0,0 +1,25 @@
+package model
+
+//SignalState holds the colors of the signals of a stock
+type SignalState struct {
+	Price    string `bson:"price" json:"price"`
+	Dividend string `bson:"dividend" json:"dividend"`
+	Pe       string `bson:"pe" json:"pe"`
+}
+
+//SignalTransition is a signal of a stock changing its color
+type SignalTransition struct {
+	Symbol string `bson:"symbol" json:"symbol"`
+	Signal string `bson:"signal" json:"signal"`
+	From   string `bson:"from" json:"from"`
+	To     string `bson:"to" json:"to"`
+}
+
+//SignalSubscription selects the transitions of a signal the user is notified about. From and To
+//are colors, empty matches any color, e.g. {dividend, "", green} is the dividend turning green,
+//{pe, green, ""} is the PE leaving green
+type SignalSubscription struct {
+	Signal string `bson:"signal" json:"signal"`
+	From   string `bson:"from,omitempty" json:"from,omitempty"`
+	To     string `bson:"to,omitempty" json:"to,omitempty"`
+}
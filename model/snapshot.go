@@ -0,0 +1,13 @@
+package model
+
+import "time"
+
+//StockSnapshot holds the stock data and the calculated information of a stock for one day
+type StockSnapshot struct {
+	ID            string              `bson:"_id" json:"-"`
+	Symbol        string              `bson:"symbol" json:"symbol"`
+	Date          time.Time           `bson:"date" json:"date"`
+	Sp500DivYield float64             `bson:"sp500DivYield" json:"sp500DivYield"`
+	Data          StockData           `bson:"data" json:"data"`
+	Calculated    CalculatedStockInfo `bson:"calculated" json:"calculated"`
+}
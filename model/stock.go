@@ -20,17 +20,58 @@ type StockData struct {
 	DividendYield5yr dividendYieldInfo `json:"dividendYield5yr"`
 }
 
+//Classification of the StockData used for the calculation
+const (
+	StockDataValid           = "valid"
+	StockDataMissingDividend = "missingDividend"
+	StockDataNegativeEps     = "negativeEps"
+	StockDataZeroPrice       = "zeroPrice"
+	StockDataStaleBenchmark  = "staleBenchmark"
+)
+
+//Status of one calculated metric. A stale metric is calculated from the stock data, but its
+//opt-in value depends on the benchmark which is not available
+const (
+	MetricOk               = "ok"
+	MetricInsufficientData = "insufficientData"
+	MetricStaleBenchmark   = "staleBenchmark"
+)
+
 //CalculatedStockInfo holds the data calculated for investment suggestions
 type CalculatedStockInfo struct {
 	Ticker         string  `json:"ticker"`
+	Status         string  `json:"status"`
 	Price          float64 `json:"price"`
 	OptInPrice     float64 `json:"optInPrice"`
 	PriceColor     string  `json:"priceColor"`
+	PriceStatus    string  `json:"priceStatus"`
 	AnnualDividend float64 `json:"dividend"`
 	DividendYield  float64 `json:"dividendYield"`
 	OptInYield     float64 `json:"optInYield"`
 	DividendColor  string  `json:"dividendColor"`
+	DividendStatus string  `json:"dividendStatus"`
 	CurrentPe      float64 `json:"currentPe"`
 	OptInPe        float64 `json:"optInPe"`
 	PeColor        string  `json:"pecolor"`
+	PeStatus       string  `json:"peStatus"`
+}
+
+//Reasons of a stock being unavailable
+const (
+	UnavailableTimeout     = "timeout"
+	UnavailableCircuitOpen = "circuitOpen"
+	UnavailableUpstream    = "upstreamError"
+)
+
+//CalculatedWatchlist holds the calculated stocks of a watchlist, and the stocks whose data could not be loaded
+type CalculatedWatchlist struct {
+	Items       []CalculatedStockInfo `json:"items"`
+	Unavailable []UnavailableStock    `json:"unavailable"`
+}
+
+//UnavailableStock is a stock whose data could not be loaded, Error holds the details of Reason
+type UnavailableStock struct {
+	Symbol string `json:"symbol"`
+	Reason string `json:"reason"`
+	Error  string `json:"error"`
 }
@@ -0,0 +1,16 @@
+package model
+
+import "go.mongodb.org/mongo-driver/bson/primitive"
+
+//UnsubscribeLinks are the signed links to disable the notifications of one watchlist, or all of them
+type UnsubscribeLinks struct {
+	Watchlist string `json:"watchlist,omitempty"`
+	All       string `json:"all,omitempty"`
+}
+
+//UnsubscribeResult tells what is disabled by the link, WatchlistID is empty if every notification is.
+//Disabled is only set once the link was confirmed
+type UnsubscribeResult struct {
+	WatchlistID primitive.ObjectID `json:"watchlistId,omitempty"`
+	Disabled    bool               `json:"disabled"`
+}
@@ -1,16 +1,75 @@
 package model
 
-import "go.mongodb.org/mongo-driver/bson/primitive"
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
 
 type Watchlist struct {
-	ID     primitive.ObjectID `bson:"_id" json:"id"`
-	Name   string             `bson:"name" json:"name"`
-	Stocks []string           `bson:"stocks" json:"stocks"`
-	UserID string             `bson:"userId" json:"userId"`
+	ID            primitive.ObjectID   `bson:"_id" json:"id"`
+	Name          string               `bson:"name" json:"name"`
+	Stocks        []string             `bson:"stocks" json:"stocks"`
+	UserID        string               `bson:"userId" json:"userId"`
+	Rule          *NotificationRule    `bson:"rule,omitempty" json:"rule,omitempty"`
+	Channels      []primitive.ObjectID `bson:"channels,omitempty" json:"channels,omitempty"`
+	Schedule      *CheckSchedule       `bson:"schedule,omitempty" json:"schedule,omitempty"`
+	NextCheckAt   *time.Time           `bson:"nextCheckAt,omitempty" json:"nextCheckAt,omitempty"`
+	Subscriptions []SignalSubscription `bson:"subscriptions,omitempty" json:"subscriptions,omitempty"`
 }
 
 type WatchlistRequest struct {
-	Name   string   `bson:"name" json:"name"`
-	Stocks []string `bson:"stocks" json:"stocks"`
-	UserID string   `bson:"userId"`
+	Name   string            `bson:"name" json:"name"`
+	Stocks []string          `bson:"stocks" json:"stocks"`
+	UserID string            `bson:"userId"`
+	Rule   *NotificationRule `bson:"rule,omitempty" json:"rule,omitempty"`
+}
+
+//Signals of a calculated stock
+const (
+	SignalPrice    = "price"
+	SignalDividend = "dividend"
+	SignalPe       = "pe"
+)
+
+//NotificationRule describes which stocks of a watchlist are notified about.
+//The score of a stock is the sum of its signals, green counts 1, yellow counts 0.5
+type NotificationRule struct {
+	MinGreen        int         `bson:"minGreen" json:"minGreen"`
+	RequiredSignals []string    `bson:"requiredSignals" json:"requiredSignals"`
+	MinScore        float64     `bson:"minScore" json:"minScore"`
+	Hysteresis      *Hysteresis `bson:"hysteresis,omitempty" json:"hysteresis,omitempty"`
+}
+
+//Hysteresis keeps stocks hovering around their opt-in price from entering and leaving the recommended stocks
+//on every check. A stock only enters once its price is Buffer percent below the opt-in price, and only leaves
+//once its price is Buffer percent above it. The change also has to hold for DwellMinutes before it is notified
+type Hysteresis struct {
+	Buffer       float64 `bson:"buffer" json:"buffer"`
+	DwellMinutes int     `bson:"dwellMinutes" json:"dwellMinutes"`
+}
+
+//PendingChange is a stock waiting for the dwell time to enter or leave the recommended stocks
+type PendingChange struct {
+	Entering bool      `bson:"entering" json:"entering"`
+	Since    time.Time `bson:"since" json:"since"`
+}
+
+//Kinds of check schedules
+const (
+	ScheduleHourly      = "hourly"
+	ScheduleMarketOpen  = "marketOpen"
+	ScheduleMarketClose = "marketClose"
+	ScheduleDaily       = "daily"
+	ScheduleCron        = "cron"
+)
+
+//CheckSchedule tells when the notifier checks the watchlist. Hourly checks run around the trading session,
+//daily checks at Time (15:04), cron checks follow a standard cron expression. TimeZone applies to the
+//daily and the cron checks, defaults to the time zone of the exchange
+type CheckSchedule struct {
+	Kind     string `bson:"kind" json:"kind"`
+	Time     string `bson:"time,omitempty" json:"time,omitempty"`
+	Cron     string `bson:"cron,omitempty" json:"cron,omitempty"`
+	TimeZone string `bson:"timeZone,omitempty" json:"timeZone,omitempty"`
 }
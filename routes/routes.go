@@ -13,7 +13,7 @@ import (
 	"github.com/nagymarci/stock-watchlist/controllers"
 )
 
-func Route(watchlistController *controllers.WatchlistController, stockController *controllers.StockController) http.Handler {
+func Route(watchlistController *controllers.WatchlistController, stockController *controllers.StockController, backtestController *controllers.BacktestController, alertController *controllers.AlertController, channelController *controllers.ChannelController, preferencesController *controllers.PreferencesController, priceAlertController *controllers.PriceAlertController, notificationController *controllers.NotificationController, statusController *controllers.StatusController, outboxController *controllers.OutboxController, unsubscribeController *controllers.UnsubscribeController, notifierController *controllers.NotifierController, calendarController *controllers.CalendarController, adminScope string) http.Handler {
 	router := mux.NewRouter()
 	router.Use(corsMiddleware)
 
@@ -23,10 +23,61 @@ func Route(watchlistController *controllers.WatchlistController, stockController
 	handlers.WatchlistGetAllHandler(watchlist, watchlistController, authorization.DefaultExtractUserID)
 	handlers.WatchlistGetHandler(watchlist, watchlistController, authorization.DefaultExtractUserID)
 	handlers.WatchlistGetCalculatedHandler(watchlist, watchlistController, authorization.DefaultExtractUserID)
+	handlers.WatchlistSetRuleHandler(watchlist, watchlistController, authorization.DefaultExtractUserID)
+	handlers.WatchlistSetScheduleHandler(watchlist, watchlistController, authorization.DefaultExtractUserID)
+	handlers.WatchlistSetSubscriptionsHandler(watchlist, watchlistController, authorization.DefaultExtractUserID)
+	handlers.WatchlistPreviewRuleHandler(watchlist, watchlistController, authorization.DefaultExtractUserID)
+	handlers.WatchlistGetSensitivityHandler(watchlist, watchlistController, authorization.DefaultExtractUserID)
+	handlers.WatchlistBacktestHandler(watchlist, backtestController, authorization.DefaultExtractUserID)
+	handlers.AlertCreateHandler(watchlist, alertController, authorization.DefaultExtractUserID)
+	handlers.AlertGetAllHandler(watchlist, alertController, authorization.DefaultExtractUserID)
+	handlers.AlertDeleteHandler(watchlist, alertController, authorization.DefaultExtractUserID)
+	handlers.WatchlistSetChannelsHandler(watchlist, channelController, authorization.DefaultExtractUserID)
+	handlers.WatchlistNotifyPreviewHandler(watchlist, notifierController, authorization.DefaultExtractUserID)
+
+	channels := mux.NewRouter().PathPrefix("/channels").Subrouter()
+	handlers.ChannelCreateHandler(channels, channelController, authorization.DefaultExtractUserID)
+	handlers.ChannelGetAllHandler(channels, channelController, authorization.DefaultExtractUserID)
+	handlers.ChannelDeleteHandler(channels, channelController, authorization.DefaultExtractUserID)
+
+	preferences := mux.NewRouter().PathPrefix("/preferences").Subrouter()
+	handlers.PreferencesGetHandler(preferences, preferencesController, authorization.DefaultExtractUserID)
+	handlers.PreferencesSetHandler(preferences, preferencesController, authorization.DefaultExtractUserID)
+	handlers.PreferencesSetWatchlistHandler(preferences, preferencesController, authorization.DefaultExtractUserID)
+
+	priceAlerts := mux.NewRouter().PathPrefix("/pricealerts").Subrouter()
+	handlers.PriceAlertCreateHandler(priceAlerts, priceAlertController, authorization.DefaultExtractUserID)
+	handlers.PriceAlertGetAllHandler(priceAlerts, priceAlertController, authorization.DefaultExtractUserID)
+	handlers.PriceAlertDeleteHandler(priceAlerts, priceAlertController, authorization.DefaultExtractUserID)
+
+	notifications := mux.NewRouter().PathPrefix("/notifications").Subrouter()
+	handlers.NotificationGetAllHandler(notifications, notificationController, authorization.DefaultExtractUserID)
+
+	admin := mux.NewRouter().PathPrefix("/admin").Subrouter()
+	handlers.AdminNotificationGetAllHandler(admin, notificationController, authorization.DefaultExtractUserID)
+	handlers.AdminOutboxGetAllHandler(admin, outboxController, authorization.DefaultExtractUserID)
+	handlers.AdminOutboxRequeueHandler(admin, outboxController, authorization.DefaultExtractUserID)
+	handlers.AdminNotifierRunHandler(admin, notifierController, authorization.DefaultExtractUserID)
 
 	all := mux.NewRouter().PathPrefix("/all").Subrouter()
 	handlers.StockGetAllCalculatedHandler(all, stockController)
 
+	stock := mux.NewRouter().PathPrefix("/stock").Subrouter()
+	handlers.StockGetHistoryHandler(stock, stockController)
+	handlers.StockGetSensitivityHandler(stock, stockController)
+
+	status := mux.NewRouter().PathPrefix("/status").Subrouter()
+	handlers.StatusGetHandler(status, statusController)
+
+	metrics := mux.NewRouter().PathPrefix("/metrics").Subrouter()
+	handlers.MetricsGetHandler(metrics)
+
+	calendar := mux.NewRouter().PathPrefix("/calendar").Subrouter()
+	handlers.CalendarGetHandler(calendar, calendarController)
+
+	unsubscribe := mux.NewRouter().PathPrefix("/unsubscribe").Subrouter()
+	handlers.UnsubscribeHandler(unsubscribe, unsubscribeController)
+
 	audience := os.Getenv("WATCHLIST_AUDIENCE")
 	authServer := os.Getenv("AUTHORIZATION_SERVER")
 	watchlistScope := os.Getenv("WATCHLIST_SCOPE")
@@ -35,10 +86,24 @@ func Route(watchlistController *controllers.WatchlistController, stockController
 		negroni.HandlerFunc(authorization.CreateAuthorizationMiddleware(audience, authServer).HandlerWithNext),
 		negroni.HandlerFunc(authorization.CreateScopeMiddleware(watchlistScope, authServer, audience)))
 
+	adminAuth := negroni.New(
+		negroni.HandlerFunc(authorization.CreateAuthorizationMiddleware(audience, authServer).HandlerWithNext),
+		negroni.HandlerFunc(authorization.CreateScopeMiddleware(adminScope, authServer, audience)))
+
 	handlers.StockGetAllCalculatedForUserHandler(all, auth, stockController, authorization.DefaultExtractUserID)
 
 	router.PathPrefix("/watchlist").Handler(auth.With(negroni.Wrap(watchlist)))
+	router.PathPrefix("/channels").Handler(auth.With(negroni.Wrap(channels)))
+	router.PathPrefix("/preferences").Handler(auth.With(negroni.Wrap(preferences)))
+	router.PathPrefix("/pricealerts").Handler(auth.With(negroni.Wrap(priceAlerts)))
+	router.PathPrefix("/notifications").Handler(auth.With(negroni.Wrap(notifications)))
+	router.PathPrefix("/admin").Handler(adminAuth.With(negroni.Wrap(admin)))
 	router.PathPrefix("/all").Handler(all)
+	router.PathPrefix("/stock").Handler(stock)
+	router.PathPrefix("/status").Handler(adminAuth.With(negroni.Wrap(status)))
+	router.PathPrefix("/metrics").Handler(adminAuth.With(negroni.Wrap(metrics)))
+	router.PathPrefix("/calendar").Handler(calendar)
+	router.PathPrefix("/unsubscribe").Handler(unsubscribe)
 
 	recovery := negroni.NewRecovery()
 	recovery.PrintStack = false
@@ -0,0 +1,44 @@
+package service
+
+import (
+	"github.com/nagymarci/stock-watchlist/expr"
+	"github.com/nagymarci/stock-watchlist/model"
+)
+
+//alertFields are the fields of CalculatedStockInfo and StockData available in alert expressions
+var alertFields = []string{
+	"ticker", "status", "score",
+	"price", "optInPrice", "priceColor",
+	"dividend", "dividendYield", "optInYield", "dividendColor",
+	"currentPe", "optInPe", "peColor",
+	"eps", "quarterlyDividend", "peRatio5yrAvg", "peRatio5yrMin", "dividendYield5yrAvg", "dividendYield5yrMax",
+}
+
+//CompileAlert compiles the expression of an alert rule
+func CompileAlert(expression string) (*expr.Expression, error) {
+	return expr.Compile(expression, alertFields)
+}
+
+func alertEnv(stock *model.StockData, calc *model.CalculatedStockInfo) expr.Env {
+	return expr.Env{
+		"ticker":              calc.Ticker,
+		"status":              calc.Status,
+		"score":               Score(calc),
+		"price":               calc.Price,
+		"optInPrice":          calc.OptInPrice,
+		"priceColor":          calc.PriceColor,
+		"dividend":            calc.AnnualDividend,
+		"dividendYield":       calc.DividendYield,
+		"optInYield":          calc.OptInYield,
+		"dividendColor":       calc.DividendColor,
+		"currentPe":           calc.CurrentPe,
+		"optInPe":             calc.OptInPe,
+		"peColor":             calc.PeColor,
+		"eps":                 stock.Eps,
+		"quarterlyDividend":   stock.Dividend,
+		"peRatio5yrAvg":       stock.PeRatio5yr.Avg,
+		"peRatio5yrMin":       stock.PeRatio5yr.Min,
+		"dividendYield5yrAvg": stock.DividendYield5yr.Avg,
+		"dividendYield5yrMax": stock.DividendYield5yr.Max,
+	}
+}
@@ -0,0 +1,210 @@
+package service
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	userprofileModel "github.com/nagymarci/stock-user-profile/model"
+	"github.com/nagymarci/stock-watchlist/model"
+)
+
+type snapshotGetter interface {
+	Get(symbol string, from, to time.Time) ([]model.StockSnapshot, error)
+}
+
+type Backtester struct {
+	snapshots snapshotGetter
+}
+
+func NewBacktester(s snapshotGetter) *Backtester {
+	return &Backtester{
+		snapshots: s,
+	}
+}
+
+//historicalSP500 provides the S&P500 dividend yield stored in a snapshot
+type historicalSP500 float64
+
+func (h historicalSP500) GetSP500DivYield() float64 {
+	return float64(h)
+}
+
+type drawdown struct {
+	peak float64
+	max  float64
+}
+
+func (d *drawdown) add(value float64) {
+	if value > d.peak {
+		d.peak = value
+	}
+
+	if d.peak > 0 && (d.peak-value)/d.peak > d.max {
+		d.max = (d.peak - value) / d.peak
+	}
+}
+
+//position tracks the simulated trades of one symbol, equity starts at 1
+type position struct {
+	holding    bool
+	entryPrice float64
+	lastPrice  float64
+	equity     float64
+	trades     int
+	wins       int
+	days       int
+	drawdown   drawdown
+}
+
+func newPosition() *position {
+	return &position{equity: 1, drawdown: drawdown{peak: 1}}
+}
+
+func (p *position) value() float64 {
+	if p.holding {
+		return p.equity * p.lastPrice / p.entryPrice
+	}
+
+	return p.equity
+}
+
+func (p *position) update(price float64, recommended bool) {
+	if !(price > 0) {
+		return
+	}
+
+	p.days++
+	p.lastPrice = price
+
+	if recommended && !p.holding {
+		p.holding = true
+		p.entryPrice = price
+	} else if !recommended && p.holding {
+		p.close()
+	}
+
+	p.drawdown.add(p.value())
+}
+
+func (p *position) close() {
+	if !p.holding {
+		return
+	}
+
+	tradeReturn := p.lastPrice / p.entryPrice
+	p.equity *= tradeReturn
+	p.trades++
+	if tradeReturn > 1 {
+		p.wins++
+	}
+	p.holding = false
+}
+
+//Run replays the daily snapshots of the symbols between from and to. A stock is bought
+//when it enters the set recommended by the strategy, and sold when it leaves it.
+//Positions still open at the end are closed on the last known price. Symbols listed
+//more than once are simulated once.
+func (b *Backtester) Run(symbols []string, from, to time.Time, strategy model.BacktestStrategy, userprofile *userprofileModel.Userprofile) (model.BacktestResult, error) {
+	result := model.BacktestResult{From: from, To: to, Strategy: strategy}
+	symbols = distinct(symbols)
+
+	days := make(map[time.Time][]model.StockSnapshot)
+	for _, symbol := range symbols {
+		snapshots, err := b.snapshots.Get(symbol, from, to)
+
+		if err != nil {
+			return result, fmt.Errorf("Failed to get snapshots of [%s]: [%v]", symbol, err)
+		}
+
+		for _, snapshot := range snapshots {
+			days[snapshot.Date] = append(days[snapshot.Date], snapshot)
+		}
+	}
+
+	var dates []time.Time
+	for date := range days {
+		dates = append(dates, date)
+	}
+	sort.Slice(dates, func(i, j int) bool { return dates[i].Before(dates[j]) })
+
+	positions := make(map[string]*position)
+	for _, symbol := range symbols {
+		positions[symbol] = newPosition()
+	}
+
+	portfolio := drawdown{peak: 1}
+	for _, date := range dates {
+		snapshots := days[date]
+
+		stocks := make([]model.StockData, len(snapshots))
+		for i, snapshot := range snapshots {
+			stocks[i] = snapshot.Data
+		}
+
+		stockService := NewStockService(historicalSP500(snapshots[0].Sp500DivYield))
+		recommended := applyBacktestStrategy(stockService.GetAllRecommendedStock(stocks, strategy.NumReqs, userprofile), strategy)
+
+		for _, snapshot := range snapshots {
+			positions[snapshot.Symbol].update(snapshot.Data.Price, contains(recommended, snapshot.Symbol))
+		}
+
+		portfolio.add(portfolioValue(positions))
+	}
+
+	trades, wins := 0, 0
+	for _, symbol := range symbols {
+		p := positions[symbol]
+		p.close()
+
+		trades += p.trades
+		wins += p.wins
+
+		result.Symbols = append(result.Symbols, model.SymbolBacktest{
+			Symbol:              symbol,
+			Days:                p.days,
+			BacktestPerformance: performance(p.equity, p.trades, p.wins, p.drawdown.max),
+		})
+	}
+
+	result.Days = len(dates)
+	result.Total = performance(portfolioValue(positions), trades, wins, portfolio.max)
+
+	return result, nil
+}
+
+func applyBacktestStrategy(stockInfos []model.CalculatedStockInfo, strategy model.BacktestStrategy) []string {
+	if strategy.PriceGreen {
+		return filterGreenPrices(stockInfos)
+	}
+
+	return tickers(stockInfos)
+}
+
+//portfolioValue returns the value of equally weighted positions
+func portfolioValue(positions map[string]*position) float64 {
+	if len(positions) == 0 {
+		return 1
+	}
+
+	sum := 0.0
+	for _, p := range positions {
+		sum += p.value()
+	}
+
+	return sum / float64(len(positions))
+}
+
+func performance(equity float64, trades, wins int, maxDrawdown float64) model.BacktestPerformance {
+	result := model.BacktestPerformance{
+		Trades:      trades,
+		Return:      (equity - 1) * 100,
+		MaxDrawdown: maxDrawdown * 100,
+	}
+
+	if trades > 0 {
+		result.HitRate = float64(wins) / float64(trades) * 100
+	}
+
+	return result
+}
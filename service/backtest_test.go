@@ -0,0 +1,112 @@
+package service
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	userprofileModel "github.com/nagymarci/stock-user-profile/model"
+	"github.com/nagymarci/stock-watchlist/model"
+)
+
+type mockSnapshots struct {
+	snapshots map[string][]model.StockSnapshot
+}
+
+func (m *mockSnapshots) Get(symbol string, from, to time.Time) ([]model.StockSnapshot, error) {
+	return m.snapshots[symbol], nil
+}
+
+func TestBacktest(t *testing.T) {
+	t.Run("buys on entry and sells on exit", func(t *testing.T) {
+		var snapshots []model.StockSnapshot
+		start := time.Date(2020, 11, 2, 0, 0, 0, 0, time.UTC)
+		for i, price := range []float64{49.28, 30, 27, 45} {
+			stock := intcStockData()
+			stock.Price = price
+			snapshots = append(snapshots, model.StockSnapshot{Symbol: "INTC", Date: start.AddDate(0, 0, i), Sp500DivYield: 1.0, Data: stock})
+		}
+
+		backtester := NewBacktester(&mockSnapshots{snapshots: map[string][]model.StockSnapshot{"INTC": snapshots}})
+
+		expectedReturn := 9.0
+		expectedRaise := 5.5
+		userprofile := userprofileModel.Userprofile{ExpectedReturn: &expectedReturn, DefaultExpectation: &expectedRaise}
+
+		strategy := model.BacktestStrategy{NumReqs: 0, PriceGreen: true}
+
+		result, err := backtester.Run([]string{"INTC", "XOM"}, start, start.AddDate(0, 0, 3), strategy, &userprofile)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if result.Days != 4 {
+			t.Fatalf("expected [4] days, got [%d]", result.Days)
+		}
+
+		intc := result.Symbols[0]
+		if intc.Trades != 1 || intc.HitRate != 100 || !almostEqual(intc.Return, 50) || !almostEqual(intc.MaxDrawdown, 10) {
+			t.Errorf("unexpected INTC result [%+v]", intc)
+		}
+
+		xom := result.Symbols[1]
+		if xom.Trades != 0 || xom.Days != 0 || xom.Return != 0 {
+			t.Errorf("unexpected XOM result [%+v]", xom)
+		}
+
+		if result.Total.Trades != 1 || !almostEqual(result.Total.Return, 25) || !almostEqual(result.Total.MaxDrawdown, 5) {
+			t.Errorf("unexpected total [%+v]", result.Total)
+		}
+	})
+	t.Run("open positions are closed at the end", func(t *testing.T) {
+		start := time.Date(2020, 11, 2, 0, 0, 0, 0, time.UTC)
+		var snapshots []model.StockSnapshot
+		for i, price := range []float64{30, 24} {
+			stock := intcStockData()
+			stock.Price = price
+			snapshots = append(snapshots, model.StockSnapshot{Symbol: "INTC", Date: start.AddDate(0, 0, i), Sp500DivYield: 1.0, Data: stock})
+		}
+
+		backtester := NewBacktester(&mockSnapshots{snapshots: map[string][]model.StockSnapshot{"INTC": snapshots}})
+
+		expectedReturn := 9.0
+		expectedRaise := 5.5
+		userprofile := userprofileModel.Userprofile{ExpectedReturn: &expectedReturn, DefaultExpectation: &expectedRaise}
+
+		result, _ := backtester.Run([]string{"INTC"}, start, start.AddDate(0, 0, 1), model.BacktestStrategy{NumReqs: 0, PriceGreen: true}, &userprofile)
+
+		if result.Total.Trades != 1 || result.Total.HitRate != 0 || !almostEqual(result.Total.Return, -20) {
+			t.Errorf("unexpected total [%+v]", result.Total)
+		}
+	})
+	t.Run("duplicated symbols are simulated once", func(t *testing.T) {
+		start := time.Date(2020, 11, 2, 0, 0, 0, 0, time.UTC)
+		var snapshots []model.StockSnapshot
+		for i, price := range []float64{30, 24} {
+			stock := intcStockData()
+			stock.Price = price
+			snapshots = append(snapshots, model.StockSnapshot{Symbol: "INTC", Date: start.AddDate(0, 0, i), Sp500DivYield: 1.0, Data: stock})
+		}
+
+		backtester := NewBacktester(&mockSnapshots{snapshots: map[string][]model.StockSnapshot{"INTC": snapshots}})
+
+		expectedReturn := 9.0
+		expectedRaise := 5.5
+		userprofile := userprofileModel.Userprofile{ExpectedReturn: &expectedReturn, DefaultExpectation: &expectedRaise}
+
+		result, _ := backtester.Run([]string{"INTC", "XOM", "INTC"}, start, start.AddDate(0, 0, 1), model.BacktestStrategy{NumReqs: 0, PriceGreen: true}, &userprofile)
+
+		if len(result.Symbols) != 2 {
+			t.Fatalf("expected [2] symbols, got [%d]", len(result.Symbols))
+		}
+
+		if result.Total.Trades != 1 || !almostEqual(result.Total.Return, -10) {
+			t.Errorf("unexpected total [%+v]", result.Total)
+		}
+	})
+}
+
+func almostEqual(a, b float64) bool {
+	return math.Abs(a-b) < 1e-9
+}
@@ -0,0 +1,314 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/nagymarci/stock-watchlist/model"
+)
+
+const calendarDateLayout = "2006-01-02"
+const calendarTimeLayout = "15:04"
+
+//usEarlyClose is the closing time of the US exchanges on the days before some holidays
+const usEarlyClose = "13:00"
+
+//Calendar tells when the exchange is open, so the scheduled jobs can skip holidays and follow early closes
+type Calendar struct {
+	exchange string
+	location *time.Location
+	open     time.Duration
+	close    time.Duration
+	holidays func(year int) []model.MarketHoliday
+	now      func() time.Time
+}
+
+//NewUSCalendar returns the calendar of the NYSE and the Nasdaq with their holidays and early closes
+func NewUSCalendar() *Calendar {
+	calendar, err := newCalendar(model.MarketCalendar{Exchange: "NYSE", TimeZone: "America/New_York", Open: "09:30", Close: "16:00"}, usHolidays)
+
+	if err != nil {
+		panic(err)
+	}
+
+	return calendar
+}
+
+//LoadCalendar reads the calendar of an exchange from a JSON file. Its holidays are not repeated,
+//every year has to be listed
+func LoadCalendar(path string) (*Calendar, error) {
+	content, err := ioutil.ReadFile(path)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to read market calendar [%s]: [%v]", path, err)
+	}
+
+	var calendar model.MarketCalendar
+	if err := json.Unmarshal(content, &calendar); err != nil {
+		return nil, fmt.Errorf("Failed to parse market calendar [%s]: [%v]", path, err)
+	}
+
+	for _, holiday := range calendar.Holidays {
+		if _, err := time.Parse(calendarDateLayout, holiday.Date); err != nil {
+			return nil, fmt.Errorf("Invalid holiday date [%s]: [%v]", holiday.Date, err)
+		}
+
+		if _, err := parseSessionTime(holiday.EarlyClose); holiday.EarlyClose != "" && err != nil {
+			return nil, err
+		}
+	}
+
+	holidays := calendar.Holidays
+
+	return newCalendar(calendar, func(year int) []model.MarketHoliday {
+		prefix := fmt.Sprintf("%04d-", year)
+
+		var result []model.MarketHoliday
+		for _, holiday := range holidays {
+			if len(holiday.Date) > len(prefix) && holiday.Date[:len(prefix)] == prefix {
+				result = append(result, holiday)
+			}
+		}
+
+		return result
+	})
+}
+
+func newCalendar(calendar model.MarketCalendar, holidays func(year int) []model.MarketHoliday) (*Calendar, error) {
+	location, err := time.LoadLocation(calendar.TimeZone)
+
+	if err != nil {
+		return nil, fmt.Errorf("Invalid time zone of market calendar [%s]: [%v]", calendar.TimeZone, err)
+	}
+
+	open, err := parseSessionTime(calendar.Open)
+
+	if err != nil {
+		return nil, err
+	}
+
+	close, err := parseSessionTime(calendar.Close)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if close <= open {
+		return nil, fmt.Errorf("Market calendar closes [%s] before it opens [%s]", calendar.Close, calendar.Open)
+	}
+
+	return &Calendar{
+		exchange: calendar.Exchange,
+		location: location,
+		open:     open,
+		close:    close,
+		holidays: holidays,
+		now:      time.Now,
+	}, nil
+}
+
+func parseSessionTime(value string) (time.Duration, error) {
+	t, err := time.Parse(calendarTimeLayout, value)
+
+	if err != nil {
+		return 0, fmt.Errorf("Invalid session time [%s], expected HH:MM", value)
+	}
+
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+}
+
+//Exchange returns the name of the exchange
+func (c *Calendar) Exchange() string {
+	return c.exchange
+}
+
+//Location returns the time zone of the exchange
+func (c *Calendar) Location() *time.Location {
+	return c.location
+}
+
+//Day returns the session of the exchange on the day of t, in the time zone of the exchange
+func (c *Calendar) Day(t time.Time) model.MarketDay {
+	t = t.In(c.location)
+	date := t.Format(calendarDateLayout)
+
+	day := model.MarketDay{Date: date}
+
+	if t.Weekday() == time.Saturday || t.Weekday() == time.Sunday {
+		return day
+	}
+
+	close := c.close
+
+	for _, holiday := range c.holidays(t.Year()) {
+		if holiday.Date != date {
+			continue
+		}
+
+		day.Holiday = holiday.Name
+
+		if holiday.EarlyClose == "" {
+			return day
+		}
+
+		close, _ = parseSessionTime(holiday.EarlyClose)
+		day.EarlyClose = true
+	}
+
+	midnight := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, c.location)
+	opens := midnight.Add(c.open)
+	closes := midnight.Add(close)
+
+	day.Open = true
+	day.Opens = &opens
+	day.Closes = &closes
+
+	return day
+}
+
+//IsTradingDay returns whether the exchange is open on the day of t, in the time zone of the exchange
+func (c *Calendar) IsTradingDay(t time.Time) bool {
+	return c.Day(t).Open
+}
+
+//Days returns the sessions of the exchange from the day of from to the day of to, inclusive
+func (c *Calendar) Days(from time.Time, to time.Time) []model.MarketDay {
+	from = from.In(c.location)
+	to = to.In(c.location)
+
+	var result []model.MarketDay
+
+	day := time.Date(from.Year(), from.Month(), from.Day(), 12, 0, 0, 0, c.location)
+	last := time.Date(to.Year(), to.Month(), to.Day(), 12, 0, 0, 0, c.location)
+
+	for !day.After(last) {
+		result = append(result, c.Day(day))
+		day = day.AddDate(0, 0, 1)
+	}
+
+	return result
+}
+
+//Schedule returns the sessions of the exchange in the date range
+func (c *Calendar) Schedule(from time.Time, to time.Time) model.MarketSchedule {
+	return model.MarketSchedule{
+		Exchange: c.exchange,
+		TimeZone: c.location.String(),
+		Days:     c.Days(from, to),
+	}
+}
+
+//AfterClose wraps a scheduled job, so it only runs on trading days in the window starting delay after the close.
+//The job should be scheduled with the window as interval, so it runs once a day
+func (c *Calendar) AfterClose(delay time.Duration, window time.Duration, job func()) func() {
+	return func() {
+		now := c.now()
+		day := c.Day(now)
+
+		if !day.Open {
+			logrus.Debugf("Market closed on [%s], skipping job", day.Date)
+			return
+		}
+
+		start := day.Closes.Add(delay)
+
+		if now.Before(start) || !now.Before(start.Add(window)) {
+			return
+		}
+
+		job()
+	}
+}
+
+//usHolidays returns the holidays and early closes of the US exchanges in the year.
+//Holidays on Saturday are observed on Friday, on Sunday on Monday, except New Year's Day on Saturday
+func usHolidays(year int) []model.MarketHoliday {
+	date := func(month time.Month, day int) time.Time {
+		return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+	}
+
+	var result []model.MarketHoliday
+
+	add := func(t time.Time, name string) {
+		result = append(result, model.MarketHoliday{Date: t.Format(calendarDateLayout), Name: name})
+	}
+
+	//the early closes are skipped if the day is a weekend or an observed holiday
+	addEarlyClose := func(t time.Time, name string) {
+		if t.Weekday() == time.Saturday || t.Weekday() == time.Sunday {
+			return
+		}
+
+		for _, holiday := range result {
+			if holiday.Date == t.Format(calendarDateLayout) {
+				return
+			}
+		}
+
+		result = append(result, model.MarketHoliday{Date: t.Format(calendarDateLayout), Name: name, EarlyClose: usEarlyClose})
+	}
+
+	if newYear := date(time.January, 1); newYear.Weekday() != time.Saturday {
+		add(observed(newYear), "New Year's Day")
+	}
+	add(nthWeekday(year, time.January, time.Monday, 3), "Martin Luther King, Jr. Day")
+	add(nthWeekday(year, time.February, time.Monday, 3), "Washington's Birthday")
+	add(easter(year).AddDate(0, 0, -2), "Good Friday")
+	add(nthWeekday(year, time.June, time.Monday, 1).AddDate(0, 0, -7), "Memorial Day")
+	if year >= 2022 {
+		add(observed(date(time.June, 19)), "Juneteenth National Independence Day")
+	}
+	add(observed(date(time.July, 4)), "Independence Day")
+	addEarlyClose(date(time.July, 3), "Independence Day Eve")
+	add(nthWeekday(year, time.September, time.Monday, 1), "Labor Day")
+	thanksgiving := nthWeekday(year, time.November, time.Thursday, 4)
+	add(thanksgiving, "Thanksgiving Day")
+	addEarlyClose(thanksgiving.AddDate(0, 0, 1), "Day after Thanksgiving")
+	add(observed(date(time.December, 25)), "Christmas Day")
+	addEarlyClose(date(time.December, 24), "Christmas Eve")
+
+	return result
+}
+
+//observed moves the holidays on weekends to the closest weekday
+func observed(t time.Time) time.Time {
+	switch t.Weekday() {
+	case time.Saturday:
+		return t.AddDate(0, 0, -1)
+	case time.Sunday:
+		return t.AddDate(0, 0, 1)
+	}
+	return t
+}
+
+//nthWeekday returns the nth weekday of the month
+func nthWeekday(year int, month time.Month, weekday time.Weekday, n int) time.Time {
+	first := time.Date(year, month, 1, 0, 0, 0, 0, time.UTC)
+	offset := (int(weekday) - int(first.Weekday()) + 7) % 7
+
+	return first.AddDate(0, 0, offset+(n-1)*7)
+}
+
+//easter returns the date of Easter Sunday with the anonymous Gregorian algorithm
+func easter(year int) time.Time {
+	a := year % 19
+	b := year / 100
+	c := year % 100
+	d := b / 4
+	e := b % 4
+	f := (b + 8) / 25
+	g := (b - f + 1) / 3
+	h := (19*a + b - d - g + 15) % 30
+	i := c / 4
+	k := c % 4
+	l := (32 + 2*e + 2*i - h - k) % 7
+	m := (a + 11*h + 22*l) / 451
+	month := (h + l - 7*m + 114) / 31
+	day := (h+l-7*m+114)%31 + 1
+
+	return time.Date(year, time.Month(month), day, 0, 0, 0, 0, time.UTC)
+}
@@ -0,0 +1,139 @@
+package service
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestCalendar(t *testing.T) {
+	calendar := NewUSCalendar()
+	newYork := calendar.Location()
+
+	t.Run("knows the US holidays and early closes", func(t *testing.T) {
+		holidays := map[string]string{
+			"2021-01-01": "New Year's Day",
+			"2021-04-02": "Good Friday",
+			"2021-05-31": "Memorial Day",
+			"2021-07-05": "Independence Day",
+			"2021-11-25": "Thanksgiving Day",
+			"2021-12-24": "Christmas Day",
+			"2022-01-17": "Martin Luther King, Jr. Day",
+			"2022-06-20": "Juneteenth National Independence Day",
+			"2023-01-02": "New Year's Day",
+		}
+
+		for date, name := range holidays {
+			day, _ := time.ParseInLocation(calendarDateLayout, date, newYork)
+
+			if d := calendar.Day(day); d.Open || d.Holiday != name {
+				t.Fatalf("unexpected day [%+v], expected [%s]", d, name)
+			}
+		}
+
+		for _, date := range []string{"2021-12-31", "2021-06-18", "2021-07-02"} {
+			day, _ := time.ParseInLocation(calendarDateLayout, date, newYork)
+
+			if d := calendar.Day(day); !d.Open || d.EarlyClose {
+				t.Fatalf("unexpected day [%+v]", d)
+			}
+		}
+
+		day := calendar.Day(time.Date(2021, 11, 26, 10, 0, 0, 0, newYork))
+
+		if !day.Open || !day.EarlyClose || day.Closes.Hour() != 13 || day.Opens.Hour() != 9 || day.Opens.Minute() != 30 {
+			t.Fatalf("unexpected early close [%+v]", day)
+		}
+
+		if d := calendar.Day(time.Date(2023, 7, 3, 10, 0, 0, 0, newYork)); !d.EarlyClose {
+			t.Fatalf("unexpected day [%+v]", d)
+		}
+	})
+	t.Run("lists the days of a range", func(t *testing.T) {
+		days := calendar.Days(time.Date(2021, 12, 23, 0, 0, 0, 0, newYork), time.Date(2021, 12, 27, 23, 0, 0, 0, newYork))
+
+		open := []bool{true, false, false, false, true}
+
+		if len(days) != len(open) {
+			t.Fatalf("unexpected days [%+v]", days)
+		}
+
+		for i := range days {
+			if days[i].Open != open[i] {
+				t.Fatalf("unexpected day [%+v]", days[i])
+			}
+		}
+	})
+	t.Run("runs the jobs only when the market is open", func(t *testing.T) {
+		runs := 0
+		job := func() { runs++ }
+
+		afterClose := calendar.AfterClose(30*time.Minute, 30*time.Minute, job)
+
+		times := []struct {
+			at       time.Time
+			wrapped  func()
+			expected int
+		}{
+			{time.Date(2021, 11, 25, 16, 30, 0, 0, newYork), afterClose, 0},
+			{time.Date(2021, 11, 24, 16, 30, 0, 0, newYork), afterClose, 1},
+			{time.Date(2021, 11, 24, 17, 0, 0, 0, newYork), afterClose, 0},
+			{time.Date(2021, 11, 26, 13, 30, 0, 0, newYork), afterClose, 1},
+			{time.Date(2021, 11, 26, 16, 30, 0, 0, newYork), afterClose, 0},
+		}
+
+		for _, tt := range times {
+			runs = 0
+			calendar.now = func() time.Time { return tt.at }
+
+			tt.wrapped()
+
+			if runs != tt.expected {
+				t.Fatalf("unexpected runs [%d] at [%v]", runs, tt.at)
+			}
+		}
+	})
+	t.Run("loads the calendar from a file", func(t *testing.T) {
+		dir, err := ioutil.TempDir("", "calendar")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer os.RemoveAll(dir)
+
+		path := filepath.Join(dir, "xetra.json")
+		content := `{"exchange": "XETRA", "timeZone": "Europe/Berlin", "open": "09:00", "close": "17:30", "holidays": [
+			{"date": "2021-12-24", "name": "Christmas Eve"},
+			{"date": "2021-12-30", "name": "Last trading day", "earlyClose": "14:00"}]}`
+
+		if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		xetra, err := LoadCalendar(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		berlin := xetra.Location()
+
+		if d := xetra.Day(time.Date(2021, 12, 24, 10, 0, 0, 0, berlin)); d.Open || d.Holiday != "Christmas Eve" {
+			t.Fatalf("unexpected day [%+v]", d)
+		}
+
+		if d := xetra.Day(time.Date(2021, 12, 30, 10, 0, 0, 0, berlin)); !d.Open || d.Closes.Hour() != 14 {
+			t.Fatalf("unexpected day [%+v]", d)
+		}
+
+		if d := xetra.Day(time.Date(2022, 12, 30, 10, 0, 0, 0, berlin)); !d.Open || d.Closes.Hour() != 17 || d.Closes.Minute() != 30 {
+			t.Fatalf("unexpected day [%+v]", d)
+		}
+
+		ioutil.WriteFile(path, []byte(`{"timeZone": "Europe/Berlin", "open": "17:00", "close": "09:00"}`), 0644)
+
+		if _, err := LoadCalendar(path); err == nil {
+			t.Fatal("expected error")
+		}
+	})
+}
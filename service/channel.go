@@ -0,0 +1,262 @@
+package service
+
+import (
+	"fmt"
+	"net"
+	netMail "net/mail"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/nagymarci/stock-watchlist/model"
+)
+
+var lookupIP = net.LookupIP
+
+type channelSender interface {
+	Send(channel *model.NotificationChannel, notification *model.Notification, message *model.RenderedMessage) error
+}
+
+type notificationRecorder interface {
+	Add(record model.NotificationRecord) error
+}
+
+type notificationRenderer interface {
+	Render(notification *model.Notification, links model.UnsubscribeLinks) (model.RenderedMessage, error)
+}
+
+type unsubscribeLinker interface {
+	Links(userID string, watchlistID primitive.ObjectID) model.UnsubscribeLinks
+}
+
+type channelProvider interface {
+	GetAll(userID string) ([]model.NotificationChannel, error)
+}
+
+//Channels delivers the notifications through the channels registered by the users
+type Channels struct {
+	channels    channelProvider
+	renderer    notificationRenderer
+	history     notificationRecorder
+	unsubscribe unsubscribeLinker
+	senders     map[string]channelSender
+	now         func() time.Time
+}
+
+func NewChannels(c channelProvider, r notificationRenderer, h notificationRecorder, u unsubscribeLinker) *Channels {
+	return &Channels{
+		channels:    c,
+		renderer:    r,
+		history:     h,
+		unsubscribe: u,
+		senders:     make(map[string]channelSender),
+		now:         time.Now,
+	}
+}
+
+//Register sets the sender of the given channel type
+func (c *Channels) Register(channelType string, sender channelSender) {
+	c.senders[channelType] = sender
+}
+
+//Resolve returns the channels chosen for the watchlist. Without chosen channels,
+//the notifications are sent to the email address of the user
+func (c *Channels) Resolve(watchlist *model.Watchlist, email string) ([]model.NotificationChannel, error) {
+	var result []model.NotificationChannel
+
+	if len(watchlist.Channels) > 0 {
+		channels, err := c.channels.GetAll(watchlist.UserID)
+
+		if err != nil {
+			return nil, fmt.Errorf("Failed to get channels of [%s]: [%v]", watchlist.UserID, err)
+		}
+
+		for _, channel := range channels {
+			if containsID(watchlist.Channels, channel.ID) {
+				result = append(result, channel)
+			}
+		}
+	}
+
+	if len(result) == 0 {
+		result = append(result, model.NotificationChannel{UserID: watchlist.UserID, Type: model.ChannelEmail, Name: "default", Address: email})
+	}
+
+	return result, nil
+}
+
+//Deliver sends the rendered notification through one channel
+func (c *Channels) Deliver(channel *model.NotificationChannel, notification *model.Notification, message *model.RenderedMessage) error {
+	sender, ok := c.senders[channel.Type]
+
+	if !ok {
+		return fmt.Errorf("No sender registered for channel type [%s]", channel.Type)
+	}
+
+	return sender.Send(channel, notification, message)
+}
+
+//Notify sends the notification through every channel of the watchlist, and records every attempt.
+//If the notification could not be delivered through some of them, a *DeliveryError lists the failed channels
+func (c *Channels) Notify(watchlist *model.Watchlist, email string, notification *model.Notification) error {
+	channels, err := c.Resolve(watchlist, email)
+
+	if err != nil {
+		return err
+	}
+
+	var links model.UnsubscribeLinks
+	if c.unsubscribe != nil {
+		links = c.unsubscribe.Links(watchlist.UserID, notification.WatchlistID)
+	}
+
+	message, err := c.renderer.Render(notification, links)
+
+	if err != nil {
+		err = fmt.Errorf("Failed to render notification: [%v]", err)
+
+		for i := range channels {
+			c.record(watchlist, &channels[i], notification, &message, c.now(), err)
+		}
+
+		return err
+	}
+
+	result := DeliveryError{Channels: len(channels)}
+	for i := range channels {
+		createdAt := c.now()
+
+		err := c.Deliver(&channels[i], notification, &message)
+
+		c.record(watchlist, &channels[i], notification, &message, createdAt, err)
+
+		if err != nil {
+			result.Failed = append(result.Failed, ChannelFailure{Channel: channels[i], Err: err})
+		}
+	}
+
+	if len(result.Failed) > 0 {
+		return &result
+	}
+
+	return nil
+}
+
+//ChannelFailure is the error of the delivery through one channel
+type ChannelFailure struct {
+	Channel model.NotificationChannel
+	Err     error
+}
+
+//DeliveryError lists the channels the notification could not be delivered through
+type DeliveryError struct {
+	Channels int
+	Failed   []ChannelFailure
+}
+
+func (e *DeliveryError) Error() string {
+	failures := make([]string, len(e.Failed))
+	for i, failure := range e.Failed {
+		failures[i] = fmt.Sprintf("%s [%s] [%s]: %v", failure.Channel.Type, failure.Channel.Name, failure.Channel.ID.Hex(), failure.Err)
+	}
+
+	return fmt.Sprintf("Failed to deliver notification through [%d] of [%d] channels: [%s]", len(e.Failed), e.Channels, strings.Join(failures, "; "))
+}
+
+//Partial returns whether the notification was delivered through some of the channels
+func (e *DeliveryError) Partial() bool {
+	return len(e.Failed) < e.Channels
+}
+
+//FailedChannels returns the ids of the channels the notification could not be delivered through
+func (e *DeliveryError) FailedChannels() []primitive.ObjectID {
+	var result []primitive.ObjectID
+
+	for _, failure := range e.Failed {
+		result = append(result, failure.Channel.ID)
+	}
+
+	return result
+}
+
+func (c *Channels) record(watchlist *model.Watchlist, channel *model.NotificationChannel, notification *model.Notification, message *model.RenderedMessage, createdAt time.Time, err error) {
+	record := model.NotificationRecord{
+		UserID:       watchlist.UserID,
+		WatchlistID:  watchlist.ID,
+		ChannelID:    channel.ID,
+		ChannelType:  channel.Type,
+		ChannelName:  channel.Name,
+		Notification: *notification,
+		Message:      *message,
+		Status:       model.NotificationSent,
+		CreatedAt:    createdAt.UTC(),
+	}
+
+	if err != nil {
+		record.Status = model.NotificationFailed
+		record.Error = err.Error()
+	} else {
+		sentAt := c.now().UTC()
+		record.SentAt = &sentAt
+	}
+
+	if err := c.history.Add(record); err != nil {
+		logrus.WithField("userId", watchlist.UserID).Errorf("Failed to record notification [%v]", err)
+	}
+}
+
+//ValidateChannel checks the type and the address of the channel
+func ValidateChannel(channel *model.NotificationChannelRequest) error {
+	switch channel.Type {
+	case model.ChannelEmail:
+		if _, err := netMail.ParseAddress(channel.Address); err != nil {
+			return fmt.Errorf("Invalid email address [%s]: [%v]", channel.Address, err)
+		}
+	case model.ChannelWebhook, model.ChannelChat:
+		u, err := url.Parse(channel.Address)
+		if err != nil || u.Scheme != "https" || u.Hostname() == "" {
+			return fmt.Errorf("Invalid webhook url [%s], an https url is required", channel.Address)
+		}
+
+		if err := validateWebhookHost(u.Hostname()); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("Unknown channel type [%s]", channel.Type)
+	}
+
+	if channel.Type == model.ChannelWebhook && len(channel.Secret) < minWebhookSecretLength {
+		return fmt.Errorf("Webhook secret must be at least [%d] characters", minWebhookSecretLength)
+	}
+
+	return nil
+}
+
+//validateWebhookHost checks that every address of the host is public
+func validateWebhookHost(host string) error {
+	ips, err := lookupIP(host)
+
+	if err != nil {
+		return fmt.Errorf("Cannot resolve webhook host [%s]: [%v]", host, err)
+	}
+
+	for _, ip := range ips {
+		if !publicIP(ip) {
+			return fmt.Errorf("Webhook host [%s] resolves to [%s]: %w", host, ip, ErrForbiddenAddress)
+		}
+	}
+
+	return nil
+}
+
+func containsID(s []primitive.ObjectID, e primitive.ObjectID) bool {
+	for _, a := range s {
+		if a == e {
+			return true
+		}
+	}
+	return false
+}
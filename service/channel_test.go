@@ -0,0 +1,237 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/nagymarci/stock-watchlist/model"
+)
+
+type mockChannelProvider struct {
+	channels []model.NotificationChannel
+}
+
+func (m *mockChannelProvider) GetAll(userID string) ([]model.NotificationChannel, error) {
+	return m.channels, nil
+}
+
+type mockNotificationRecorder struct {
+	records []model.NotificationRecord
+}
+
+func (m *mockNotificationRecorder) Add(record model.NotificationRecord) error {
+	m.records = append(m.records, record)
+	return nil
+}
+
+type mockChannelSender struct {
+	sent     []string
+	subjects []string
+	err      error
+}
+
+func (m *mockChannelSender) Send(channel *model.NotificationChannel, notification *model.Notification, message *model.RenderedMessage) error {
+	m.sent = append(m.sent, channel.Address)
+	m.subjects = append(m.subjects, message.Subject)
+	return m.err
+}
+
+func TestChannels(t *testing.T) {
+	t.Run("sends to the email of the user without chosen channels", func(t *testing.T) {
+		email := &mockChannelSender{}
+		channels := NewChannels(&mockChannelProvider{}, testRenderer(t), &mockNotificationRecorder{}, nil)
+		channels.Register(model.ChannelEmail, email)
+
+		err := channels.Notify(&model.Watchlist{UserID: "userId"}, "alice@example.com", &model.Notification{Kind: model.NotificationChange, WatchlistName: "watchlist"})
+
+		if err != nil || len(email.sent) != 1 || email.sent[0] != "alice@example.com" {
+			t.Fatalf("expected email to alice@example.com, got [%v] [%v]", email.sent, err)
+		}
+
+		if email.subjects[0] != "watchlist changed!" {
+			t.Fatalf("unexpected subject [%s]", email.subjects[0])
+		}
+	})
+	t.Run("sends to the chosen channels", func(t *testing.T) {
+		hook := model.NotificationChannel{ID: primitive.NewObjectID(), Type: model.ChannelWebhook, Address: "https://example.com/hook"}
+		chat := model.NotificationChannel{ID: primitive.NewObjectID(), Type: model.ChannelChat, Address: "https://example.com/chat"}
+		other := model.NotificationChannel{ID: primitive.NewObjectID(), Type: model.ChannelEmail, Address: "bob@example.com"}
+
+		email := &mockChannelSender{}
+		webhook := &mockChannelSender{err: errors.New("unavailable")}
+		chatSender := &mockChannelSender{}
+		history := &mockNotificationRecorder{}
+		channels := NewChannels(&mockChannelProvider{channels: []model.NotificationChannel{hook, chat, other}}, testRenderer(t), history, nil)
+		channels.Register(model.ChannelEmail, email)
+		channels.Register(model.ChannelWebhook, webhook)
+		channels.Register(model.ChannelChat, chatSender)
+
+		watchlist := model.Watchlist{UserID: "userId", Channels: []primitive.ObjectID{hook.ID, chat.ID}}
+
+		err := channels.Notify(&watchlist, "alice@example.com", &model.Notification{Kind: model.NotificationChange})
+
+		var delivery *DeliveryError
+		if !errors.As(err, &delivery) || !delivery.Partial() || len(delivery.FailedChannels()) != 1 || delivery.FailedChannels()[0] != hook.ID {
+			t.Fatalf("expected partial delivery failing through the webhook, got [%v]", err)
+		}
+
+		if len(email.sent) != 0 || len(webhook.sent) != 1 || len(chatSender.sent) != 1 {
+			t.Fatalf("expected webhook and chat delivery, got [%v] [%v] [%v]", email.sent, webhook.sent, chatSender.sent)
+		}
+
+		if len(history.records) != 2 {
+			t.Fatalf("expected [2] records, got [%+v]", history.records)
+		}
+
+		failed, sent := history.records[0], history.records[1]
+
+		if failed.ChannelID != hook.ID || failed.Status != model.NotificationFailed || failed.Error != "unavailable" || failed.SentAt != nil {
+			t.Fatalf("unexpected failed record [%+v]", failed)
+		}
+
+		if sent.ChannelID != chat.ID || sent.Status != model.NotificationSent || sent.SentAt == nil || sent.UserID != "userId" || sent.Message.Subject == "" {
+			t.Fatalf("unexpected sent record [%+v]", sent)
+		}
+	})
+}
+
+func TestWebhook(t *testing.T) {
+	t.Run("signs the payload and retries server errors", func(t *testing.T) {
+		attempts := 0
+		var body []byte
+		var signature, timestamp string
+
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			attempts++
+			if attempts == 1 {
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return
+			}
+			body, _ = ioutil.ReadAll(r.Body)
+			signature = r.Header.Get(WebhookSignatureHeader)
+			timestamp = r.Header.Get(WebhookTimestampHeader)
+			w.WriteHeader(http.StatusNoContent)
+		}))
+		defer server.Close()
+
+		webhook := NewWebhook()
+		webhook.client = server.Client()
+		webhook.backoff = 0
+
+		channel := model.NotificationChannel{Type: model.ChannelWebhook, Address: server.URL, Secret: "0123456789abcdef"}
+		notification := model.Notification{Kind: model.NotificationChange, WatchlistName: "watchlist", Added: []string{"INTC"}, Stocks: []model.CalculatedStockInfo{{Ticker: "INTC", Price: 1234.5, DividendYield: 3.571}}, Locale: "hu-HU"}
+
+		err := webhook.Send(&channel, &notification, &model.RenderedMessage{Subject: "watchlist changed!", Text: "text"})
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if attempts != 2 {
+			t.Fatalf("expected [2] attempts, got [%d]", attempts)
+		}
+
+		if signature != "sha256="+SignWebhook(channel.Secret, timestamp, body) {
+			t.Fatalf("invalid signature [%s]", signature)
+		}
+
+		var payload webhookPayload
+		json.Unmarshal(body, &payload)
+
+		if payload.Event != "watchlist.change" || payload.Subject != "watchlist changed!" || payload.Notification.Added[0] != "INTC" {
+			t.Fatalf("unexpected payload [%s]", body)
+		}
+
+		if payload.Locale != "hu" || len(payload.Formatted) != 1 || payload.Formatted[0].Price != "1\u00a0234,50\u00a0USD" || payload.Formatted[0].DividendYield != "3,57%" {
+			t.Fatalf("unexpected formatting [%s]", body)
+		}
+	})
+	t.Run("does not retry client errors", func(t *testing.T) {
+		attempts := 0
+
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			attempts++
+			w.WriteHeader(http.StatusBadRequest)
+		}))
+		defer server.Close()
+
+		chat := NewChatWebhook()
+		chat.client = server.Client()
+		chat.backoff = 0
+
+		err := chat.Send(&model.NotificationChannel{Type: model.ChannelChat, Address: server.URL}, &model.Notification{}, &model.RenderedMessage{})
+
+		if err == nil || attempts != 1 {
+			t.Fatalf("expected single failed attempt, got [%d] [%v]", attempts, err)
+		}
+	})
+	t.Run("refuses to connect to private addresses", func(t *testing.T) {
+		attempts := 0
+
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			attempts++
+			w.WriteHeader(http.StatusNoContent)
+		}))
+		defer server.Close()
+
+		webhook := NewWebhook()
+		webhook.backoff = 0
+
+		err := webhook.Send(&model.NotificationChannel{Type: model.ChannelWebhook, Address: server.URL, Secret: "0123456789abcdef"}, &model.Notification{}, &model.RenderedMessage{})
+
+		if !errors.Is(err, ErrForbiddenAddress) || attempts != 0 {
+			t.Fatalf("expected refused connection, got [%d] [%v]", attempts, err)
+		}
+	})
+}
+
+func TestValidateChannel(t *testing.T) {
+	defer func(original func(string) ([]net.IP, error)) { lookupIP = original }(lookupIP)
+	lookupIP = func(host string) ([]net.IP, error) {
+		switch host {
+		case "hooks.example.com":
+			return []net.IP{net.ParseIP("93.184.216.34")}, nil
+		case "internal.example.com":
+			return []net.IP{net.ParseIP("93.184.216.34"), net.ParseIP("10.0.0.5")}, nil
+		}
+		return net.LookupIP(host)
+	}
+
+	secret := "0123456789abcdef"
+
+	valid := []model.NotificationChannelRequest{
+		{Type: model.ChannelEmail, Address: "alice@example.com"},
+		{Type: model.ChannelWebhook, Address: "https://hooks.example.com/watchlist", Secret: secret},
+		{Type: model.ChannelChat, Address: "https://93.184.216.34:8443/chat"},
+	}
+
+	for _, channel := range valid {
+		if err := ValidateChannel(&channel); err != nil {
+			t.Fatalf("unexpected error for [%s]: [%v]", channel.Address, err)
+		}
+	}
+
+	invalid := map[string]model.NotificationChannelRequest{
+		"http":       {Type: model.ChannelWebhook, Address: "http://hooks.example.com/watchlist", Secret: secret},
+		"secret":     {Type: model.ChannelWebhook, Address: "https://hooks.example.com/watchlist", Secret: "short"},
+		"loopback":   {Type: model.ChannelChat, Address: "https://127.0.0.1/chat"},
+		"private":    {Type: model.ChannelChat, Address: "https://192.168.1.10/chat"},
+		"link-local": {Type: model.ChannelChat, Address: "https://169.254.169.254/latest/meta-data"},
+		"ipv6":       {Type: model.ChannelChat, Address: "https://[::1]/chat"},
+		"resolved":   {Type: model.ChannelChat, Address: "https://internal.example.com/chat"},
+		"email":      {Type: model.ChannelEmail, Address: "not an address"},
+	}
+
+	for name, channel := range invalid {
+		if err := ValidateChannel(&channel); err == nil {
+			t.Fatalf("expected error for invalid %s", name)
+		}
+	}
+}
@@ -0,0 +1,330 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/nagymarci/stock-watchlist/model"
+)
+
+type preferencesProvider interface {
+	Get(userID string) (model.NotificationPreferences, error)
+	List() ([]model.NotificationPreferences, error)
+	UpdateLastDigest(userID string, sent time.Time) error
+}
+
+type digestEventStore interface {
+	Add(event model.DigestEvent) error
+	GetByUser(userID string, until time.Time) ([]model.DigestEvent, error)
+	DeleteByUser(userID string, until time.Time) error
+}
+
+//Digests sends the notifications right away or queues them for the digest,
+//depending on the delivery mode chosen by the user
+type Digests struct {
+	preferences       preferencesProvider
+	events            digestEventStore
+	channels          notificationSender
+	userprofileClient userprofileGetter
+	now               func() time.Time
+}
+
+func NewDigests(p preferencesProvider, e digestEventStore, ns notificationSender, uc userprofileGetter) *Digests {
+	return &Digests{
+		preferences:       p,
+		events:            e,
+		channels:          ns,
+		userprofileClient: uc,
+		now:               time.Now,
+	}
+}
+
+//Notify sends the notification if the user wants immediate delivery, otherwise queues it
+func (d *Digests) Notify(watchlist *model.Watchlist, email string, notification *model.Notification) error {
+	preferences, err := userPreferences(d.preferences, watchlist.UserID)
+
+	if err != nil {
+		return err
+	}
+
+	if preferences.Delivery == model.DeliveryImmediate {
+		return d.channels.Notify(watchlist, email, notification)
+	}
+
+	err = d.events.Add(model.DigestEvent{
+		UserID:       watchlist.UserID,
+		Channels:     watchlist.Channels,
+		Notification: *notification,
+		CreatedAt:    d.now().UTC(),
+	})
+
+	if err != nil {
+		return fmt.Errorf("Failed to queue notification for digest: [%v]", err)
+	}
+
+	return nil
+}
+
+//SendDigests sends the queued notifications of the users whose digest is due.
+//Events left queued by users who switched back to immediate delivery are sent right away
+func (d *Digests) SendDigests() {
+	preferences, err := d.preferences.List()
+
+	if err != nil {
+		logrus.Errorf("Failed to get notification preferences [%v]", err)
+		return
+	}
+
+	now := d.now().UTC()
+
+	for i := range preferences {
+		if !DigestDue(&preferences[i], now) {
+			continue
+		}
+
+		d.sendDigest(&preferences[i], now)
+	}
+}
+
+func (d *Digests) sendDigest(preferences *model.NotificationPreferences, now time.Time) {
+	log := logrus.WithField("userId", preferences.UserID)
+
+	events, err := d.events.GetByUser(preferences.UserID, now)
+
+	if err != nil {
+		log.Errorln("Failed to get digest events ", err)
+		return
+	}
+
+	digest := BuildDigest(events)
+
+	if len(digest.Changes) > 0 {
+		userprofile, locale, err := d.userprofileClient.GetLocalizedUserprofile(context.Background(), preferences.UserID)
+
+		if err != nil {
+			log.Errorln("Failed to get userprofile to digest ", err)
+			return
+		}
+
+		digest.Locale = NotificationLocale(preferences, locale)
+
+		channels := digestChannels(events)
+		if len(channels) == 0 {
+			channels = preferences.Channels
+		}
+
+		watchlist := model.Watchlist{UserID: preferences.UserID, Name: "digest", Channels: channels}
+
+		err = d.channels.Notify(&watchlist, userprofile.Email, &digest)
+
+		//a digest delivered through some of the channels is not sent again, the failures are recorded
+		var delivery *DeliveryError
+		if errors.As(err, &delivery) && delivery.Partial() {
+			log.Warnln("Failed to send digest through some channels ", err)
+		} else if err != nil {
+			log.Errorln("Failed to send digest ", err)
+			return
+		}
+	}
+
+	if len(events) > 0 {
+		if err := d.events.DeleteByUser(preferences.UserID, now); err != nil {
+			log.Errorln("Failed to delete digest events ", err)
+		}
+	}
+
+	if preferences.Delivery != model.DeliveryImmediate {
+		if err := d.preferences.UpdateLastDigest(preferences.UserID, now); err != nil {
+			log.Errorln("Failed to update last digest ", err)
+		}
+	}
+}
+
+//digestSignals merges the signal notifications of a watchlist into the net transitions,
+//from the first color to the last one of every signal
+func digestSignals(notifications []model.Notification) (model.Notification, bool) {
+	type signalKey struct {
+		symbol string
+		signal string
+	}
+
+	var keys []signalKey
+	net := make(map[signalKey]model.SignalTransition)
+
+	for _, notification := range notifications {
+		for _, transition := range notification.Transitions {
+			key := signalKey{transition.Symbol, transition.Signal}
+
+			if merged, ok := net[key]; ok {
+				merged.To = transition.To
+				net[key] = merged
+				continue
+			}
+
+			keys = append(keys, key)
+			net[key] = transition
+		}
+	}
+
+	var transitions []model.SignalTransition
+	for _, key := range keys {
+		if net[key].From != net[key].To {
+			transitions = append(transitions, net[key])
+		}
+	}
+
+	if len(transitions) == 0 {
+		return model.Notification{}, false
+	}
+
+	last := notifications[len(notifications)-1]
+	symbols := transitionSymbols(transitions)
+
+	return model.Notification{
+		Kind:          model.NotificationSignal,
+		WatchlistID:   last.WatchlistID,
+		WatchlistName: last.WatchlistName,
+		Added:         symbols,
+		Current:       symbols,
+		Stocks:        stockDetails(latestStocks(notifications), symbols),
+		Transitions:   transitions,
+	}, true
+}
+
+//DigestDue returns whether a digest was scheduled since the last one was sent
+func DigestDue(preferences *model.NotificationPreferences, now time.Time) bool {
+	if preferences.Delivery == model.DeliveryImmediate {
+		return true
+	}
+
+	return preferences.LastDigest.Before(lastDigestTime(preferences, now))
+}
+
+//lastDigestTime returns the latest scheduled digest time not after now
+func lastDigestTime(preferences *model.NotificationPreferences, now time.Time) time.Time {
+	local := now.In(location(preferences))
+
+	scheduled := time.Date(local.Year(), local.Month(), local.Day(), preferences.DigestHour, 0, 0, 0, local.Location())
+
+	if preferences.Delivery == model.DeliveryWeekly {
+		days := (int(scheduled.Weekday()) - preferences.DigestWeekday + 7) % 7
+		scheduled = scheduled.AddDate(0, 0, -days)
+
+		if scheduled.After(local) {
+			scheduled = scheduled.AddDate(0, 0, -7)
+		}
+
+		return scheduled
+	}
+
+	if scheduled.After(local) {
+		scheduled = scheduled.AddDate(0, 0, -1)
+	}
+
+	return scheduled
+}
+
+//BuildDigest merges the queued events into one notification, holding the net changes
+//of every watchlist and alert rule since the first event
+func BuildDigest(events []model.DigestEvent) model.Notification {
+	type changeKey struct {
+		watchlistID primitive.ObjectID
+		kind        string
+		alertName   string
+	}
+
+	var keys []changeKey
+	grouped := make(map[changeKey][]model.Notification)
+
+	for _, event := range events {
+		key := changeKey{event.Notification.WatchlistID, event.Notification.Kind, event.Notification.AlertName}
+
+		if _, ok := grouped[key]; !ok {
+			keys = append(keys, key)
+		}
+
+		grouped[key] = append(grouped[key], event.Notification)
+	}
+
+	digest := model.Notification{Kind: model.NotificationDigest}
+
+	for _, key := range keys {
+		notifications := grouped[key]
+		first := notifications[0]
+		last := notifications[len(notifications)-1]
+
+		if key.kind == model.NotificationSignal {
+			if change, ok := digestSignals(notifications); ok {
+				digest.Changes = append(digest.Changes, change)
+			}
+			continue
+		}
+
+		var previous []string
+		for _, symbol := range first.Current {
+			if !contains(first.Added, symbol) {
+				previous = append(previous, symbol)
+			}
+		}
+		previous = append(previous, first.Removed...)
+
+		removed, added := getChanges(previous, last.Current)
+
+		if len(removed) == 0 && len(added) == 0 {
+			continue
+		}
+
+		digest.Changes = append(digest.Changes, model.Notification{
+			Kind:          last.Kind,
+			WatchlistID:   last.WatchlistID,
+			WatchlistName: last.WatchlistName,
+			AlertName:     last.AlertName,
+			Removed:       removed,
+			Added:         added,
+			Current:       last.Current,
+			Stocks:        stockDetails(latestStocks(notifications), removed, added, last.Current),
+		})
+	}
+
+	return digest
+}
+
+//latestStocks returns the most recent calculated information of every stock in the notifications
+func latestStocks(notifications []model.Notification) []model.CalculatedStockInfo {
+	var result []model.CalculatedStockInfo
+	index := make(map[string]int)
+
+	for _, notification := range notifications {
+		for _, stock := range notification.Stocks {
+			if i, ok := index[stock.Ticker]; ok {
+				result[i] = stock
+				continue
+			}
+
+			index[stock.Ticker] = len(result)
+			result = append(result, stock)
+		}
+	}
+
+	return result
+}
+
+//digestChannels returns the channels chosen for any of the watchlists in the events
+func digestChannels(events []model.DigestEvent) []primitive.ObjectID {
+	var result []primitive.ObjectID
+
+	for _, event := range events {
+		for _, id := range event.Channels {
+			if !containsID(result, id) {
+				result = append(result, id)
+			}
+		}
+	}
+
+	return result
+}
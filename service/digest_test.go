@@ -0,0 +1,242 @@
+package service
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	userprofileModel "github.com/nagymarci/stock-user-profile/model"
+	"github.com/nagymarci/stock-watchlist/model"
+	"github.com/nagymarci/stock-watchlist/service/mocks"
+)
+
+type mockPreferences struct {
+	preferences map[string]model.NotificationPreferences
+}
+
+func (m *mockPreferences) Get(userID string) (model.NotificationPreferences, error) {
+	preferences, ok := m.preferences[userID]
+	if !ok {
+		return preferences, mongo.ErrNoDocuments
+	}
+	return preferences, nil
+}
+
+func (m *mockPreferences) List() ([]model.NotificationPreferences, error) {
+	var result []model.NotificationPreferences
+	for _, preferences := range m.preferences {
+		result = append(result, preferences)
+	}
+	return result, nil
+}
+
+func (m *mockPreferences) UpdateLastDigest(userID string, sent time.Time) error {
+	preferences := m.preferences[userID]
+	preferences.LastDigest = sent
+	m.preferences[userID] = preferences
+	return nil
+}
+
+type mockDigestEvents struct {
+	events []model.DigestEvent
+}
+
+func (m *mockDigestEvents) Add(event model.DigestEvent) error {
+	m.events = append(m.events, event)
+	return nil
+}
+
+func (m *mockDigestEvents) GetByUser(userID string, until time.Time) ([]model.DigestEvent, error) {
+	var result []model.DigestEvent
+	for _, event := range m.events {
+		if event.UserID == userID && !event.CreatedAt.After(until) {
+			result = append(result, event)
+		}
+	}
+	return result, nil
+}
+
+func (m *mockDigestEvents) DeleteByUser(userID string, until time.Time) error {
+	var kept []model.DigestEvent
+	for _, event := range m.events {
+		if event.UserID != userID || event.CreatedAt.After(until) {
+			kept = append(kept, event)
+		}
+	}
+	m.events = kept
+	return nil
+}
+
+func changeEvent(watchlistID primitive.ObjectID, removed, added, current []string) model.DigestEvent {
+	return model.DigestEvent{
+		UserID: "userId",
+		Notification: model.Notification{
+			Kind:          model.NotificationChange,
+			WatchlistID:   watchlistID,
+			WatchlistName: "watchlist",
+			Removed:       removed,
+			Added:         added,
+			Current:       current,
+		},
+	}
+}
+
+func TestBuildDigest(t *testing.T) {
+	t.Run("changes that cancel out are left out", func(t *testing.T) {
+		watchlistID := primitive.NewObjectID()
+
+		digest := BuildDigest([]model.DigestEvent{
+			changeEvent(watchlistID, nil, []string{"INTC"}, []string{"XOM", "INTC"}),
+			changeEvent(watchlistID, []string{"INTC"}, nil, []string{"XOM"}),
+		})
+
+		if digest.Kind != model.NotificationDigest || len(digest.Changes) != 0 {
+			t.Fatalf("expected empty digest, got [%+v]", digest)
+		}
+	})
+	t.Run("net changes per watchlist and alert", func(t *testing.T) {
+		first := primitive.NewObjectID()
+		second := primitive.NewObjectID()
+
+		alert := changeEvent(second, nil, []string{"T"}, []string{"T"})
+		alert.Notification.Kind = model.NotificationAlert
+		alert.Notification.AlertName = "high yield"
+
+		events := []model.DigestEvent{
+			changeEvent(first, []string{"MMM"}, []string{"INTC"}, []string{"XOM", "INTC"}),
+			alert,
+			changeEvent(first, []string{"XOM"}, []string{"KO"}, []string{"INTC", "KO"}),
+		}
+		events[0].Notification.Stocks = []model.CalculatedStockInfo{{Ticker: "MMM", Price: 1}, {Ticker: "INTC", Price: 1}}
+		events[2].Notification.Stocks = []model.CalculatedStockInfo{{Ticker: "XOM", Price: 2}, {Ticker: "INTC", Price: 2}, {Ticker: "KO", Price: 2}}
+
+		digest := BuildDigest(events)
+
+		if len(digest.Changes) != 2 {
+			t.Fatalf("expected [2] changes, got [%+v]", digest.Changes)
+		}
+
+		change := digest.Changes[0]
+		if change.WatchlistID != first || !reflect.DeepEqual(change.Removed, []string{"XOM", "MMM"}) || !reflect.DeepEqual(change.Added, []string{"INTC", "KO"}) {
+			t.Fatalf("unexpected change [%+v]", change)
+		}
+
+		expectedStocks := []model.CalculatedStockInfo{{Ticker: "MMM", Price: 1}, {Ticker: "INTC", Price: 2}, {Ticker: "XOM", Price: 2}, {Ticker: "KO", Price: 2}}
+		if !reflect.DeepEqual(change.Stocks, expectedStocks) {
+			t.Fatalf("expected latest stock information, got [%+v]", change.Stocks)
+		}
+
+		if digest.Changes[1].AlertName != "high yield" || !reflect.DeepEqual(digest.Changes[1].Added, []string{"T"}) {
+			t.Fatalf("unexpected alert change [%+v]", digest.Changes[1])
+		}
+	})
+	t.Run("net signal transitions", func(t *testing.T) {
+		watchlistID := primitive.NewObjectID()
+
+		signal := func(transitions ...model.SignalTransition) model.DigestEvent {
+			return model.DigestEvent{Notification: model.Notification{Kind: model.NotificationSignal, WatchlistID: watchlistID, Transitions: transitions}}
+		}
+
+		digest := BuildDigest([]model.DigestEvent{
+			signal(model.SignalTransition{Symbol: "INTC", Signal: model.SignalPe, From: "green", To: "yellow"}, model.SignalTransition{Symbol: "KO", Signal: model.SignalDividend, From: "red", To: "yellow"}),
+			signal(model.SignalTransition{Symbol: "INTC", Signal: model.SignalPe, From: "yellow", To: "green"}),
+			signal(model.SignalTransition{Symbol: "KO", Signal: model.SignalDividend, From: "yellow", To: "green"}),
+		})
+
+		expected := []model.SignalTransition{{Symbol: "KO", Signal: model.SignalDividend, From: "red", To: "green"}}
+
+		if len(digest.Changes) != 1 || !reflect.DeepEqual(digest.Changes[0].Transitions, expected) || !reflect.DeepEqual(digest.Changes[0].Added, []string{"KO"}) {
+			t.Fatalf("unexpected digest [%+v]", digest.Changes)
+		}
+	})
+}
+
+func TestDigestDue(t *testing.T) {
+	loc, _ := time.LoadLocation("Europe/Budapest")
+
+	daily := model.NotificationPreferences{Delivery: model.DeliveryDaily, DigestHour: 8, TimeZone: "Europe/Budapest"}
+	//Wednesday
+	daily.LastDigest = time.Date(2020, 12, 2, 8, 0, 0, 0, loc)
+
+	if DigestDue(&daily, time.Date(2020, 12, 3, 7, 59, 0, 0, loc)) {
+		t.Fatal("daily digest is not due before the digest hour")
+	}
+
+	if !DigestDue(&daily, time.Date(2020, 12, 3, 8, 0, 0, 0, loc).UTC()) {
+		t.Fatal("daily digest is due at the digest hour")
+	}
+
+	weekly := model.NotificationPreferences{Delivery: model.DeliveryWeekly, DigestHour: 18, DigestWeekday: int(time.Friday), TimeZone: "Europe/Budapest"}
+	weekly.LastDigest = time.Date(2020, 11, 27, 18, 0, 0, 0, loc)
+
+	if DigestDue(&weekly, time.Date(2020, 12, 4, 17, 0, 0, 0, loc)) {
+		t.Fatal("weekly digest is not due before the digest hour on the digest day")
+	}
+
+	if !DigestDue(&weekly, time.Date(2020, 12, 6, 9, 0, 0, 0, loc)) {
+		t.Fatal("missed weekly digest is due")
+	}
+}
+
+func TestDigests(t *testing.T) {
+	watchlist := model.Watchlist{ID: primitive.NewObjectID(), Name: "watchlist", UserID: "userId"}
+	now := time.Date(2020, 12, 3, 14, 0, 0, 0, time.UTC)
+
+	t.Run("sends immediately without preferences", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		channels := mocks.NewMocknotificationSender(ctrl)
+		events := &mockDigestEvents{}
+		digests := NewDigests(&mockPreferences{}, events, channels, nil)
+
+		notification := model.Notification{Kind: model.NotificationChange}
+		channels.EXPECT().Notify(&watchlist, "alice@example.com", &notification).Return(nil)
+
+		if err := digests.Notify(&watchlist, "alice@example.com", &notification); err != nil || len(events.events) != 0 {
+			t.Fatalf("expected immediate delivery, got [%v] [%v]", events.events, err)
+		}
+	})
+	t.Run("queues and sends the digest when due", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		channels := mocks.NewMocknotificationSender(ctrl)
+		userprofileClient := mocks.NewMockuserprofileGetter(ctrl)
+		events := &mockDigestEvents{}
+		preferences := &mockPreferences{preferences: map[string]model.NotificationPreferences{
+			"userId": {UserID: "userId", Delivery: model.DeliveryDaily, DigestHour: 16, TimeZone: "UTC", LastDigest: now.Add(-22 * time.Hour)},
+		}}
+
+		digests := NewDigests(preferences, events, channels, userprofileClient)
+		digests.now = func() time.Time { return now }
+
+		digests.Notify(&watchlist, "alice@example.com", &model.Notification{Kind: model.NotificationChange, WatchlistID: watchlist.ID, Added: []string{"INTC"}, Current: []string{"INTC"}})
+
+		if len(events.events) != 1 {
+			t.Fatalf("expected queued event, got [%v]", events.events)
+		}
+
+		digests.SendDigests()
+
+		now = now.Add(2 * time.Hour)
+
+		userprofileClient.EXPECT().GetLocalizedUserprofile(gomock.Any(), "userId").Return(userprofileModel.Userprofile{Email: "alice@example.com"}, "", nil)
+		channels.EXPECT().Notify(gomock.Any(), "alice@example.com", gomock.Any()).DoAndReturn(func(w *model.Watchlist, email string, digest *model.Notification) error {
+			if digest.Kind != model.NotificationDigest || len(digest.Changes) != 1 || digest.Changes[0].Added[0] != "INTC" {
+				t.Fatalf("unexpected digest [%+v]", digest)
+			}
+			return nil
+		})
+
+		digests.SendDigests()
+
+		if len(events.events) != 0 || !preferences.preferences["userId"].LastDigest.Equal(now) {
+			t.Fatalf("expected sent digest, got [%v] [%v]", events.events, preferences.preferences["userId"].LastDigest)
+		}
+	})
+}
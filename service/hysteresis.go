@@ -0,0 +1,93 @@
+package service
+
+import (
+	"time"
+
+	"github.com/nagymarci/stock-watchlist/model"
+)
+
+//maxHysteresisBuffer is the widest price band around the opt-in price in percent
+const maxHysteresisBuffer = 50.0
+
+//maxDwellMinutes is the longest dwell time, one week
+const maxDwellMinutes = 7 * 24 * 60
+
+//ApplyHysteresis returns the recommended stocks of the watchlist with the hysteresis of the rule applied,
+//and the stocks still waiting for the dwell time. Stocks entering or leaving within the price band
+//keep their previous state, and so do the ones changing for less than the dwell time. Stocks of the watchlist
+//that could not be fetched keep their previous state and their pending change
+func ApplyHysteresis(symbols []string, previous []string, calculated []model.CalculatedStockInfo, rule *model.NotificationRule, pending map[string]model.PendingChange, now time.Time) ([]string, map[string]model.PendingChange) {
+	hysteresis := rule.Hysteresis
+	if hysteresis == nil {
+		hysteresis = &model.Hysteresis{}
+	}
+
+	dwell := time.Duration(hysteresis.DwellMinutes) * time.Minute
+	nextPending := make(map[string]model.PendingChange)
+
+	var result []string
+
+	for i := range calculated {
+		stock := &calculated[i]
+		recommended := contains(previous, stock.Ticker)
+		wanted := wantsRecommendation(stock, rule, hysteresis.Buffer, recommended)
+
+		if wanted != recommended && dwell > 0 {
+			change, ok := pending[stock.Ticker]
+
+			if !ok || change.Entering != wanted {
+				change = model.PendingChange{Entering: wanted, Since: now.UTC()}
+			}
+
+			if now.Sub(change.Since) < dwell {
+				nextPending[stock.Ticker] = change
+				wanted = recommended
+			}
+		}
+
+		if wanted {
+			result = append(result, stock.Ticker)
+		}
+	}
+
+	fetched := tickers(calculated)
+
+	for _, symbol := range symbols {
+		if contains(fetched, symbol) {
+			continue
+		}
+
+		if contains(previous, symbol) && !contains(result, symbol) {
+			result = append(result, symbol)
+		}
+
+		if change, ok := pending[symbol]; ok {
+			nextPending[symbol] = change
+		}
+	}
+
+	return result, nextPending
+}
+
+//wantsRecommendation returns whether the stock should be recommended. Within the price band above the opt-in
+//price a recommended stock is evaluated as if its price was still green, others have to fall below the band to enter
+func wantsRecommendation(stock *model.CalculatedStockInfo, rule *model.NotificationRule, buffer float64, recommended bool) bool {
+	matches := MatchesRule(stock, rule)
+
+	if buffer == 0 || stock.OptInPrice <= 0 {
+		return matches
+	}
+
+	if !recommended {
+		return matches && stock.Price <= stock.OptInPrice*(1-buffer/100)
+	}
+
+	if matches || stock.Price > stock.OptInPrice*(1+buffer/100) {
+		return matches
+	}
+
+	withinBand := *stock
+	withinBand.PriceColor = "green"
+
+	return MatchesRule(&withinBand, rule)
+}
@@ -0,0 +1,102 @@
+package service
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/nagymarci/stock-watchlist/model"
+)
+
+func TestApplyHysteresis(t *testing.T) {
+	now := time.Date(2021, 3, 1, 15, 0, 0, 0, time.UTC)
+
+	green := func(ticker string, price float64) model.CalculatedStockInfo {
+		return model.CalculatedStockInfo{Ticker: ticker, Price: price, OptInPrice: 100, PriceColor: "green", DividendColor: "green", PeColor: "green"}
+	}
+	red := func(ticker string, price float64) model.CalculatedStockInfo {
+		return model.CalculatedStockInfo{Ticker: ticker, Price: price, OptInPrice: 100, PriceColor: "red", DividendColor: "green", PeColor: "green"}
+	}
+
+	t.Run("stocks within the price band keep their state", func(t *testing.T) {
+		rule := DefaultNotificationRule()
+		rule.Hysteresis = &model.Hysteresis{Buffer: 2}
+
+		calculated := []model.CalculatedStockInfo{
+			green("ENTERS", 97.5),
+			green("WAITS", 99),
+			red("STAYS", 101.5),
+			red("LEAVES", 103),
+		}
+
+		current, pending := ApplyHysteresis(tickers(calculated), []string{"STAYS", "LEAVES"}, calculated, &rule, nil, now)
+
+		if !reflect.DeepEqual(current, []string{"ENTERS", "STAYS"}) || len(pending) != 0 {
+			t.Fatalf("unexpected result [%v] [%v]", current, pending)
+		}
+	})
+	t.Run("changes wait for the dwell time", func(t *testing.T) {
+		rule := DefaultNotificationRule()
+		rule.Hysteresis = &model.Hysteresis{DwellMinutes: 120}
+
+		calculated := []model.CalculatedStockInfo{green("NEW", 90), green("OLD", 90), red("GONE", 110), red("FLIPPED", 110)}
+
+		pending := map[string]model.PendingChange{
+			"OLD":     {Entering: true, Since: now.Add(-3 * time.Hour)},
+			"FLIPPED": {Entering: true, Since: now.Add(-3 * time.Hour)},
+		}
+
+		current, next := ApplyHysteresis(tickers(calculated), []string{"GONE", "FLIPPED"}, calculated, &rule, pending, now)
+
+		if !reflect.DeepEqual(current, []string{"OLD", "GONE", "FLIPPED"}) {
+			t.Fatalf("unexpected stocks [%v]", current)
+		}
+
+		expected := map[string]model.PendingChange{
+			"NEW":     {Entering: true, Since: now},
+			"GONE":    {Entering: false, Since: now},
+			"FLIPPED": {Entering: false, Since: now},
+		}
+
+		if !reflect.DeepEqual(next, expected) {
+			t.Fatalf("unexpected pending [%v]", next)
+		}
+	})
+	t.Run("stocks that could not be fetched keep their state", func(t *testing.T) {
+		rule := DefaultNotificationRule()
+		rule.Hysteresis = &model.Hysteresis{DwellMinutes: 120}
+
+		calculated := []model.CalculatedStockInfo{green("HELD", 90)}
+
+		pending := map[string]model.PendingChange{
+			"FAILED":  {Entering: false, Since: now.Add(-time.Hour)},
+			"WAITING": {Entering: true, Since: now.Add(-time.Hour)},
+			"REMOVED": {Entering: false, Since: now.Add(-time.Hour)},
+		}
+
+		current, next := ApplyHysteresis([]string{"HELD", "FAILED", "WAITING"}, []string{"HELD", "FAILED", "REMOVED"}, calculated, &rule, pending, now)
+
+		if !reflect.DeepEqual(current, []string{"HELD", "FAILED"}) {
+			t.Fatalf("unexpected stocks [%v]", current)
+		}
+
+		expected := map[string]model.PendingChange{
+			"FAILED":  {Entering: false, Since: now.Add(-time.Hour)},
+			"WAITING": {Entering: true, Since: now.Add(-time.Hour)},
+		}
+
+		if !reflect.DeepEqual(next, expected) {
+			t.Fatalf("unexpected pending [%v]", next)
+		}
+	})
+	t.Run("validates the hysteresis of the rule", func(t *testing.T) {
+		for _, hysteresis := range []model.Hysteresis{{Buffer: -1}, {Buffer: 60}, {DwellMinutes: -5}, {DwellMinutes: maxDwellMinutes + 1}} {
+			rule := DefaultNotificationRule()
+			rule.Hysteresis = &hysteresis
+
+			if err := ValidateNotificationRule(&rule); err == nil {
+				t.Fatalf("expected error for [%+v]", hysteresis)
+			}
+		}
+	})
+}
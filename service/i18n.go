@@ -0,0 +1,250 @@
+package service
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/nagymarci/stock-watchlist/model"
+)
+
+//DefaultLocale is used for the users without a locale, and for the messages missing from the catalog of their locale
+const DefaultLocale = "en"
+
+var localeTagPattern = regexp.MustCompile(`^[a-zA-Z]{2,3}([-_][a-zA-Z0-9]{2,8})*$`)
+
+//Locale holds the message catalog and the number format of a language.
+//Messages missing from the catalog are looked up in the fallback locale
+type Locale struct {
+	Tag      string
+	decimal  string
+	group    string
+	currency string
+	messages map[string]string
+	fallback *Locale
+}
+
+var englishLocale = &Locale{
+	Tag:      "en",
+	decimal:  ".",
+	group:    ",",
+	currency: "$%s",
+	messages: map[string]string{
+		"change.subject":     "%s changed!",
+		"change.intro":       "Recommendations in your %s profile has changed.",
+		"change.removed":     "Removed stocks",
+		"change.added":       "Added stocks",
+		"change.current":     "Currently recommended stocks: %s",
+		"alert.subject":      "%s alert %s changed!",
+		"alert.intro":        "Stocks matching your %s alert in your %s profile has changed.",
+		"alert.removed":      "Stopped matching",
+		"alert.added":        "Started matching",
+		"alert.current":      "Currently matching stocks: %s",
+		"digest.subject":     "Your watchlist digest",
+		"digest.intro":       "Changes of your watchlists since the last digest.",
+		"digest.alert":       "%s alert",
+		"digest.removed":     "Removed",
+		"digest.added":       "Added",
+		"digest.current":     "Currently: %s",
+		"priceAlert.subject": "%s!",
+		"priceAlert.intro":   "Your price alert %s fired.",
+		"signal.subject":     "%s signals changed!",
+		"signal.intro":       "Signals of stocks in your %s profile has changed.",
+		"signal.current":     "Current signals",
+		"stock.row":          "%s: price %s, opt-in price %s, yield %s, price/dividend/pe %s/%s/%s",
+		"stock.transition":   "%s: %s turned %s, was %s",
+		"stock.none":         "-",
+		"table.ticker":       "Ticker",
+		"table.price":        "Price",
+		"table.optInPrice":   "Opt-in price",
+		"table.yield":        "Yield",
+		"table.dividend":     "Dividend",
+		"table.pe":           "PE",
+		"table.signal":       "Signal",
+		"table.from":         "From",
+		"table.to":           "To",
+		"footer.watchlist":   "Stop notifications of this watchlist",
+		"footer.all":         "Stop all notifications",
+		"color.green":        "green",
+		"color.yellow":       "yellow",
+		"color.red":          "red",
+		"metric.price":       "price",
+		"metric.dividend":    "dividend",
+		"metric.pe":          "pe",
+	},
+}
+
+var hungarianLocale = &Locale{
+	Tag:      "hu",
+	decimal:  ",",
+	group:    " ",
+	currency: "%s\u00a0USD",
+	messages: map[string]string{
+		"change.subject":   "Változott: %s",
+		"change.intro":     "Megváltoztak a(z) %s profil ajánlásai.",
+		"change.removed":   "Kikerült részvények",
+		"change.added":     "Bekerült részvények",
+		"change.current":   "Jelenleg ajánlott részvények: %s",
+		"alert.subject":    "%[1]s: változott a(z) %[2]s riasztás",
+		"alert.intro":      "Változtak a(z) %[2]s profil %[1]s riasztásának megfelelő részvények.",
+		"alert.removed":    "Már nem felel meg",
+		"alert.added":      "Megfelel",
+		"alert.current":    "Jelenleg megfelelő részvények: %s",
+		"digest.subject":   "A figyelőlisták összefoglalója",
+		"digest.intro":     "A figyelőlisták változásai a legutóbbi összefoglaló óta.",
+		"digest.alert":     "%s riasztás",
+		"digest.removed":   "Kikerült",
+		"digest.added":     "Bekerült",
+		"digest.current":   "Jelenleg: %s",
+		"priceAlert.intro": "Teljesült a(z) %s árfolyamriasztás.",
+		"signal.subject":   "%s: változtak a jelzések",
+		"signal.intro":     "Változtak a(z) %s profil részvényeinek jelzései.",
+		"signal.current":   "Aktuális jelzések",
+		"stock.row":        "%s: árfolyam %s, belépési ár %s, hozam %s, ár/osztalék/pe %s/%s/%s",
+		"stock.transition": "%[1]s: %[2]s %[3]s lett, korábban %[4]s",
+		"table.ticker":     "Részvény",
+		"table.price":      "Árfolyam",
+		"table.optInPrice": "Belépési ár",
+		"table.yield":      "Hozam",
+		"table.dividend":   "Osztalék",
+		"table.pe":         "P/E",
+		"table.signal":     "Jelzés",
+		"table.from":       "Korábban",
+		"table.to":         "Most",
+		"footer.watchlist": "Értesítések leállítása ennél a figyelőlistánál",
+		"footer.all":       "Minden értesítés leállítása",
+		"color.green":      "zöld",
+		"color.yellow":     "sárga",
+		"color.red":        "piros",
+		"metric.price":     "árfolyam",
+		"metric.dividend":  "osztalék",
+		"metric.pe":        "pe",
+	},
+	fallback: englishLocale,
+}
+
+//locales holds the shipped locales by tag
+var locales = map[string]*Locale{
+	englishLocale.Tag:   englishLocale,
+	hungarianLocale.Tag: hungarianLocale,
+}
+
+//Locales returns the tags of the shipped locales
+func Locales() []string {
+	return []string{englishLocale.Tag, hungarianLocale.Tag}
+}
+
+//LocaleOf returns the locale of the tag, falling back from the region to the language,
+//and to the default locale, e.g. hu-HU, hu, en
+func LocaleOf(tag string) *Locale {
+	tag = strings.ToLower(strings.Replace(tag, "_", "-", -1))
+
+	for tag != "" {
+		if locale, ok := locales[tag]; ok {
+			return locale
+		}
+
+		i := strings.LastIndex(tag, "-")
+		if i < 0 {
+			break
+		}
+		tag = tag[:i]
+	}
+
+	return locales[DefaultLocale]
+}
+
+//NotificationLocale returns the locale of the notifications of the user: the one chosen in the preferences,
+//then the one of the userprofile, then the default
+func NotificationLocale(preferences *model.NotificationPreferences, userprofileLocale string) string {
+	if preferences != nil && preferences.Locale != "" {
+		return preferences.Locale
+	}
+
+	if userprofileLocale != "" && ValidateLocale(userprofileLocale) == nil {
+		return userprofileLocale
+	}
+
+	return DefaultLocale
+}
+
+//ValidateLocale checks that the locale is a language tag, e.g. en or hu-HU. Empty selects the default locale
+func ValidateLocale(tag string) error {
+	if tag != "" && !localeTagPattern.MatchString(tag) {
+		return fmt.Errorf("Invalid locale [%s]", tag)
+	}
+
+	return nil
+}
+
+//T returns the message of the key formatted with the arguments. Unknown keys are returned as they are
+func (l *Locale) T(key string, args ...interface{}) string {
+	message, ok := l.message(key)
+
+	if !ok {
+		return key
+	}
+
+	if len(args) == 0 {
+		return message
+	}
+
+	return fmt.Sprintf(message, args...)
+}
+
+func (l *Locale) message(key string) (string, bool) {
+	for locale := l; locale != nil; locale = locale.fallback {
+		if message, ok := locale.messages[key]; ok {
+			return message, true
+		}
+	}
+
+	return "", false
+}
+
+//Name returns the translation of a signal or color name, or the name itself if it is unknown
+func (l *Locale) Name(kind string, name string) string {
+	if message, ok := l.message(kind + "." + name); ok {
+		return message
+	}
+
+	return name
+}
+
+//Number formats the value with two decimals and grouped thousands
+func (l *Locale) Number(value float64) string {
+	text := strconv.FormatFloat(math.Abs(value), 'f', 2, 64)
+
+	integer, fraction := text[:len(text)-3], text[len(text)-2:]
+
+	var grouped strings.Builder
+	for i, digit := range integer {
+		if i > 0 && (len(integer)-i)%3 == 0 {
+			grouped.WriteString(l.group)
+		}
+		grouped.WriteRune(digit)
+	}
+
+	sign := ""
+	if value < 0 && text != "0.00" {
+		sign = "-"
+	}
+
+	return sign + grouped.String() + l.decimal + fraction
+}
+
+//Percent formats the value as percentage
+func (l *Locale) Percent(value float64) string {
+	return l.Number(value) + "%"
+}
+
+//Money formats the value as US dollar amount
+func (l *Locale) Money(value float64) string {
+	if value < 0 {
+		return "-" + fmt.Sprintf(l.currency, l.Number(-value))
+	}
+
+	return fmt.Sprintf(l.currency, l.Number(value))
+}
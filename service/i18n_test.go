@@ -0,0 +1,87 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/nagymarci/stock-watchlist/model"
+)
+
+func TestLocale(t *testing.T) {
+	t.Run("falls back from the region to the language and the default", func(t *testing.T) {
+		for tag, expected := range map[string]string{"": "en", "hu": "hu", "hu-HU": "hu", "hu_hu": "hu", "en-GB": "en", "de-AT": "en"} {
+			if locale := LocaleOf(tag); locale.Tag != expected {
+				t.Fatalf("unexpected locale of [%s]: [%s]", tag, locale.Tag)
+			}
+		}
+	})
+	t.Run("falls back to the default catalog for missing messages", func(t *testing.T) {
+		hu := LocaleOf("hu")
+
+		if message := hu.T("priceAlert.subject", "KO above 60"); message != "KO above 60!" {
+			t.Fatalf("unexpected message [%s]", message)
+		}
+
+		if message := hu.T("unknown.key"); message != "unknown.key" {
+			t.Fatalf("unexpected message [%s]", message)
+		}
+
+		if name := hu.Name("color", "blue"); name != "blue" {
+			t.Fatalf("unexpected name [%s]", name)
+		}
+	})
+	t.Run("formats the numbers of the locale", func(t *testing.T) {
+		en, hu := LocaleOf("en"), LocaleOf("hu")
+
+		cases := []struct {
+			actual   string
+			expected string
+		}{
+			{en.Number(1234567.891), "1,234,567.89"},
+			{en.Number(-999.999), "-1,000.00"},
+			{en.Number(-0.001), "0.00"},
+			{en.Money(-12.5), "-$12.50"},
+			{en.Percent(3.456), "3.46%"},
+			{hu.Number(1234567.891), "1 234 567,89"},
+			{hu.Money(45.123), "45,12\u00a0USD"},
+			{hu.Percent(3.456), "3,46%"},
+		}
+
+		for _, c := range cases {
+			if c.actual != c.expected {
+				t.Fatalf("expected [%s], got [%s]", c.expected, c.actual)
+			}
+		}
+	})
+	t.Run("resolves the locale of the notifications", func(t *testing.T) {
+		cases := []struct {
+			name        string
+			preferences *model.NotificationPreferences
+			userprofile string
+			expected    string
+		}{
+			{"preferences first", &model.NotificationPreferences{Locale: "de"}, "hu-HU", "de"},
+			{"then the userprofile", &model.NotificationPreferences{}, "hu-HU", "hu-HU"},
+			{"invalid userprofile locale is ignored", &model.NotificationPreferences{}, "hu-", DefaultLocale},
+			{"then the default", &model.NotificationPreferences{}, "", DefaultLocale},
+		}
+
+		for _, c := range cases {
+			if locale := NotificationLocale(c.preferences, c.userprofile); locale != c.expected {
+				t.Fatalf("%s: expected [%s], got [%s]", c.name, c.expected, locale)
+			}
+		}
+	})
+	t.Run("validates the locale tag", func(t *testing.T) {
+		for _, valid := range []string{"", "en", "hu-HU", "zh_Hant_TW"} {
+			if err := ValidateLocale(valid); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		for _, invalid := range []string{"e", "english!", "hu-"} {
+			if err := ValidateLocale(invalid); err == nil {
+				t.Fatalf("expected error for [%s]", invalid)
+			}
+		}
+	})
+}
@@ -0,0 +1,143 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"github.com/nagymarci/stock-watchlist/model"
+)
+
+type leaseStore interface {
+	TryAcquire(name string, holder string, now time.Time, ttl time.Duration) (bool, error)
+	Release(name string, holder string) error
+	Get(name string) (model.Lease, error)
+}
+
+//Leader elects one instance through a lease to run the scheduled jobs. The holder renews
+//the lease periodically, other instances take it over once it expired
+type Leader struct {
+	leases   leaseStore
+	name     string
+	instance string
+	ttl      time.Duration
+	now      func() time.Time
+
+	mu     sync.Mutex
+	leader bool
+}
+
+//ErrNotLeader is returned when a job is started on an instance not holding the lease
+var ErrNotLeader = errors.New("This instance does not hold the scheduler lease")
+
+func NewLeader(l leaseStore, name string, instance string, ttl time.Duration) *Leader {
+	return &Leader{
+		leases:   l,
+		name:     name,
+		instance: instance,
+		ttl:      ttl,
+		now:      time.Now,
+	}
+}
+
+//InstanceID identifies the running instance, INSTANCE_ID if set, otherwise the host name and the process id
+func InstanceID() string {
+	if id := os.Getenv("INSTANCE_ID"); id != "" {
+		return id
+	}
+
+	host, err := os.Hostname()
+	if err != nil {
+		host = "unknown"
+	}
+
+	return fmt.Sprintf("%s-%d", host, os.Getpid())
+}
+
+//Start tries to acquire or renew the lease every third of its ttl in the background
+func (l *Leader) Start() {
+	l.refresh()
+
+	go func() {
+		ticker := time.NewTicker(l.ttl / 3)
+		defer ticker.Stop()
+
+		for range ticker.C {
+			l.refresh()
+		}
+	}()
+}
+
+//refresh acquires or renews the lease, and returns whether this instance holds it
+func (l *Leader) refresh() bool {
+	acquired, err := l.leases.TryAcquire(l.name, l.instance, l.now().UTC(), l.ttl)
+
+	if err != nil {
+		logrus.WithField("lease", l.name).Errorf("Failed to acquire lease [%v]", err)
+		acquired = false
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if acquired != l.leader {
+		logrus.WithFields(logrus.Fields{"lease": l.name, "instance": l.instance, "leader": acquired}).Infoln("Leadership changed")
+	}
+
+	l.leader = acquired
+
+	return acquired
+}
+
+//IsLeader returns whether this instance held the lease at the last renewal
+func (l *Leader) IsLeader() bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.leader
+}
+
+//Guard returns a job that runs only on the instance holding the lease.
+//The lease is renewed right before the job, so a stale leader does not run it
+func (l *Leader) Guard(job func()) func() {
+	return func() {
+		if !l.refresh() {
+			return
+		}
+
+		job()
+	}
+}
+
+//Release gives up the lease, so another instance can take over without waiting for the expiry
+func (l *Leader) Release() error {
+	l.mu.Lock()
+	l.leader = false
+	l.mu.Unlock()
+
+	return l.leases.Release(l.name, l.instance)
+}
+
+//Status returns the current holder of the lease
+func (l *Leader) Status() (model.SchedulerStatus, error) {
+	status := model.SchedulerStatus{Instance: l.instance, Leader: l.IsLeader()}
+
+	lease, err := l.leases.Get(l.name)
+
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return status, nil
+	}
+
+	if err != nil {
+		return status, fmt.Errorf("Failed to get lease [%s]: [%v]", l.name, err)
+	}
+
+	status.Lease = &lease
+
+	return status, nil
+}
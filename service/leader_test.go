@@ -0,0 +1,108 @@
+package service
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"github.com/nagymarci/stock-watchlist/model"
+)
+
+type mockLeases struct {
+	mu     sync.Mutex
+	leases map[string]model.Lease
+}
+
+func (m *mockLeases) TryAcquire(name string, holder string, now time.Time, ttl time.Duration) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	lease, ok := m.leases[name]
+	if ok && lease.Holder != holder && !lease.ExpiresAt.Before(now) {
+		return false, nil
+	}
+
+	if !ok || lease.Holder != holder {
+		lease = model.Lease{Name: name, Holder: holder, AcquiredAt: now}
+	}
+	lease.RenewedAt = now
+	lease.ExpiresAt = now.Add(ttl)
+	m.leases[name] = lease
+
+	return true, nil
+}
+
+func (m *mockLeases) Release(name string, holder string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.leases[name].Holder == holder {
+		delete(m.leases, name)
+	}
+	return nil
+}
+
+func (m *mockLeases) Get(name string) (model.Lease, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	lease, ok := m.leases[name]
+	if !ok {
+		return lease, mongo.ErrNoDocuments
+	}
+	return lease, nil
+}
+
+func TestLeader(t *testing.T) {
+	leases := &mockLeases{leases: make(map[string]model.Lease)}
+	now := time.Date(2020, 12, 3, 14, 0, 0, 0, time.UTC)
+
+	first := NewLeader(leases, "scheduler", "first", 30*time.Second)
+	first.now = func() time.Time { return now }
+	second := NewLeader(leases, "scheduler", "second", 30*time.Second)
+	second.now = func() time.Time { return now }
+
+	runs := map[string]int{}
+	firstJob := first.Guard(func() { runs["first"]++ })
+	secondJob := second.Guard(func() { runs["second"]++ })
+
+	firstJob()
+	secondJob()
+
+	if runs["first"] != 1 || runs["second"] != 0 || !first.IsLeader() || second.IsLeader() {
+		t.Fatalf("expected only the first instance to run, got [%v]", runs)
+	}
+
+	//the holder keeps the lease while renewing it
+	now = now.Add(20 * time.Second)
+	firstJob()
+	now = now.Add(20 * time.Second)
+	secondJob()
+
+	if runs["first"] != 2 || runs["second"] != 0 {
+		t.Fatalf("expected renewed lease to block the second instance, got [%v]", runs)
+	}
+
+	//an expired lease is taken over
+	now = now.Add(31 * time.Second)
+	secondJob()
+	firstJob()
+
+	if runs["first"] != 2 || runs["second"] != 1 {
+		t.Fatalf("expected the second instance to take over, got [%v]", runs)
+	}
+
+	status, err := second.Status()
+	if err != nil || !status.Leader || status.Lease.Holder != "second" || !status.Lease.AcquiredAt.Equal(now) {
+		t.Fatalf("unexpected status [%+v] [%v]", status, err)
+	}
+
+	second.Release()
+	firstJob()
+
+	if runs["first"] != 3 {
+		t.Fatalf("expected the first instance to take over the released lease, got [%v]", runs)
+	}
+}
@@ -1,50 +1,424 @@
 package service
 
 import (
+	"bytes"
+	"crypto/rand"
+	"crypto/tls"
+	"encoding/hex"
 	"fmt"
+	"io/ioutil"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	netMail "net/mail"
 	"net/smtp"
+	"net/textproto"
 	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/nagymarci/stock-watchlist/model"
 )
 
-// smtpServer data to smtp server
-type smtpServer struct {
-	host string
-	port string
+//TLS modes of the smtp connection
+const (
+	MailTLSStartTLS = "starttls"
+	MailTLSImplicit = "tls"
+	MailTLSNone     = "none"
+)
+
+//mailIdleTimeout is how long an unused smtp connection is kept open for the next message
+const mailIdleTimeout = 30 * time.Second
+
+const mailDialTimeout = 10 * time.Second
+
+//MailConfig holds the settings of the mailer. If Dir is set, the messages are written there as .eml files
+//instead of being sent
+type MailConfig struct {
+	Host     string
+	Port     string
+	Username string
+	Password string
+	From     string
+	TLS      string
+	Dir      string
+}
+
+//LoadMailConfig reads and validates the mailer settings from the environment.
+//The sender defaults to the username, the TLS mode to STARTTLS
+func LoadMailConfig() (MailConfig, error) {
+	config := MailConfig{
+		Host:     os.Getenv("SMPT_SERVER_HOST"),
+		Port:     os.Getenv("SMPT_SERVER_PORT"),
+		Username: os.Getenv("SMPT_SENDER_USERNAME"),
+		Password: os.Getenv("SMPT_SENDER_PASSWORD"),
+		From:     os.Getenv("MAIL_FROM"),
+		TLS:      os.Getenv("MAIL_TLS"),
+		Dir:      os.Getenv("MAIL_DIR"),
+	}
+
+	if config.From == "" {
+		config.From = config.Username
+	}
+
+	if config.TLS == "" {
+		config.TLS = MailTLSStartTLS
+	}
+
+	return config, config.Validate()
+}
+
+//Validate checks that messages can be sent with the settings
+func (c *MailConfig) Validate() error {
+	if _, err := netMail.ParseAddress(c.From); err != nil {
+		return fmt.Errorf("Invalid mail sender address [%s]: [%v]", c.From, err)
+	}
+
+	if c.Dir != "" {
+		return nil
+	}
+
+	if c.Host == "" {
+		return fmt.Errorf("Missing smtp server host")
+	}
+
+	if port, err := strconv.Atoi(c.Port); err != nil || port < 1 || port > 65535 {
+		return fmt.Errorf("Invalid smtp server port [%s]", c.Port)
+	}
+
+	if c.TLS != MailTLSStartTLS && c.TLS != MailTLSImplicit && c.TLS != MailTLSNone {
+		return fmt.Errorf("Unknown mail TLS mode [%s]", c.TLS)
+	}
+
+	if c.Password != "" && c.Username == "" {
+		return fmt.Errorf("Missing smtp username for the password")
+	}
+
+	if c.TLS == MailTLSNone && c.Username != "" {
+		return fmt.Errorf("Refusing to send smtp credentials without TLS")
+	}
+
+	return nil
 }
 
-// Address URI to smtp server
-func (s *smtpServer) Address() string {
-	return s.host + ":" + s.port
+//SMTPMailer sends the emails through an smtp server. The connection is kept open between the messages,
+//so a notifier run reuses it, and closed after it is idle for a while
+type SMTPMailer struct {
+	config      MailConfig
+	from        *netMail.Address
+	idleTimeout time.Duration
+	now         func() time.Time
+
+	mu     sync.Mutex
+	client *smtp.Client
+	idle   *time.Timer
 }
 
-type mail struct{}
+func NewSMTPMailer(config MailConfig) (*SMTPMailer, error) {
+	from, err := netMail.ParseAddress(config.From)
+
+	if err != nil {
+		return nil, fmt.Errorf("Invalid mail sender address [%s]: [%v]", config.From, err)
+	}
 
-func NewMail() *mail {
-	return &mail{}
+	return &SMTPMailer{
+		config:      config,
+		from:        from,
+		idleTimeout: mailIdleTimeout,
+		now:         time.Now,
+	}, nil
 }
 
-func (m *mail) SendNotification(profileName string, removed, added, currentStocks []string, email string) error {
-	// Sender data.
-	from := os.Getenv("SMPT_SENDER_USERNAME")
-	password := os.Getenv("SMPT_SENDER_PASSWORD")
-	// Receiver email address.
-	to := []string{
-		email,
+func (m *SMTPMailer) Send(channel *model.NotificationChannel, notification *model.Notification, rendered *model.RenderedMessage) error {
+	message, err := buildMailMessage(m.from, channel.Address, m.now(), rendered)
+
+	if err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.idle != nil {
+		m.idle.Stop()
+	}
+	defer func() {
+		m.idle = time.AfterFunc(m.idleTimeout, m.Close)
+	}()
+
+	err = m.deliver(channel.Address, message)
+
+	if err != nil {
+		m.closeClient()
 	}
-	// smtp server configuration.
-	smtpServer := smtpServer{host: os.Getenv("SMPT_SERVER_HOST"), port: os.Getenv("SMPT_SERVER_PORT")}
-	// Message.
-	message := []byte(fmt.Sprintf("To: %v\n"+
-		"Subject: %v changed!\n"+
-		"\n"+
-		"Recommendations in your %v profile has changed.\n\n"+
-		"Removed stocks: %+v\n"+
-		"Added stocks: %+v\n\n"+
-		"Currently recommended stocks: %+v", to[0], profileName, profileName, removed, added, currentStocks))
-	// Authentication.
-	auth := smtp.PlainAuth("", from, password, smtpServer.host)
-	// Sending email.
-	err := smtp.SendMail(smtpServer.Address(), auth, from, to, message)
 
 	return err
 }
+
+//Close closes the connection to the smtp server
+func (m *SMTPMailer) Close() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.closeClient()
+}
+
+func (m *SMTPMailer) closeClient() {
+	if m.client == nil {
+		return
+	}
+
+	if err := m.client.Quit(); err != nil {
+		m.client.Close()
+	}
+
+	m.client = nil
+}
+
+func (m *SMTPMailer) deliver(to string, message []byte) error {
+	if err := m.begin(); err != nil {
+		return err
+	}
+
+	if err := m.client.Rcpt(to); err != nil {
+		return fmt.Errorf("Failed to set mail recipient [%s]: [%v]", to, err)
+	}
+
+	writer, err := m.client.Data()
+
+	if err != nil {
+		return fmt.Errorf("Failed to start mail data: [%v]", err)
+	}
+
+	if _, err := writer.Write(message); err != nil {
+		return fmt.Errorf("Failed to write mail data: [%v]", err)
+	}
+
+	if err := writer.Close(); err != nil {
+		return fmt.Errorf("Failed to send mail: [%v]", err)
+	}
+
+	return nil
+}
+
+//begin starts the mail transaction. The server may have closed the idle connection, so a reused connection
+//failing the NOOP probe or the MAIL command is replaced once. Nothing is sent again after that point
+func (m *SMTPMailer) begin() error {
+	if m.client != nil {
+		if err := m.client.Noop(); err == nil {
+			if err := m.client.Mail(m.from.Address); err == nil {
+				return nil
+			}
+		}
+
+		m.closeClient()
+	}
+
+	client, err := m.connect()
+
+	if err != nil {
+		return err
+	}
+
+	m.client = client
+
+	if err := m.client.Mail(m.from.Address); err != nil {
+		return fmt.Errorf("Failed to set mail sender: [%v]", err)
+	}
+
+	return nil
+}
+
+func (m *SMTPMailer) connect() (*smtp.Client, error) {
+	address := net.JoinHostPort(m.config.Host, m.config.Port)
+	tlsConfig := &tls.Config{ServerName: m.config.Host}
+	dialer := &net.Dialer{Timeout: mailDialTimeout}
+
+	var conn net.Conn
+	var err error
+
+	if m.config.TLS == MailTLSImplicit {
+		conn, err = tls.DialWithDialer(dialer, "tcp", address, tlsConfig)
+	} else {
+		conn, err = dialer.Dial("tcp", address)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to connect to smtp server [%s]: [%v]", address, err)
+	}
+
+	client, err := smtp.NewClient(conn, m.config.Host)
+
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("Failed to connect to smtp server [%s]: [%v]", address, err)
+	}
+
+	if hostname, err := os.Hostname(); err == nil {
+		if err := client.Hello(hostname); err != nil {
+			client.Close()
+			return nil, fmt.Errorf("Failed to greet smtp server: [%v]", err)
+		}
+	}
+
+	if m.config.TLS == MailTLSStartTLS {
+		if ok, _ := client.Extension("STARTTLS"); !ok {
+			client.Close()
+			return nil, fmt.Errorf("Smtp server [%s] does not support STARTTLS", address)
+		}
+
+		if err := client.StartTLS(tlsConfig); err != nil {
+			client.Close()
+			return nil, fmt.Errorf("Failed to start TLS: [%v]", err)
+		}
+	}
+
+	if m.config.Username != "" {
+		if err := client.Auth(smtp.PlainAuth("", m.config.Username, m.config.Password, m.config.Host)); err != nil {
+			client.Close()
+			return nil, fmt.Errorf("Failed to authenticate to smtp server: [%v]", err)
+		}
+	}
+
+	logrus.WithField("smtpServer", address).Debugln("Connected to smtp server")
+
+	return client, nil
+}
+
+//FileMailer writes the emails as .eml files into a directory, for local development and tests
+type FileMailer struct {
+	dir  string
+	from *netMail.Address
+	now  func() time.Time
+}
+
+func NewFileMailer(config MailConfig) (*FileMailer, error) {
+	from, err := netMail.ParseAddress(config.From)
+
+	if err != nil {
+		return nil, fmt.Errorf("Invalid mail sender address [%s]: [%v]", config.From, err)
+	}
+
+	if err := os.MkdirAll(config.Dir, 0755); err != nil {
+		return nil, fmt.Errorf("Failed to create mail directory [%s]: [%v]", config.Dir, err)
+	}
+
+	return &FileMailer{
+		dir:  config.Dir,
+		from: from,
+		now:  time.Now,
+	}, nil
+}
+
+func (m *FileMailer) Send(channel *model.NotificationChannel, notification *model.Notification, rendered *model.RenderedMessage) error {
+	now := m.now()
+
+	message, err := buildMailMessage(m.from, channel.Address, now, rendered)
+
+	if err != nil {
+		return err
+	}
+
+	name := fmt.Sprintf("%d.%s.eml", now.UnixNano(), randomHex(4))
+
+	//the file is renamed once complete, so readers of the directory never see partial messages
+	temporary := filepath.Join(m.dir, "."+name+".tmp")
+
+	if err := ioutil.WriteFile(temporary, message, 0644); err != nil {
+		return fmt.Errorf("Failed to write mail [%s]: [%v]", name, err)
+	}
+
+	if err := os.Rename(temporary, filepath.Join(m.dir, name)); err != nil {
+		os.Remove(temporary)
+		return fmt.Errorf("Failed to write mail [%s]: [%v]", name, err)
+	}
+
+	return nil
+}
+
+//buildMailMessage creates a multipart/alternative message with the text and the html body
+func buildMailMessage(from *netMail.Address, to string, date time.Time, rendered *model.RenderedMessage) ([]byte, error) {
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+
+	parts := []struct {
+		contentType string
+		content     string
+	}{
+		{"text/plain; charset=UTF-8", rendered.Text},
+		{"text/html; charset=UTF-8", rendered.HTML},
+	}
+
+	for _, p := range parts {
+		if p.content == "" {
+			continue
+		}
+
+		part, err := writer.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {p.contentType},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		})
+		if err != nil {
+			return nil, fmt.Errorf("Failed to create mail part: [%v]", err)
+		}
+
+		qp := quotedprintable.NewWriter(part)
+		if _, err := qp.Write([]byte(p.content)); err != nil {
+			return nil, fmt.Errorf("Failed to encode mail part: [%v]", err)
+		}
+		if err := qp.Close(); err != nil {
+			return nil, fmt.Errorf("Failed to encode mail part: [%v]", err)
+		}
+	}
+
+	if err := writer.Close(); err != nil {
+		return nil, fmt.Errorf("Failed to close mail body: [%v]", err)
+	}
+
+	var message bytes.Buffer
+	fmt.Fprintf(&message, "From: %s\r\n", from.String())
+	fmt.Fprintf(&message, "To: %s\r\n", (&netMail.Address{Address: to}).String())
+	fmt.Fprintf(&message, "Subject: %s\r\n", mime.QEncoding.Encode("UTF-8", rendered.Subject))
+	fmt.Fprintf(&message, "Date: %s\r\n", date.Format(time.RFC1123Z))
+	fmt.Fprintf(&message, "Message-ID: %s\r\n", messageID(from))
+	if link := unsubscribeLink(rendered.Unsubscribe); link != "" {
+		fmt.Fprintf(&message, "List-Unsubscribe: <%s>\r\n", link)
+		fmt.Fprintf(&message, "List-Unsubscribe-Post: List-Unsubscribe=One-Click\r\n")
+	}
+	fmt.Fprintf(&message, "MIME-Version: 1.0\r\n")
+	fmt.Fprintf(&message, "Content-Type: multipart/alternative; boundary=%q\r\n", writer.Boundary())
+	fmt.Fprintf(&message, "\r\n")
+	message.Write(body.Bytes())
+
+	return message.Bytes(), nil
+}
+
+//messageID returns a unique message id in the domain of the sender
+func messageID(from *netMail.Address) string {
+	domain := "localhost"
+	if at := strings.LastIndex(from.Address, "@"); at >= 0 {
+		domain = from.Address[at+1:]
+	}
+
+	return "<" + randomHex(16) + "@" + domain + ">"
+}
+
+func randomHex(n int) string {
+	b := make([]byte, n)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+//unsubscribeLink returns the link of the List-Unsubscribe header, preferring the one of the watchlist
+func unsubscribeLink(links model.UnsubscribeLinks) string {
+	if links.Watchlist != "" {
+		return links.Watchlist
+	}
+	return links.All
+}
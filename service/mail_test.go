@@ -0,0 +1,282 @@
+package service
+
+import (
+	"bufio"
+	"io/ioutil"
+	"net"
+	netMail "net/mail"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/nagymarci/stock-watchlist/model"
+)
+
+func TestMailConfig(t *testing.T) {
+	valid := MailConfig{Host: "smtp.example.com", Port: "587", Username: "watchlist@example.com", Password: "secret", From: "watchlist@example.com", TLS: MailTLSStartTLS}
+
+	if err := valid.Validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	invalid := map[string]func(c *MailConfig){
+		"sender":   func(c *MailConfig) { c.From = "not an address" },
+		"host":     func(c *MailConfig) { c.Host = "" },
+		"port":     func(c *MailConfig) { c.Port = "smtp" },
+		"tls":      func(c *MailConfig) { c.TLS = "ssl" },
+		"username": func(c *MailConfig) { c.Username = "" },
+		"plain":    func(c *MailConfig) { c.TLS = MailTLSNone },
+	}
+
+	for name, change := range invalid {
+		config := valid
+		change(&config)
+
+		if err := config.Validate(); err == nil {
+			t.Fatalf("expected error for invalid %s", name)
+		}
+	}
+
+	directory := MailConfig{From: "watchlist@example.com", Dir: "mails"}
+	if err := directory.Validate(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestFileMailer(t *testing.T) {
+	dir, err := ioutil.TempDir("", "mails")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	mailer, err := NewFileMailer(MailConfig{From: "Stock Watchlist <watchlist@example.com>", Dir: filepath.Join(dir, "out")})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 2; i++ {
+		if err := mailer.Send(&model.NotificationChannel{Address: "alice@example.com"}, &model.Notification{}, &model.RenderedMessage{Subject: "changed", Text: "text"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	files, _ := filepath.Glob(filepath.Join(dir, "out", "*"))
+	if len(files) != 2 || !strings.HasSuffix(files[0], ".eml") || !strings.HasSuffix(files[1], ".eml") {
+		t.Fatalf("unexpected files [%v]", files)
+	}
+
+	content, _ := ioutil.ReadFile(files[0])
+	message, err := netMail.ReadMessage(strings.NewReader(string(content)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if message.Header.Get("From") != `"Stock Watchlist" <watchlist@example.com>` || message.Header.Get("To") != "<alice@example.com>" || message.Header.Get("Subject") != "changed" {
+		t.Fatalf("unexpected headers [%v]", message.Header)
+	}
+}
+
+//fakeSMTPServer accepts plain smtp connections and counts them and the received messages
+type fakeSMTPServer struct {
+	listener net.Listener
+
+	mu          sync.Mutex
+	open        []net.Conn
+	connections int
+	recipients  int
+	rejectRcpt  bool
+	messages    []string
+}
+
+func newFakeSMTPServer(t *testing.T) *fakeSMTPServer {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	server := &fakeSMTPServer{listener: listener}
+	go server.serve()
+
+	return server
+}
+
+func (s *fakeSMTPServer) serve() {
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+
+		s.mu.Lock()
+		s.connections++
+		s.open = append(s.open, conn)
+		s.mu.Unlock()
+
+		go s.handle(conn)
+	}
+}
+
+func (s *fakeSMTPServer) handle(conn net.Conn) {
+	defer conn.Close()
+
+	reader := bufio.NewReader(conn)
+	reply := func(line string) { conn.Write([]byte(line + "\r\n")) }
+
+	reply("220 localhost ready")
+
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return
+		}
+
+		command := strings.ToUpper(strings.Fields(line + " ")[0])
+
+		switch command {
+		case "EHLO", "HELO":
+			reply("250 localhost")
+		case "RCPT":
+			s.mu.Lock()
+			s.recipients++
+			reject := s.rejectRcpt
+			s.mu.Unlock()
+
+			if reject {
+				reply("550 no such user")
+			} else {
+				reply("250 ok")
+			}
+		case "DATA":
+			reply("354 go ahead")
+
+			var data strings.Builder
+			for {
+				line, err := reader.ReadString('\n')
+				if err != nil {
+					return
+				}
+				if line == ".\r\n" {
+					break
+				}
+				data.WriteString(line)
+			}
+
+			s.mu.Lock()
+			s.messages = append(s.messages, data.String())
+			s.mu.Unlock()
+
+			reply("250 queued")
+		case "QUIT":
+			reply("221 bye")
+			return
+		default:
+			reply("250 ok")
+		}
+	}
+}
+
+//drop closes the open connections, like a server timing out idle clients
+func (s *fakeSMTPServer) drop() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, conn := range s.open {
+		conn.Close()
+	}
+	s.open = nil
+}
+
+func (s *fakeSMTPServer) counts() (int, int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.connections, len(s.messages)
+}
+
+func TestSMTPMailer(t *testing.T) {
+	server := newFakeSMTPServer(t)
+	defer server.listener.Close()
+
+	host, port, _ := net.SplitHostPort(server.listener.Addr().String())
+
+	mailer, err := NewSMTPMailer(MailConfig{Host: host, Port: port, From: "watchlist@example.com", TLS: MailTLSNone})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	send := func() {
+		if err := mailer.Send(&model.NotificationChannel{Address: "alice@example.com"}, &model.Notification{}, &model.RenderedMessage{Subject: "changed", Text: "text"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	t.Run("reuses the connection", func(t *testing.T) {
+		send()
+		send()
+
+		if connections, messages := server.counts(); connections != 1 || messages != 2 {
+			t.Fatalf("unexpected connections [%d] and messages [%d]", connections, messages)
+		}
+	})
+	t.Run("reconnects after close", func(t *testing.T) {
+		mailer.Close()
+		send()
+
+		if connections, messages := server.counts(); connections != 2 || messages != 3 {
+			t.Fatalf("unexpected connections [%d] and messages [%d]", connections, messages)
+		}
+	})
+	t.Run("replaces the connection closed by the server", func(t *testing.T) {
+		server.drop()
+		send()
+
+		if connections, messages := server.counts(); connections != 3 || messages != 4 {
+			t.Fatalf("unexpected connections [%d] and messages [%d]", connections, messages)
+		}
+	})
+	t.Run("does not resend to rejected recipients", func(t *testing.T) {
+		server.mu.Lock()
+		server.rejectRcpt = true
+		recipients := server.recipients
+		server.mu.Unlock()
+
+		if err := mailer.Send(&model.NotificationChannel{Address: "bob@example.com"}, &model.Notification{}, &model.RenderedMessage{Subject: "changed", Text: "text"}); err == nil {
+			t.Fatal("expected error")
+		}
+
+		server.mu.Lock()
+		server.rejectRcpt = false
+		attempts := server.recipients - recipients
+		server.mu.Unlock()
+
+		if connections, messages := server.counts(); connections != 3 || messages != 4 || attempts != 1 {
+			t.Fatalf("unexpected connections [%d], messages [%d] and recipient attempts [%d]", connections, messages, attempts)
+		}
+	})
+	t.Run("closes idle connection", func(t *testing.T) {
+		mailer.idleTimeout = 10 * time.Millisecond
+		send()
+
+		time.Sleep(50 * time.Millisecond)
+
+		mailer.mu.Lock()
+		closed := mailer.client == nil
+		mailer.mu.Unlock()
+
+		if !closed {
+			t.Fatal("expected idle connection to be closed")
+		}
+	})
+	t.Run("fails on missing STARTTLS", func(t *testing.T) {
+		mailer.Close()
+		mailer.config.TLS = MailTLSStartTLS
+
+		if err := mailer.Send(&model.NotificationChannel{Address: "alice@example.com"}, &model.Notification{}, &model.RenderedMessage{Subject: "changed", Text: "text"}); err == nil {
+			t.Fatal("expected error")
+		}
+	})
+}
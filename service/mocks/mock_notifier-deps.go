@@ -5,6 +5,7 @@
 package mocks
 
 import (
+	context "context"
 	gomock "github.com/golang/mock/gomock"
 	model "github.com/nagymarci/stock-user-profile/model"
 	model0 "github.com/nagymarci/stock-watchlist/model"
@@ -103,41 +104,217 @@ func (mr *MockrecommendationProviderMockRecorder) Update(log, id, stocks interfa
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Update", reflect.TypeOf((*MockrecommendationProvider)(nil).Update), log, id, stocks)
 }
 
-// MockemailSender is a mock of emailSender interface
-type MockemailSender struct {
+// GetSignals mocks base method
+func (m *MockrecommendationProvider) GetSignals(id primitive.ObjectID) (map[string]model0.SignalState, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetSignals", id)
+	ret0, _ := ret[0].(map[string]model0.SignalState)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetSignals indicates an expected call of GetSignals
+func (mr *MockrecommendationProviderMockRecorder) GetSignals(id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSignals", reflect.TypeOf((*MockrecommendationProvider)(nil).GetSignals), id)
+}
+
+// UpdateSignals mocks base method
+func (m *MockrecommendationProvider) UpdateSignals(id primitive.ObjectID, signals map[string]model0.SignalState) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateSignals", id, signals)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateSignals indicates an expected call of UpdateSignals
+func (mr *MockrecommendationProviderMockRecorder) UpdateSignals(id, signals interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateSignals", reflect.TypeOf((*MockrecommendationProvider)(nil).UpdateSignals), id, signals)
+}
+
+// GetPending mocks base method
+func (m *MockrecommendationProvider) GetPending(id primitive.ObjectID) (map[string]model0.PendingChange, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetPending", id)
+	ret0, _ := ret[0].(map[string]model0.PendingChange)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetPending indicates an expected call of GetPending
+func (mr *MockrecommendationProviderMockRecorder) GetPending(id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPending", reflect.TypeOf((*MockrecommendationProvider)(nil).GetPending), id)
+}
+
+// UpdatePending mocks base method
+func (m *MockrecommendationProvider) UpdatePending(id primitive.ObjectID, pending map[string]model0.PendingChange) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdatePending", id, pending)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdatePending indicates an expected call of UpdatePending
+func (mr *MockrecommendationProviderMockRecorder) UpdatePending(id, pending interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdatePending", reflect.TypeOf((*MockrecommendationProvider)(nil).UpdatePending), id, pending)
+}
+
+// MockalertRuleProvider is a mock of alertRuleProvider interface
+type MockalertRuleProvider struct {
 	ctrl     *gomock.Controller
-	recorder *MockemailSenderMockRecorder
+	recorder *MockalertRuleProviderMockRecorder
 }
 
-// MockemailSenderMockRecorder is the mock recorder for MockemailSender
-type MockemailSenderMockRecorder struct {
-	mock *MockemailSender
+// MockalertRuleProviderMockRecorder is the mock recorder for MockalertRuleProvider
+type MockalertRuleProviderMockRecorder struct {
+	mock *MockalertRuleProvider
 }
 
-// NewMockemailSender creates a new mock instance
-func NewMockemailSender(ctrl *gomock.Controller) *MockemailSender {
-	mock := &MockemailSender{ctrl: ctrl}
-	mock.recorder = &MockemailSenderMockRecorder{mock}
+// NewMockalertRuleProvider creates a new mock instance
+func NewMockalertRuleProvider(ctrl *gomock.Controller) *MockalertRuleProvider {
+	mock := &MockalertRuleProvider{ctrl: ctrl}
+	mock.recorder = &MockalertRuleProviderMockRecorder{mock}
 	return mock
 }
 
 // EXPECT returns an object that allows the caller to indicate expected use
-func (m *MockemailSender) EXPECT() *MockemailSenderMockRecorder {
+func (m *MockalertRuleProvider) EXPECT() *MockalertRuleProviderMockRecorder {
 	return m.recorder
 }
 
-// SendNotification mocks base method
-func (m *MockemailSender) SendNotification(profileName string, removed, added, currentStocks []string, email string) error {
+// GetByWatchlist mocks base method
+func (m *MockalertRuleProvider) GetByWatchlist(watchlistID primitive.ObjectID) ([]model0.AlertRule, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "SendNotification", profileName, removed, added, currentStocks, email)
+	ret := m.ctrl.Call(m, "GetByWatchlist", watchlistID)
+	ret0, _ := ret[0].([]model0.AlertRule)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetByWatchlist indicates an expected call of GetByWatchlist
+func (mr *MockalertRuleProviderMockRecorder) GetByWatchlist(watchlistID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByWatchlist", reflect.TypeOf((*MockalertRuleProvider)(nil).GetByWatchlist), watchlistID)
+}
+
+// UpdateMatching mocks base method
+func (m *MockalertRuleProvider) UpdateMatching(id primitive.ObjectID, matching []string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateMatching", id, matching)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
-// SendNotification indicates an expected call of SendNotification
-func (mr *MockemailSenderMockRecorder) SendNotification(profileName, removed, added, currentStocks, email interface{}) *gomock.Call {
+// UpdateMatching indicates an expected call of UpdateMatching
+func (mr *MockalertRuleProviderMockRecorder) UpdateMatching(id, matching interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateMatching", reflect.TypeOf((*MockalertRuleProvider)(nil).UpdateMatching), id, matching)
+}
+
+// MockpriceAlertProvider is a mock of priceAlertProvider interface
+type MockpriceAlertProvider struct {
+	ctrl     *gomock.Controller
+	recorder *MockpriceAlertProviderMockRecorder
+}
+
+// MockpriceAlertProviderMockRecorder is the mock recorder for MockpriceAlertProvider
+type MockpriceAlertProviderMockRecorder struct {
+	mock *MockpriceAlertProvider
+}
+
+// NewMockpriceAlertProvider creates a new mock instance
+func NewMockpriceAlertProvider(ctrl *gomock.Controller) *MockpriceAlertProvider {
+	mock := &MockpriceAlertProvider{ctrl: ctrl}
+	mock.recorder = &MockpriceAlertProviderMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use
+func (m *MockpriceAlertProvider) EXPECT() *MockpriceAlertProviderMockRecorder {
+	return m.recorder
+}
+
+// GetActive mocks base method
+func (m *MockpriceAlertProvider) GetActive() ([]model0.PriceAlert, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetActive")
+	ret0, _ := ret[0].([]model0.PriceAlert)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetActive indicates an expected call of GetActive
+func (mr *MockpriceAlertProviderMockRecorder) GetActive() *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SendNotification", reflect.TypeOf((*MockemailSender)(nil).SendNotification), profileName, removed, added, currentStocks, email)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetActive", reflect.TypeOf((*MockpriceAlertProvider)(nil).GetActive))
+}
+
+// UpdateTriggered mocks base method
+func (m *MockpriceAlertProvider) UpdateTriggered(id primitive.ObjectID, triggered bool) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateTriggered", id, triggered)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateTriggered indicates an expected call of UpdateTriggered
+func (mr *MockpriceAlertProviderMockRecorder) UpdateTriggered(id, triggered interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateTriggered", reflect.TypeOf((*MockpriceAlertProvider)(nil).UpdateTriggered), id, triggered)
+}
+
+// RecordFiring mocks base method
+func (m *MockpriceAlertProvider) RecordFiring(id primitive.ObjectID, firing model0.PriceAlertFiring, active bool) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RecordFiring", id, firing, active)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RecordFiring indicates an expected call of RecordFiring
+func (mr *MockpriceAlertProviderMockRecorder) RecordFiring(id, firing, active interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RecordFiring", reflect.TypeOf((*MockpriceAlertProvider)(nil).RecordFiring), id, firing, active)
+}
+
+// MocknotificationSender is a mock of notificationSender interface
+type MocknotificationSender struct {
+	ctrl     *gomock.Controller
+	recorder *MocknotificationSenderMockRecorder
+}
+
+// MocknotificationSenderMockRecorder is the mock recorder for MocknotificationSender
+type MocknotificationSenderMockRecorder struct {
+	mock *MocknotificationSender
+}
+
+// NewMocknotificationSender creates a new mock instance
+func NewMocknotificationSender(ctrl *gomock.Controller) *MocknotificationSender {
+	mock := &MocknotificationSender{ctrl: ctrl}
+	mock.recorder = &MocknotificationSenderMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use
+func (m *MocknotificationSender) EXPECT() *MocknotificationSenderMockRecorder {
+	return m.recorder
+}
+
+// Notify mocks base method
+func (m *MocknotificationSender) Notify(watchlist *model0.Watchlist, email string, notification *model0.Notification) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Notify", watchlist, email, notification)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Notify indicates an expected call of Notify
+func (mr *MocknotificationSenderMockRecorder) Notify(watchlist, email, notification interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Notify", reflect.TypeOf((*MocknotificationSender)(nil).Notify), watchlist, email, notification)
 }
 
 // MockstockGetter is a mock of stockGetter interface
@@ -164,18 +341,32 @@ func (m *MockstockGetter) EXPECT() *MockstockGetterMockRecorder {
 }
 
 // Get mocks base method
-func (m *MockstockGetter) Get(symbol string) (model0.StockData, error) {
+func (m *MockstockGetter) Get(ctx context.Context, symbol string) (model0.StockData, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Get", symbol)
+	ret := m.ctrl.Call(m, "Get", ctx, symbol)
 	ret0, _ := ret[0].(model0.StockData)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // Get indicates an expected call of Get
-func (mr *MockstockGetterMockRecorder) Get(symbol interface{}) *gomock.Call {
+func (mr *MockstockGetterMockRecorder) Get(ctx, symbol interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Get", reflect.TypeOf((*MockstockGetter)(nil).Get), symbol)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Get", reflect.TypeOf((*MockstockGetter)(nil).Get), ctx, symbol)
+}
+
+// RefreshSP500DivYield mocks base method
+func (m *MockstockGetter) RefreshSP500DivYield(ctx context.Context) float64 {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RefreshSP500DivYield", ctx)
+	ret0, _ := ret[0].(float64)
+	return ret0
+}
+
+// RefreshSP500DivYield indicates an expected call of RefreshSP500DivYield
+func (mr *MockstockGetterMockRecorder) RefreshSP500DivYield(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RefreshSP500DivYield", reflect.TypeOf((*MockstockGetter)(nil).RefreshSP500DivYield), ctx)
 }
 
 // MockstockRecommendator is a mock of stockRecommendator interface
@@ -201,18 +392,56 @@ func (m *MockstockRecommendator) EXPECT() *MockstockRecommendatorMockRecorder {
 	return m.recorder
 }
 
-// GetAllRecommendedStock mocks base method
-func (m *MockstockRecommendator) GetAllRecommendedStock(stocks []model0.StockData, numReqs int, userprofile *model.Userprofile) []model0.CalculatedStockInfo {
+// Calculate mocks base method
+func (m *MockstockRecommendator) Calculate(stockInfo *model0.StockData, expectedRaise, expectedReturn float64) model0.CalculatedStockInfo {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetAllRecommendedStock", stocks, numReqs, userprofile)
-	ret0, _ := ret[0].([]model0.CalculatedStockInfo)
+	ret := m.ctrl.Call(m, "Calculate", stockInfo, expectedRaise, expectedReturn)
+	ret0, _ := ret[0].(model0.CalculatedStockInfo)
 	return ret0
 }
 
-// GetAllRecommendedStock indicates an expected call of GetAllRecommendedStock
-func (mr *MockstockRecommendatorMockRecorder) GetAllRecommendedStock(stocks, numReqs, userprofile interface{}) *gomock.Call {
+// Calculate indicates an expected call of Calculate
+func (mr *MockstockRecommendatorMockRecorder) Calculate(stockInfo, expectedRaise, expectedReturn interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAllRecommendedStock", reflect.TypeOf((*MockstockRecommendator)(nil).GetAllRecommendedStock), stocks, numReqs, userprofile)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Calculate", reflect.TypeOf((*MockstockRecommendator)(nil).Calculate), stockInfo, expectedRaise, expectedReturn)
+}
+
+// MockpreferencesGetter is a mock of preferencesGetter interface
+type MockpreferencesGetter struct {
+	ctrl     *gomock.Controller
+	recorder *MockpreferencesGetterMockRecorder
+}
+
+// MockpreferencesGetterMockRecorder is the mock recorder for MockpreferencesGetter
+type MockpreferencesGetterMockRecorder struct {
+	mock *MockpreferencesGetter
+}
+
+// NewMockpreferencesGetter creates a new mock instance
+func NewMockpreferencesGetter(ctrl *gomock.Controller) *MockpreferencesGetter {
+	mock := &MockpreferencesGetter{ctrl: ctrl}
+	mock.recorder = &MockpreferencesGetterMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use
+func (m *MockpreferencesGetter) EXPECT() *MockpreferencesGetterMockRecorder {
+	return m.recorder
+}
+
+// Get mocks base method
+func (m *MockpreferencesGetter) Get(userID string) (model0.NotificationPreferences, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Get", userID)
+	ret0, _ := ret[0].(model0.NotificationPreferences)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Get indicates an expected call of Get
+func (mr *MockpreferencesGetterMockRecorder) Get(userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Get", reflect.TypeOf((*MockpreferencesGetter)(nil).Get), userID)
 }
 
 // MockuserprofileGetter is a mock of userprofileGetter interface
@@ -238,17 +467,18 @@ func (m *MockuserprofileGetter) EXPECT() *MockuserprofileGetterMockRecorder {
 	return m.recorder
 }
 
-// GetUserprofile mocks base method
-func (m *MockuserprofileGetter) GetUserprofile(userId string) (model.Userprofile, error) {
+// GetLocalizedUserprofile mocks base method
+func (m *MockuserprofileGetter) GetLocalizedUserprofile(ctx context.Context, userId string) (model.Userprofile, string, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetUserprofile", userId)
+	ret := m.ctrl.Call(m, "GetLocalizedUserprofile", ctx, userId)
 	ret0, _ := ret[0].(model.Userprofile)
-	ret1, _ := ret[1].(error)
-	return ret0, ret1
+	ret1, _ := ret[1].(string)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
 }
 
-// GetUserprofile indicates an expected call of GetUserprofile
-func (mr *MockuserprofileGetterMockRecorder) GetUserprofile(userId interface{}) *gomock.Call {
+// GetLocalizedUserprofile indicates an expected call of GetLocalizedUserprofile
+func (mr *MockuserprofileGetterMockRecorder) GetLocalizedUserprofile(ctx, userId interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserprofile", reflect.TypeOf((*MockuserprofileGetter)(nil).GetUserprofile), userId)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetLocalizedUserprofile", reflect.TypeOf((*MockuserprofileGetter)(nil).GetLocalizedUserprofile), ctx, userId)
 }
@@ -0,0 +1,124 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: service/snapshot.go
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	gomock "github.com/golang/mock/gomock"
+	model "github.com/nagymarci/stock-watchlist/model"
+	reflect "reflect"
+)
+
+// MocksnapshotSaver is a mock of snapshotSaver interface
+type MocksnapshotSaver struct {
+	ctrl     *gomock.Controller
+	recorder *MocksnapshotSaverMockRecorder
+}
+
+// MocksnapshotSaverMockRecorder is the mock recorder for MocksnapshotSaver
+type MocksnapshotSaverMockRecorder struct {
+	mock *MocksnapshotSaver
+}
+
+// NewMocksnapshotSaver creates a new mock instance
+func NewMocksnapshotSaver(ctrl *gomock.Controller) *MocksnapshotSaver {
+	mock := &MocksnapshotSaver{ctrl: ctrl}
+	mock.recorder = &MocksnapshotSaverMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use
+func (m *MocksnapshotSaver) EXPECT() *MocksnapshotSaverMockRecorder {
+	return m.recorder
+}
+
+// Save mocks base method
+func (m *MocksnapshotSaver) Save(snapshot model.StockSnapshot) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Save", snapshot)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Save indicates an expected call of Save
+func (mr *MocksnapshotSaverMockRecorder) Save(snapshot interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Save", reflect.TypeOf((*MocksnapshotSaver)(nil).Save), snapshot)
+}
+
+// MockstockLister is a mock of stockLister interface
+type MockstockLister struct {
+	ctrl     *gomock.Controller
+	recorder *MockstockListerMockRecorder
+}
+
+// MockstockListerMockRecorder is the mock recorder for MockstockLister
+type MockstockListerMockRecorder struct {
+	mock *MockstockLister
+}
+
+// NewMockstockLister creates a new mock instance
+func NewMockstockLister(ctrl *gomock.Controller) *MockstockLister {
+	mock := &MockstockLister{ctrl: ctrl}
+	mock.recorder = &MockstockListerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use
+func (m *MockstockLister) EXPECT() *MockstockListerMockRecorder {
+	return m.recorder
+}
+
+// GetAll mocks base method
+func (m *MockstockLister) GetAll(ctx context.Context) ([]model.StockData, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetAll", ctx)
+	ret0, _ := ret[0].([]model.StockData)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetAll indicates an expected call of GetAll
+func (mr *MockstockListerMockRecorder) GetAll(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAll", reflect.TypeOf((*MockstockLister)(nil).GetAll), ctx)
+}
+
+// MockstockCalculator is a mock of stockCalculator interface
+type MockstockCalculator struct {
+	ctrl     *gomock.Controller
+	recorder *MockstockCalculatorMockRecorder
+}
+
+// MockstockCalculatorMockRecorder is the mock recorder for MockstockCalculator
+type MockstockCalculatorMockRecorder struct {
+	mock *MockstockCalculator
+}
+
+// NewMockstockCalculator creates a new mock instance
+func NewMockstockCalculator(ctrl *gomock.Controller) *MockstockCalculator {
+	mock := &MockstockCalculator{ctrl: ctrl}
+	mock.recorder = &MockstockCalculatorMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use
+func (m *MockstockCalculator) EXPECT() *MockstockCalculatorMockRecorder {
+	return m.recorder
+}
+
+// Calculate mocks base method
+func (m *MockstockCalculator) Calculate(stockInfo *model.StockData, expectedRaise, expectedReturn float64) model.CalculatedStockInfo {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Calculate", stockInfo, expectedRaise, expectedReturn)
+	ret0, _ := ret[0].(model.CalculatedStockInfo)
+	return ret0
+}
+
+// Calculate indicates an expected call of Calculate
+func (mr *MockstockCalculatorMockRecorder) Calculate(stockInfo, expectedRaise, expectedReturn interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Calculate", reflect.TypeOf((*MockstockCalculator)(nil).Calculate), stockInfo, expectedRaise, expectedReturn)
+}
@@ -2,6 +2,14 @@ package service
 
 //go:generate $GOPATH/bin/mockgen -source=notifier.go -destination=mocks/mock_notifier-deps.go -package=mocks
 import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+	"sync/atomic"
+	"time"
+
 	"github.com/sirupsen/logrus"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 
@@ -12,12 +20,25 @@ import (
 type Notifier struct {
 	recommendations   recommendationProvider
 	watchlists        watchlistList
+	alertRules        alertRuleProvider
 	stockClient       stockGetter
 	stockService      stockRecommendator
 	userprofileClient userprofileGetter
-	emailClient       emailSender
+	channels          notificationSender
+	preferences       preferencesGetter
+	priceAlerts       priceAlertProvider
+	workers           int
+	runTimeout        time.Duration
+	now               func() time.Time
+
+	running int32
+	mu      sync.Mutex
+	lastRun *model.NotifierRunStats
 }
 
+//ErrNotifierRunning is returned when a run is started while the previous one has not finished yet
+var ErrNotifierRunning = errors.New("Notifier run is already in progress")
+
 type watchlistList interface {
 	List() ([]model.Watchlist, error)
 }
@@ -25,85 +46,598 @@ type watchlistList interface {
 type recommendationProvider interface {
 	Get(id primitive.ObjectID) ([]string, error)
 	Update(log *logrus.Entry, id primitive.ObjectID, stocks []string) error
+	GetSignals(id primitive.ObjectID) (map[string]model.SignalState, error)
+	UpdateSignals(id primitive.ObjectID, signals map[string]model.SignalState) error
+	GetPending(id primitive.ObjectID) (map[string]model.PendingChange, error)
+	UpdatePending(id primitive.ObjectID, pending map[string]model.PendingChange) error
+}
+
+type alertRuleProvider interface {
+	GetByWatchlist(watchlistID primitive.ObjectID) ([]model.AlertRule, error)
+	UpdateMatching(id primitive.ObjectID, matching []string) error
 }
 
-type emailSender interface {
-	SendNotification(profileName string, removed, added, currentStocks []string, email string) error
+type priceAlertProvider interface {
+	GetActive() ([]model.PriceAlert, error)
+	UpdateTriggered(id primitive.ObjectID, triggered bool) error
+	RecordFiring(id primitive.ObjectID, firing model.PriceAlertFiring, active bool) error
+}
+
+type notificationSender interface {
+	Notify(watchlist *model.Watchlist, email string, notification *model.Notification) error
 }
 
 type stockGetter interface {
-	Get(symbol string) (model.StockData, error)
+	Get(ctx context.Context, symbol string) (model.StockData, error)
+	RefreshSP500DivYield(ctx context.Context) float64
 }
 
 type stockRecommendator interface {
-	GetAllRecommendedStock(stocks []model.StockData, numReqs int, userprofile *userprofileModel.Userprofile) []model.CalculatedStockInfo
+	Calculate(stockInfo *model.StockData, expectedRaise float64, expectedReturn float64) model.CalculatedStockInfo
+}
+
+type preferencesGetter interface {
+	Get(userID string) (model.NotificationPreferences, error)
 }
 
 type userprofileGetter interface {
-	GetUserprofile(userId string) (userprofileModel.Userprofile, error)
+	GetLocalizedUserprofile(ctx context.Context, userId string) (userprofileModel.Userprofile, string, error)
 }
 
-func NewNotifier(r recommendationProvider, w watchlistList, sc stockGetter, ss stockRecommendator, uc userprofileGetter, ec emailSender) *Notifier {
+func NewNotifier(r recommendationProvider, w watchlistList, a alertRuleProvider, sc stockGetter, ss stockRecommendator, uc userprofileGetter, ns notificationSender, p preferencesGetter, pa priceAlertProvider) *Notifier {
 	return &Notifier{
 		recommendations:   r,
 		watchlists:        w,
+		alertRules:        a,
 		stockClient:       sc,
 		stockService:      ss,
 		userprofileClient: uc,
-		emailClient:       ec,
+		channels:          ns,
+		preferences:       p,
+		priceAlerts:       pa,
+		workers:           defaultNotifierWorkers,
+		runTimeout:        defaultNotifierRunTimeout,
+		now:               time.Now,
 	}
 }
 
 func (n *Notifier) NotifyChanges() {
+	if _, err := n.RunNow(); err != nil {
+		logrus.Warnln(err)
+	}
+}
+
+//RunNow runs the notifier for every watchlist and price alert, unless a run is already in progress on this instance
+func (n *Notifier) RunNow() (model.NotifierRunStats, error) {
+	return n.guarded(n.Run)
+}
+
+//RunDue runs the notifier for the watchlists due to be checked, and the price alerts if requested
+func (n *Notifier) RunDue(watchlists []model.Watchlist, priceAlerts bool) (model.NotifierRunStats, error) {
+	return n.guarded(func() model.NotifierRunStats {
+		return n.RunWatchlists(watchlists, priceAlerts)
+	})
+}
+
+//guarded makes sure only one run is in progress on this instance, and keeps the statistics of the runs
+func (n *Notifier) guarded(run func() model.NotifierRunStats) (model.NotifierRunStats, error) {
+	if !atomic.CompareAndSwapInt32(&n.running, 0, 1) {
+		return model.NotifierRunStats{}, ErrNotifierRunning
+	}
+	defer atomic.StoreInt32(&n.running, 0)
+
+	stats := run()
+
+	n.mu.Lock()
+	n.lastRun = &stats
+	n.mu.Unlock()
+
+	logrus.WithFields(logrus.Fields{
+		"durationMs":           stats.DurationMs,
+		"watchlists":           stats.Watchlists,
+		"symbols":              stats.Symbols,
+		"users":                stats.Users,
+		"fetchFailures":        stats.FetchFailures,
+		"notificationsSent":    stats.NotificationsSent,
+		"notificationFailures": stats.NotificationFailures,
+	}).Infoln("Notifier run finished")
+
+	return stats, nil
+}
+
+//Preview evaluates the watchlist the same way as a run, and returns the notifications it would send,
+//without sending them or updating the recommendations and the alert rules
+func (n *Notifier) Preview(watchlist *model.Watchlist) (model.NotificationPreview, error) {
+	preview := model.NotificationPreview{WatchlistID: watchlist.ID}
+
+	ctx, cancel := context.WithTimeout(context.Background(), n.runTimeout)
+	defer cancel()
+
+	run := newNotifierRun()
+	run.dryRun = true
+
+	n.fetchPreferences(run, []string{watchlist.UserID})
+
+	preferences, ok := run.preferences[watchlist.UserID]
+
+	if !ok {
+		return preview, fmt.Errorf("Failed to get notification preferences of [%s]", watchlist.UserID)
+	}
+
+	n.fetchStocksAndUserprofiles(ctx, run, distinct(watchlist.Stocks), []string{watchlist.UserID})
+
+	if _, ok := run.userprofiles[watchlist.UserID]; !ok {
+		return preview, fmt.Errorf("Failed to get userprofile of [%s]", watchlist.UserID)
+	}
+
+	n.notifyWatchlist(watchlist, run)
+
+	now := n.now()
+	preview.Enabled = NotificationsEnabled(&preferences, watchlist.ID, now) && !n.quiet(&preferences, now)
+
+	for _, notification := range run.notifications {
+		preview.Notifications = append(preview.Notifications, model.PreviewedNotification{Notification: notification})
+	}
+
+	return preview, nil
+}
+
+//LastRun returns the statistics of the last scheduled run on this instance, nil if it has not run yet
+func (n *Notifier) LastRun() *model.NotifierRunStats {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	return n.lastRun
+}
+
+//Run checks every watchlist and price alert, the upstream calls of the run share a deadline of runTimeout
+func (n *Notifier) Run() model.NotifierRunStats {
+	ctx, cancel := context.WithTimeout(context.Background(), n.runTimeout)
+	defer cancel()
+
 	watchlists, err := n.watchlists.List()
 
 	if err != nil {
 		logrus.Errorf("Failed to get watchlists [%v]", err)
-		return
+		return model.NotifierRunStats{StartedAt: n.now().UTC()}
+	}
+
+	return n.runWatchlists(ctx, watchlists, true)
+}
+
+//RunWatchlists checks the watchlists, and every price alert if priceAlerts is set. The preferences, the stocks
+//and the userprofiles are fetched once per run, then the watchlists and the price alerts of the users are evaluated in parallel
+func (n *Notifier) RunWatchlists(watchlists []model.Watchlist, priceAlerts bool) model.NotifierRunStats {
+	ctx, cancel := context.WithTimeout(context.Background(), n.runTimeout)
+	defer cancel()
+
+	return n.runWatchlists(ctx, watchlists, priceAlerts)
+}
+
+func (n *Notifier) runWatchlists(ctx context.Context, watchlists []model.Watchlist, priceAlerts bool) model.NotifierRunStats {
+	stats := model.NotifierRunStats{StartedAt: n.now().UTC()}
+	started := time.Now()
+
+	var alerts []model.PriceAlert
+	var err error
+
+	if priceAlerts {
+		alerts, err = n.priceAlerts.GetActive()
+
+		if err != nil {
+			logrus.Errorf("Failed to get price alerts [%v]", err)
+		}
+	}
+
+	alertsByUser := make(map[string][]model.PriceAlert)
+	var users, alertOwners []string
+
+	for _, watchlist := range watchlists {
+		users = append(users, watchlist.UserID)
+	}
+
+	for _, alert := range alerts {
+		users = append(users, alert.UserID)
+		alertOwners = append(alertOwners, alert.UserID)
+		alertsByUser[alert.UserID] = append(alertsByUser[alert.UserID], alert)
 	}
 
+	run := newNotifierRun()
+	n.fetchPreferences(run, distinct(users))
+
+	now := n.now()
+
+	//only the watchlists and the price alerts notified about in this run need stocks and userprofiles
+	var active []model.Watchlist
+	var alertUsers, symbols []string
+	users = nil
+
 	for _, watchlist := range watchlists {
-		log := logrus.WithField("watchlistId", watchlist.ID)
-		previouStocks, _ := n.recommendations.Get(watchlist.ID)
+		preferences, ok := run.preferences[watchlist.UserID]
+
+		if !ok || !NotificationsEnabled(&preferences, watchlist.ID, now) || n.quiet(&preferences, now) {
+			continue
+		}
+
+		active = append(active, watchlist)
+		users = append(users, watchlist.UserID)
+		symbols = append(symbols, watchlist.Stocks...)
+	}
+
+	for _, userID := range distinct(alertOwners) {
+		preferences, ok := run.preferences[userID]
+
+		if !ok || preferences.Disabled || n.quiet(&preferences, now) {
+			continue
+		}
+
+		alertUsers = append(alertUsers, userID)
+		users = append(users, userID)
+
+		for _, alert := range alertsByUser[userID] {
+			symbols = append(symbols, alert.Symbol)
+		}
+	}
 
-		var stockInfos []model.StockData
+	symbols = distinct(symbols)
+	users = distinct(users)
+
+	n.fetchStocksAndUserprofiles(ctx, run, symbols, users)
+
+	parallel(n.workers, len(active)+len(alertUsers), func(i int) {
+		if i < len(active) {
+			n.notifyWatchlist(&active[i], run)
+			return
+		}
+
+		userID := alertUsers[i-len(active)]
+		n.notifyPriceAlertsOfUser(userID, alertsByUser[userID], run)
+	})
+
+	stats.DurationMs = time.Since(started).Milliseconds()
+	stats.Watchlists = len(active)
+	stats.Symbols = len(symbols)
+	stats.Users = len(users)
+	stats.FetchFailures = run.fetchFailures
+	stats.NotificationsSent = atomic.LoadInt64(&run.sent)
+	stats.NotificationFailures = atomic.LoadInt64(&run.failed)
+
+	return stats
+}
+
+//quiet returns whether immediate notifications are held back because of the quiet hours,
+//the changes are kept until the quiet hours end
+func (n *Notifier) quiet(preferences *model.NotificationPreferences, now time.Time) bool {
+	return preferences.Delivery == model.DeliveryImmediate && InQuietHours(preferences, now)
+}
+
+func (n *Notifier) notifyWatchlist(watchlist *model.Watchlist, run *notifierRun) {
+	log := logrus.WithField("watchlistId", watchlist.ID)
+
+	preferences := run.preferences[watchlist.UserID]
+
+	userprofile, ok := run.userprofiles[watchlist.UserID]
+
+	if !ok {
+		return
+	}
 
-		for _, symbol := range watchlist.Stocks {
-			result, err := n.stockClient.Get(symbol)
+	var stockInfos []model.StockData
+
+	for _, symbol := range watchlist.Stocks {
+		result, ok := run.stocks[symbol]
+
+		if !ok {
+			continue
+		}
+
+		stockInfos = append(stockInfos, result)
+	}
+
+	calculated := make([]model.CalculatedStockInfo, len(stockInfos))
+	for i := range stockInfos {
+		calculated[i] = n.stockService.Calculate(&stockInfos[i], userprofile.GetExpectation(stockInfos[i].Ticker), *userprofile.ExpectedReturn)
+	}
+
+	n.notifyRecommendations(log, watchlist, calculated, &userprofile, &preferences, run)
+	n.notifyAlerts(log, watchlist, stockInfos, calculated, &userprofile, &preferences, run)
+
+	if len(watchlist.Subscriptions) > 0 {
+		n.notifySignals(log, watchlist, calculated, &userprofile, &preferences, run)
+	}
+}
+
+//send delivers the notification in the locale of the user without the muted symbols of the watchlist,
+//through the preferred channels of the user if none is chosen for the watchlist
+func (n *Notifier) send(watchlist *model.Watchlist, email string, notification *model.Notification, preferences *model.NotificationPreferences, run *notifierRun) error {
+	if !FilterMuted(notification, WatchlistPreferencesOf(preferences, watchlist.ID).MutedSymbols) {
+		return nil
+	}
+
+	notification.Locale = NotificationLocale(preferences, run.locales[watchlist.UserID])
+
+	if run.dryRun {
+		run.collect(notification)
+		return nil
+	}
+
+	target := *watchlist
+	if len(target.Channels) == 0 {
+		target.Channels = preferences.Channels
+	}
+
+	err := n.channels.Notify(&target, email, notification)
+
+	run.delivered(err)
+
+	return err
+}
+
+//notifyRecommendations notifies about the stocks entering and leaving the recommended stocks of the watchlist.
+//With hysteresis, the stocks waiting for the dwell time are kept once the changes are delivered or if there are none
+func (n *Notifier) notifyRecommendations(log *logrus.Entry, watchlist *model.Watchlist, calculated []model.CalculatedStockInfo, userprofile *userprofileModel.Userprofile, preferences *model.NotificationPreferences, run *notifierRun) {
+	previouStocks, _ := n.recommendations.Get(watchlist.ID)
+
+	rule := NotificationRuleOf(watchlist)
+
+	var currentStocks []string
+	var pending, nextPending map[string]model.PendingChange
+
+	if rule.Hysteresis == nil {
+		currentStocks = tickers(FilterByRule(calculated, &rule))
+	} else {
+		if rule.Hysteresis.DwellMinutes > 0 {
+			var err error
+			if pending, err = n.recommendations.GetPending(watchlist.ID); err != nil {
+				log.Errorln("Failed to get pending changes ", err)
+			}
+		}
+
+		currentStocks, nextPending = ApplyHysteresis(watchlist.Stocks, previouStocks, calculated, &rule, pending, n.now())
+	}
+
+	removed, added := getChanges(previouStocks, currentStocks)
+
+	if len(removed) == 0 && len(added) == 0 {
+		n.updatePending(log, watchlist, pending, nextPending, run)
+		return
+	}
+
+	notification := model.Notification{
+		Kind:          model.NotificationChange,
+		WatchlistID:   watchlist.ID,
+		WatchlistName: watchlist.Name,
+		Removed:       removed,
+		Added:         added,
+		Current:       currentStocks,
+		Stocks:        stockDetails(calculated, removed, added, currentStocks),
+	}
+
+	err := n.send(watchlist, userprofile.Email, &notification, preferences, run)
+
+	if err != nil {
+		log.Errorln("Failed to send notification ", err)
+		return
+	}
+
+	if !run.dryRun {
+		n.recommendations.Update(log, watchlist.ID, currentStocks)
+		n.updatePending(log, watchlist, pending, nextPending, run)
+	}
+}
+
+//updatePending stores the stocks waiting for the dwell time if they changed
+func (n *Notifier) updatePending(log *logrus.Entry, watchlist *model.Watchlist, pending map[string]model.PendingChange, nextPending map[string]model.PendingChange, run *notifierRun) {
+	if run.dryRun || watchlist.Rule == nil || watchlist.Rule.Hysteresis == nil || watchlist.Rule.Hysteresis.DwellMinutes == 0 {
+		return
+	}
+
+	if len(pending) == 0 && len(nextPending) == 0 || reflect.DeepEqual(pending, nextPending) {
+		return
+	}
+
+	if err := n.recommendations.UpdatePending(watchlist.ID, nextPending); err != nil {
+		log.Errorln("Failed to update pending changes ", err)
+	}
+}
+
+//notifyAlerts evaluates the alert rules of the watchlist, and notifies about
+//the stocks that started or stopped matching them
+func (n *Notifier) notifyAlerts(log *logrus.Entry, watchlist *model.Watchlist, stockInfos []model.StockData, calculated []model.CalculatedStockInfo, userprofile *userprofileModel.Userprofile, preferences *model.NotificationPreferences, run *notifierRun) {
+	rules, err := n.alertRules.GetByWatchlist(watchlist.ID)
+
+	if err != nil {
+		log.Errorln("Failed to get alert rules ", err)
+		return
+	}
+
+	if len(rules) == 0 {
+		return
+	}
+
+	fetched := tickers(calculated)
+
+	for _, rule := range rules {
+		ruleLog := log.WithField("alertRuleId", rule.ID)
+
+		expression, err := CompileAlert(rule.Expression)
+
+		if err != nil {
+			ruleLog.Warnf("Failed to compile alert rule [%v]", err)
+			continue
+		}
+
+		var matching []string
+
+		for i := range stockInfos {
+			ok, err := expression.Eval(alertEnv(&stockInfos[i], &calculated[i]))
 
 			if err != nil {
-				log.Warnf("Failed to get stock [%s]: [%v]\n", symbol, err)
+				ruleLog.Debugf("Failed to evaluate alert rule for [%s]: [%v]", stockInfos[i].Ticker, err)
 				continue
 			}
 
-			stockInfos = append(stockInfos, result)
+			if ok {
+				matching = append(matching, stockInfos[i].Ticker)
+			}
+		}
+
+		//stocks that could not be fetched keep their previous state
+		for _, symbol := range rule.Matching {
+			if !contains(fetched, symbol) {
+				matching = append(matching, symbol)
+			}
+		}
+
+		stopped, started := getChanges(rule.Matching, matching)
+
+		if len(stopped) == 0 && len(started) == 0 {
+			continue
+		}
+
+		notification := model.Notification{
+			Kind:          model.NotificationAlert,
+			WatchlistID:   watchlist.ID,
+			WatchlistName: watchlist.Name,
+			AlertName:     rule.Name,
+			Removed:       stopped,
+			Added:         started,
+			Current:       matching,
+			Stocks:        stockDetails(calculated, stopped, started, matching),
 		}
 
-		userprofile, err := n.userprofileClient.GetUserprofile(watchlist.UserID)
+		err = n.send(watchlist, userprofile.Email, &notification, preferences, run)
 
 		if err != nil {
-			log.Errorln("Failed to get userprofile to notification ", err)
+			ruleLog.Errorln("Failed to send alert notification ", err)
 			continue
 		}
 
-		calculatedStockData := n.stockService.GetAllRecommendedStock(stockInfos, 2, &userprofile)
+		if run.dryRun {
+			continue
+		}
 
-		currentStocks := filterGreenPrices(calculatedStockData)
+		err = n.alertRules.UpdateMatching(rule.ID, matching)
 
-		removed, added := getChanges(previouStocks, currentStocks)
+		if err != nil {
+			ruleLog.Errorln("Failed to update alert rule ", err)
+		}
+	}
+}
 
-		if len(removed) == 0 && len(added) == 0 {
+//notifySignals notifies about the signal transitions the watchlist is subscribed to, and keeps the signals
+//of its stocks. The signals are only tracked for watchlists with subscriptions
+func (n *Notifier) notifySignals(log *logrus.Entry, watchlist *model.Watchlist, calculated []model.CalculatedStockInfo, userprofile *userprofileModel.Userprofile, preferences *model.NotificationPreferences, run *notifierRun) {
+	previous, err := n.recommendations.GetSignals(watchlist.ID)
+
+	if err != nil {
+		log.Errorln("Failed to get signals ", err)
+		return
+	}
+
+	//stocks that could not be fetched keep their previous state
+	current := make(map[string]model.SignalState)
+	for symbol, state := range previous {
+		if contains(watchlist.Stocks, symbol) {
+			current[symbol] = state
+		}
+	}
+	for i := range calculated {
+		current[calculated[i].Ticker] = SignalStateOf(&calculated[i])
+	}
+
+	transitions := SubscribedTransitions(SignalTransitions(previous, calculated), watchlist.Subscriptions)
+
+	if len(transitions) > 0 {
+		symbols := transitionSymbols(transitions)
+
+		notification := model.Notification{
+			Kind:          model.NotificationSignal,
+			WatchlistID:   watchlist.ID,
+			WatchlistName: watchlist.Name,
+			Added:         symbols,
+			Current:       symbols,
+			Stocks:        stockDetails(calculated, symbols),
+			Transitions:   transitions,
+		}
+
+		err = n.send(watchlist, userprofile.Email, &notification, preferences, run)
+
+		if err != nil {
+			log.Errorln("Failed to send signal notification ", err)
+			return
+		}
+	}
+
+	if run.dryRun || reflect.DeepEqual(previous, current) {
+		return
+	}
+
+	if err := n.recommendations.UpdateSignals(watchlist.ID, current); err != nil {
+		log.Errorln("Failed to update signals ", err)
+	}
+}
+
+//notifyPriceAlertsOfUser notifies the user about the price alerts whose condition started to hold
+func (n *Notifier) notifyPriceAlertsOfUser(userID string, alerts []model.PriceAlert, run *notifierRun) {
+	log := logrus.WithField("userId", userID)
+
+	preferences := run.preferences[userID]
+
+	userprofile, ok := run.userprofiles[userID]
+
+	if !ok {
+		return
+	}
+
+	now := n.now()
+
+	for _, alert := range alerts {
+		alertLog := log.WithField("priceAlertId", alert.ID)
+
+		stock, ok := run.stocks[alert.Symbol]
+
+		if !ok {
 			continue
 		}
 
-		err = n.emailClient.SendNotification(watchlist.Name, removed, added, currentStocks, userprofile.Email)
+		calc := n.stockService.Calculate(&stock, userprofile.GetExpectation(stock.Ticker), *userprofile.ExpectedReturn)
+
+		holds := PriceAlertHolds(&alert, &calc)
+
+		if !holds {
+			if alert.Triggered {
+				if err := n.priceAlerts.UpdateTriggered(alert.ID, false); err != nil {
+					alertLog.Errorln("Failed to rearm price alert ", err)
+				}
+			}
+			continue
+		}
+
+		if alert.Triggered {
+			continue
+		}
+
+		notification := model.Notification{
+			Kind:      model.NotificationPrice,
+			AlertName: PriceAlertName(&alert),
+			Added:     []string{alert.Symbol},
+			Current:   []string{alert.Symbol},
+			Stocks:    []model.CalculatedStockInfo{calc},
+		}
+
+		err := n.send(&model.Watchlist{UserID: userID}, userprofile.Email, &notification, &preferences, run)
 
 		if err != nil {
-			log.Errorln("Failed to send notification ", err)
+			alertLog.Errorln("Failed to send price alert notification ", err)
 			continue
 		}
 
-		n.recommendations.Update(log, watchlist.ID, currentStocks)
+		firing := model.PriceAlertFiring{At: now.UTC(), Price: calc.Price, DividendYield: calc.DividendYield}
+
+		if err := n.priceAlerts.RecordFiring(alert.ID, firing, alert.Recurring); err != nil {
+			alertLog.Errorln("Failed to record price alert firing ", err)
+		}
 	}
 }
 
@@ -120,6 +654,31 @@ func filterGreenPrices(stockInfos []model.CalculatedStockInfo) []string {
 	return result
 }
 
+func tickers(stockInfos []model.CalculatedStockInfo) []string {
+	var result []string
+
+	for _, calc := range stockInfos {
+		result = append(result, calc.Ticker)
+	}
+	return result
+}
+
+//stockDetails returns the calculated information of the stocks in any of the symbol lists
+func stockDetails(calculated []model.CalculatedStockInfo, symbols ...[]string) []model.CalculatedStockInfo {
+	var result []model.CalculatedStockInfo
+
+	for _, calc := range calculated {
+		for _, s := range symbols {
+			if contains(s, calc.Ticker) {
+				result = append(result, calc)
+				break
+			}
+		}
+	}
+
+	return result
+}
+
 func getChanges(old, new []string) ([]string, []string) {
 	if len(old) == 0 || len(new) == 0 {
 		return old, new
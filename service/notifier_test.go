@@ -1,7 +1,9 @@
 package service
 
 import (
+	"errors"
 	"testing"
+	"time"
 
 	"github.com/nagymarci/stock-watchlist/model"
 	"go.mongodb.org/mongo-driver/bson/primitive"
@@ -19,12 +21,16 @@ func TestNotification(t *testing.T) {
 
 		recommendations := mocks.NewMockrecommendationProvider(ctrl)
 		watchlists := mocks.NewMockwatchlistList(ctrl)
+		alertRules := mocks.NewMockalertRuleProvider(ctrl)
 		stockClient := mocks.NewMockstockGetter(ctrl)
+		stockClient.EXPECT().RefreshSP500DivYield(gomock.Any()).Return(1.0).AnyTimes()
 		stockService := mocks.NewMockstockRecommendator(ctrl)
 		userprofileClient := mocks.NewMockuserprofileGetter(ctrl)
-		emailClient := mocks.NewMockemailSender(ctrl)
+		channels := mocks.NewMocknotificationSender(ctrl)
+		priceAlerts := mocks.NewMockpriceAlertProvider(ctrl)
+		priceAlerts.EXPECT().GetActive().Return(nil, nil).AnyTimes()
 
-		notifier := NewNotifier(recommendations, watchlists, stockClient, stockService, userprofileClient, emailClient)
+		notifier := NewNotifier(recommendations, watchlists, alertRules, stockClient, stockService, userprofileClient, channels, &mockPreferences{}, priceAlerts)
 
 		watchlistID := primitive.NewObjectID()
 		expectedWatchlist := model.Watchlist{ID: watchlistID, Name: "watchlist", Stocks: []string{"INTC"}, UserID: "userId"}
@@ -44,11 +50,12 @@ func TestNotification(t *testing.T) {
 		userprofile := userprofileModel.Userprofile{Email: "alice@example.com", ExpectedReturn: &expectedReturn, Expectations: []userprofileModel.Expectation{userprofileModel.Expectation{Stock: "INTC", ExpectedRaise: &expectedRaise}}}
 
 		watchlists.EXPECT().List().Return([]model.Watchlist{expectedWatchlist}, nil)
+		alertRules.EXPECT().GetByWatchlist(watchlistID).Return(nil, nil)
 		recommendations.EXPECT().Get(watchlistID).Return([]string{}, nil)
-		stockClient.EXPECT().Get("INTC").Return(stock, nil)
-		userprofileClient.EXPECT().GetUserprofile("userId").Return(userprofile, nil)
-		stockService.EXPECT().GetAllRecommendedStock(gomock.Any(), gomock.Any(), gomock.Any()).Return([]model.CalculatedStockInfo{})
-		emailClient.EXPECT().SendNotification(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+		stockClient.EXPECT().Get(gomock.Any(), "INTC").Return(stock, nil)
+		userprofileClient.EXPECT().GetLocalizedUserprofile(gomock.Any(), "userId").Return(userprofile, "", nil)
+		stockService.EXPECT().Calculate(gomock.Any(), expectedRaise, expectedReturn).Return(model.CalculatedStockInfo{Ticker: "INTC", PriceColor: "red"})
+		channels.EXPECT().Notify(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
 
 		notifier.NotifyChanges()
 	})
@@ -58,12 +65,16 @@ func TestNotification(t *testing.T) {
 
 		recommendations := mocks.NewMockrecommendationProvider(ctrl)
 		watchlists := mocks.NewMockwatchlistList(ctrl)
+		alertRules := mocks.NewMockalertRuleProvider(ctrl)
 		stockClient := mocks.NewMockstockGetter(ctrl)
+		stockClient.EXPECT().RefreshSP500DivYield(gomock.Any()).Return(1.0).AnyTimes()
 		stockService := mocks.NewMockstockRecommendator(ctrl)
 		userprofileClient := mocks.NewMockuserprofileGetter(ctrl)
-		emailClient := mocks.NewMockemailSender(ctrl)
+		channels := mocks.NewMocknotificationSender(ctrl)
+		priceAlerts := mocks.NewMockpriceAlertProvider(ctrl)
+		priceAlerts.EXPECT().GetActive().Return(nil, nil).AnyTimes()
 
-		notifier := NewNotifier(recommendations, watchlists, stockClient, stockService, userprofileClient, emailClient)
+		notifier := NewNotifier(recommendations, watchlists, alertRules, stockClient, stockService, userprofileClient, channels, &mockPreferences{}, priceAlerts)
 
 		watchlistID := primitive.NewObjectID()
 		expectedWatchlist := model.Watchlist{ID: watchlistID, Name: "watchlist", Stocks: []string{"INTC"}, UserID: "userId"}
@@ -82,15 +93,18 @@ func TestNotification(t *testing.T) {
 		expectedRaise := 5.5
 		userprofile := userprofileModel.Userprofile{Email: "alice@example.com", ExpectedReturn: &expectedReturn, Expectations: []userprofileModel.Expectation{userprofileModel.Expectation{Stock: "INTC", ExpectedRaise: &expectedRaise}}}
 
+		calculatedStockInfo := model.CalculatedStockInfo{Ticker: "INTC", PriceColor: "red"}
+
 		var empty []string
 
 		watchlists.EXPECT().List().Return([]model.Watchlist{expectedWatchlist}, nil)
+		alertRules.EXPECT().GetByWatchlist(watchlistID).Return(nil, nil)
 		recommendations.EXPECT().Get(watchlistID).Return([]string{"INTC"}, nil)
 		recommendations.EXPECT().Update(gomock.Any(), watchlistID, empty).Return(nil)
-		stockClient.EXPECT().Get("INTC").Return(stock, nil)
-		userprofileClient.EXPECT().GetUserprofile("userId").Return(userprofile, nil)
-		stockService.EXPECT().GetAllRecommendedStock(gomock.Any(), gomock.Any(), gomock.Any()).Return([]model.CalculatedStockInfo{})
-		emailClient.EXPECT().SendNotification(expectedWatchlist.Name, []string{"INTC"}, empty, empty, userprofile.Email).Times(1)
+		stockClient.EXPECT().Get(gomock.Any(), "INTC").Return(stock, nil)
+		userprofileClient.EXPECT().GetLocalizedUserprofile(gomock.Any(), "userId").Return(userprofile, "", nil)
+		stockService.EXPECT().Calculate(gomock.Any(), expectedRaise, expectedReturn).Return(calculatedStockInfo)
+		channels.EXPECT().Notify(&expectedWatchlist, userprofile.Email, &model.Notification{Kind: model.NotificationChange, WatchlistID: watchlistID, WatchlistName: expectedWatchlist.Name, Removed: []string{"INTC"}, Added: empty, Current: empty, Stocks: []model.CalculatedStockInfo{calculatedStockInfo}, Locale: DefaultLocale}).Times(1)
 
 		notifier.NotifyChanges()
 	})
@@ -100,12 +114,16 @@ func TestNotification(t *testing.T) {
 
 		recommendations := mocks.NewMockrecommendationProvider(ctrl)
 		watchlists := mocks.NewMockwatchlistList(ctrl)
+		alertRules := mocks.NewMockalertRuleProvider(ctrl)
 		stockClient := mocks.NewMockstockGetter(ctrl)
+		stockClient.EXPECT().RefreshSP500DivYield(gomock.Any()).Return(1.0).AnyTimes()
 		stockService := mocks.NewMockstockRecommendator(ctrl)
 		userprofileClient := mocks.NewMockuserprofileGetter(ctrl)
-		emailClient := mocks.NewMockemailSender(ctrl)
+		channels := mocks.NewMocknotificationSender(ctrl)
+		priceAlerts := mocks.NewMockpriceAlertProvider(ctrl)
+		priceAlerts.EXPECT().GetActive().Return(nil, nil).AnyTimes()
 
-		notifier := NewNotifier(recommendations, watchlists, stockClient, stockService, userprofileClient, emailClient)
+		notifier := NewNotifier(recommendations, watchlists, alertRules, stockClient, stockService, userprofileClient, channels, &mockPreferences{}, priceAlerts)
 
 		watchlistID := primitive.NewObjectID()
 		expectedWatchlist := model.Watchlist{ID: watchlistID, Name: "watchlist", Stocks: []string{"INTC"}, UserID: "userId"}
@@ -127,16 +145,487 @@ func TestNotification(t *testing.T) {
 		calculatedStockInfo := model.CalculatedStockInfo{}
 		calculatedStockInfo.Ticker = "INTC"
 		calculatedStockInfo.PriceColor = "green"
+		calculatedStockInfo.PeColor = "green"
 
 		var empty []string
 
 		watchlists.EXPECT().List().Return([]model.Watchlist{expectedWatchlist}, nil)
+		alertRules.EXPECT().GetByWatchlist(watchlistID).Return(nil, nil)
 		recommendations.EXPECT().Get(watchlistID).Return(empty, nil)
 		recommendations.EXPECT().Update(gomock.Any(), watchlistID, []string{"INTC"}).Return(nil)
-		stockClient.EXPECT().Get("INTC").Return(stock, nil)
-		userprofileClient.EXPECT().GetUserprofile("userId").Return(userprofile, nil)
-		stockService.EXPECT().GetAllRecommendedStock(gomock.Any(), gomock.Any(), gomock.Any()).Return([]model.CalculatedStockInfo{calculatedStockInfo})
-		emailClient.EXPECT().SendNotification(expectedWatchlist.Name, empty, []string{"INTC"}, []string{"INTC"}, userprofile.Email).Times(1)
+		stockClient.EXPECT().Get(gomock.Any(), "INTC").Return(stock, nil)
+		userprofileClient.EXPECT().GetLocalizedUserprofile(gomock.Any(), "userId").Return(userprofile, "", nil)
+		stockService.EXPECT().Calculate(gomock.Any(), expectedRaise, expectedReturn).Return(calculatedStockInfo)
+		channels.EXPECT().Notify(&expectedWatchlist, userprofile.Email, &model.Notification{Kind: model.NotificationChange, WatchlistID: watchlistID, WatchlistName: expectedWatchlist.Name, Removed: empty, Added: []string{"INTC"}, Current: []string{"INTC"}, Stocks: []model.CalculatedStockInfo{calculatedStockInfo}, Locale: DefaultLocale}).Times(1)
+
+		notifier.NotifyChanges()
+	})
+	t.Run("email uses the rule of the watchlist", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		recommendations := mocks.NewMockrecommendationProvider(ctrl)
+		watchlists := mocks.NewMockwatchlistList(ctrl)
+		alertRules := mocks.NewMockalertRuleProvider(ctrl)
+		stockClient := mocks.NewMockstockGetter(ctrl)
+		stockClient.EXPECT().RefreshSP500DivYield(gomock.Any()).Return(1.0).AnyTimes()
+		stockService := mocks.NewMockstockRecommendator(ctrl)
+		userprofileClient := mocks.NewMockuserprofileGetter(ctrl)
+		channels := mocks.NewMocknotificationSender(ctrl)
+		priceAlerts := mocks.NewMockpriceAlertProvider(ctrl)
+		priceAlerts.EXPECT().GetActive().Return(nil, nil).AnyTimes()
+
+		notifier := NewNotifier(recommendations, watchlists, alertRules, stockClient, stockService, userprofileClient, channels, &mockPreferences{}, priceAlerts)
+
+		watchlistID := primitive.NewObjectID()
+		rule := model.NotificationRule{MinGreen: 1, RequiredSignals: []string{model.SignalDividend}}
+		expectedWatchlist := model.Watchlist{ID: watchlistID, Name: "watchlist", Stocks: []string{"INTC", "XOM"}, UserID: "userId", Rule: &rule}
+
+		expectedReturn := 9.0
+		expectedRaise := 5.5
+		userprofile := userprofileModel.Userprofile{Email: "alice@example.com", ExpectedReturn: &expectedReturn, DefaultExpectation: &expectedRaise}
+
+		intc := model.CalculatedStockInfo{Ticker: "INTC", PriceColor: "green", DividendColor: "yellow"}
+		xom := model.CalculatedStockInfo{Ticker: "XOM", PriceColor: "red", DividendColor: "green"}
+
+		var empty []string
+
+		watchlists.EXPECT().List().Return([]model.Watchlist{expectedWatchlist}, nil)
+		alertRules.EXPECT().GetByWatchlist(watchlistID).Return(nil, nil)
+		recommendations.EXPECT().Get(watchlistID).Return(empty, nil)
+		recommendations.EXPECT().Update(gomock.Any(), watchlistID, []string{"XOM"}).Return(nil)
+		stockClient.EXPECT().Get(gomock.Any(), "INTC").Return(model.StockData{Ticker: "INTC"}, nil)
+		stockClient.EXPECT().Get(gomock.Any(), "XOM").Return(model.StockData{Ticker: "XOM"}, nil)
+		userprofileClient.EXPECT().GetLocalizedUserprofile(gomock.Any(), "userId").Return(userprofile, "", nil)
+		stockService.EXPECT().Calculate(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(func(stock *model.StockData, expectedRaise, expectedReturn float64) model.CalculatedStockInfo {
+			if stock.Ticker == "INTC" {
+				return intc
+			}
+			return xom
+		}).Times(2)
+		channels.EXPECT().Notify(&expectedWatchlist, userprofile.Email, &model.Notification{Kind: model.NotificationChange, WatchlistID: watchlistID, WatchlistName: expectedWatchlist.Name, Removed: empty, Added: []string{"XOM"}, Current: []string{"XOM"}, Stocks: []model.CalculatedStockInfo{xom}, Locale: DefaultLocale}).Times(1)
+
+		notifier.NotifyChanges()
+	})
+	t.Run("email when alert rule starts and stops matching", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		recommendations := mocks.NewMockrecommendationProvider(ctrl)
+		watchlists := mocks.NewMockwatchlistList(ctrl)
+		alertRules := mocks.NewMockalertRuleProvider(ctrl)
+		stockClient := mocks.NewMockstockGetter(ctrl)
+		stockClient.EXPECT().RefreshSP500DivYield(gomock.Any()).Return(1.0).AnyTimes()
+		stockService := mocks.NewMockstockRecommendator(ctrl)
+		userprofileClient := mocks.NewMockuserprofileGetter(ctrl)
+		channels := mocks.NewMocknotificationSender(ctrl)
+		priceAlerts := mocks.NewMockpriceAlertProvider(ctrl)
+		priceAlerts.EXPECT().GetActive().Return(nil, nil).AnyTimes()
+
+		notifier := NewNotifier(recommendations, watchlists, alertRules, stockClient, stockService, userprofileClient, channels, &mockPreferences{}, priceAlerts)
+
+		watchlistID := primitive.NewObjectID()
+		expectedWatchlist := model.Watchlist{ID: watchlistID, Name: "watchlist", Stocks: []string{"INTC", "XOM"}, UserID: "userId"}
+		alertRule := model.AlertRule{ID: primitive.NewObjectID(), WatchlistID: watchlistID, Name: "high yield", Expression: "dividendYield > 4", Matching: []string{"XOM"}}
+
+		expectedReturn := 9.0
+		expectedRaise := 5.5
+		userprofile := userprofileModel.Userprofile{Email: "alice@example.com", ExpectedReturn: &expectedReturn, DefaultExpectation: &expectedRaise}
+
+		intc := model.CalculatedStockInfo{Ticker: "INTC", DividendYield: 4.5}
+		xom := model.CalculatedStockInfo{Ticker: "XOM", DividendYield: 3.5}
+
+		var empty []string
+
+		watchlists.EXPECT().List().Return([]model.Watchlist{expectedWatchlist}, nil)
+		recommendations.EXPECT().Get(watchlistID).Return(empty, nil)
+		stockClient.EXPECT().Get(gomock.Any(), "INTC").Return(model.StockData{Ticker: "INTC"}, nil)
+		stockClient.EXPECT().Get(gomock.Any(), "XOM").Return(model.StockData{Ticker: "XOM"}, nil)
+		userprofileClient.EXPECT().GetLocalizedUserprofile(gomock.Any(), "userId").Return(userprofile, "", nil)
+		stockService.EXPECT().Calculate(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(func(stock *model.StockData, expectedRaise, expectedReturn float64) model.CalculatedStockInfo {
+			if stock.Ticker == "INTC" {
+				return intc
+			}
+			return xom
+		}).Times(2)
+		alertRules.EXPECT().GetByWatchlist(watchlistID).Return([]model.AlertRule{alertRule}, nil)
+		alertRules.EXPECT().UpdateMatching(alertRule.ID, []string{"INTC"}).Return(nil)
+		channels.EXPECT().Notify(&expectedWatchlist, userprofile.Email, &model.Notification{Kind: model.NotificationAlert, WatchlistID: watchlistID, WatchlistName: expectedWatchlist.Name, AlertName: "high yield", Removed: []string{"XOM"}, Added: []string{"INTC"}, Current: []string{"INTC"}, Stocks: []model.CalculatedStockInfo{intc, xom}, Locale: DefaultLocale}).Times(1)
+
+		notifier.NotifyChanges()
+	})
+	t.Run("no email if watchlist snoozed or in quiet hours", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		recommendations := mocks.NewMockrecommendationProvider(ctrl)
+		watchlists := mocks.NewMockwatchlistList(ctrl)
+		alertRules := mocks.NewMockalertRuleProvider(ctrl)
+		stockClient := mocks.NewMockstockGetter(ctrl)
+		stockClient.EXPECT().RefreshSP500DivYield(gomock.Any()).Return(1.0).AnyTimes()
+		stockService := mocks.NewMockstockRecommendator(ctrl)
+		userprofileClient := mocks.NewMockuserprofileGetter(ctrl)
+		channels := mocks.NewMocknotificationSender(ctrl)
+		priceAlerts := mocks.NewMockpriceAlertProvider(ctrl)
+		priceAlerts.EXPECT().GetActive().Return(nil, nil).AnyTimes()
+
+		now := time.Date(2020, 12, 3, 14, 0, 0, 0, time.UTC)
+		snoozeUntil := now.Add(time.Hour)
+
+		snoozed := model.Watchlist{ID: primitive.NewObjectID(), Name: "snoozed", Stocks: []string{"INTC"}, UserID: "userId"}
+		quiet := model.Watchlist{ID: primitive.NewObjectID(), Name: "quiet", Stocks: []string{"INTC"}, UserID: "otherId"}
+
+		preferences := &mockPreferences{preferences: map[string]model.NotificationPreferences{
+			"userId":  {UserID: "userId", Delivery: model.DeliveryImmediate, TimeZone: "UTC", Watchlists: []model.WatchlistPreferences{{WatchlistID: snoozed.ID, SnoozeUntil: &snoozeUntil}}},
+			"otherId": {UserID: "otherId", Delivery: model.DeliveryImmediate, TimeZone: "Europe/Budapest", QuietHours: &model.QuietHours{Start: 12, End: 16}},
+		}}
+
+		notifier := NewNotifier(recommendations, watchlists, alertRules, stockClient, stockService, userprofileClient, channels, preferences, priceAlerts)
+		notifier.now = func() time.Time { return now }
+
+		watchlists.EXPECT().List().Return([]model.Watchlist{snoozed, quiet}, nil)
+		stockClient.EXPECT().Get(gomock.Any(), gomock.Any()).Times(0)
+		channels.EXPECT().Notify(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+
+		notifier.NotifyChanges()
+	})
+	t.Run("muted symbols are left out of the email", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		recommendations := mocks.NewMockrecommendationProvider(ctrl)
+		watchlists := mocks.NewMockwatchlistList(ctrl)
+		alertRules := mocks.NewMockalertRuleProvider(ctrl)
+		stockClient := mocks.NewMockstockGetter(ctrl)
+		stockClient.EXPECT().RefreshSP500DivYield(gomock.Any()).Return(1.0).AnyTimes()
+		stockService := mocks.NewMockstockRecommendator(ctrl)
+		userprofileClient := mocks.NewMockuserprofileGetter(ctrl)
+		channels := mocks.NewMocknotificationSender(ctrl)
+		priceAlerts := mocks.NewMockpriceAlertProvider(ctrl)
+		priceAlerts.EXPECT().GetActive().Return(nil, nil).AnyTimes()
+
+		watchlistID := primitive.NewObjectID()
+		channelID := primitive.NewObjectID()
+		watchlist := model.Watchlist{ID: watchlistID, Name: "watchlist", Stocks: []string{"INTC", "XOM"}, UserID: "userId"}
+
+		preferences := &mockPreferences{preferences: map[string]model.NotificationPreferences{
+			"userId": {UserID: "userId", Delivery: model.DeliveryImmediate, TimeZone: "UTC", Channels: []primitive.ObjectID{channelID}, Watchlists: []model.WatchlistPreferences{{WatchlistID: watchlistID, MutedSymbols: []string{"XOM"}}}},
+		}}
+
+		notifier := NewNotifier(recommendations, watchlists, alertRules, stockClient, stockService, userprofileClient, channels, preferences, priceAlerts)
+
+		expectedReturn := 9.0
+		expectedRaise := 5.5
+		userprofile := userprofileModel.Userprofile{Email: "alice@example.com", ExpectedReturn: &expectedReturn, DefaultExpectation: &expectedRaise}
+
+		intc := model.CalculatedStockInfo{Ticker: "INTC", PriceColor: "green", PeColor: "green"}
+		xom := model.CalculatedStockInfo{Ticker: "XOM", PriceColor: "green", PeColor: "green"}
+
+		var empty []string
+
+		watchlists.EXPECT().List().Return([]model.Watchlist{watchlist}, nil)
+		alertRules.EXPECT().GetByWatchlist(watchlistID).Return(nil, nil)
+		recommendations.EXPECT().Get(watchlistID).Return([]string{"INTC"}, nil)
+		recommendations.EXPECT().Update(gomock.Any(), watchlistID, []string{"INTC", "XOM"}).Return(nil)
+		stockClient.EXPECT().Get(gomock.Any(), "INTC").Return(model.StockData{Ticker: "INTC"}, nil)
+		stockClient.EXPECT().Get(gomock.Any(), "XOM").Return(model.StockData{Ticker: "XOM"}, nil)
+		userprofileClient.EXPECT().GetLocalizedUserprofile(gomock.Any(), "userId").Return(userprofile, "", nil)
+		stockService.EXPECT().Calculate(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(func(stock *model.StockData, expectedRaise, expectedReturn float64) model.CalculatedStockInfo {
+			if stock.Ticker == "INTC" {
+				return intc
+			}
+			return xom
+		}).Times(2)
+		channels.EXPECT().Notify(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+
+		notifier.NotifyChanges()
+
+		//unmuted changes are sent to the preferred channels
+		expectedWatchlist := watchlist
+		expectedWatchlist.Channels = []primitive.ObjectID{channelID}
+
+		watchlists.EXPECT().List().Return([]model.Watchlist{watchlist}, nil)
+		alertRules.EXPECT().GetByWatchlist(watchlistID).Return(nil, nil)
+		recommendations.EXPECT().Get(watchlistID).Return([]string{"INTC", "XOM"}, nil)
+		recommendations.EXPECT().Update(gomock.Any(), watchlistID, empty).Return(nil)
+		stockClient.EXPECT().Get(gomock.Any(), "INTC").Return(model.StockData{Ticker: "INTC"}, nil)
+		stockClient.EXPECT().Get(gomock.Any(), "XOM").Return(model.StockData{Ticker: "XOM"}, nil)
+		userprofileClient.EXPECT().GetLocalizedUserprofile(gomock.Any(), "userId").Return(userprofile, "", nil)
+		stockService.EXPECT().Calculate(gomock.Any(), gomock.Any(), gomock.Any()).Return(model.CalculatedStockInfo{}).Times(2)
+		channels.EXPECT().Notify(&expectedWatchlist, userprofile.Email, &model.Notification{Kind: model.NotificationChange, WatchlistID: watchlistID, WatchlistName: watchlist.Name, Removed: []string{"INTC"}, Locale: DefaultLocale}).Return(nil)
+
+		notifier.NotifyChanges()
+	})
+	t.Run("price alerts fire once the condition holds", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		recommendations := mocks.NewMockrecommendationProvider(ctrl)
+		watchlists := mocks.NewMockwatchlistList(ctrl)
+		alertRules := mocks.NewMockalertRuleProvider(ctrl)
+		stockClient := mocks.NewMockstockGetter(ctrl)
+		stockClient.EXPECT().RefreshSP500DivYield(gomock.Any()).Return(1.0).AnyTimes()
+		stockService := mocks.NewMockstockRecommendator(ctrl)
+		userprofileClient := mocks.NewMockuserprofileGetter(ctrl)
+		channels := mocks.NewMocknotificationSender(ctrl)
+		priceAlerts := mocks.NewMockpriceAlertProvider(ctrl)
+
+		notifier := NewNotifier(recommendations, watchlists, alertRules, stockClient, stockService, userprofileClient, channels, &mockPreferences{}, priceAlerts)
+
+		now := time.Date(2020, 12, 3, 14, 0, 0, 0, time.UTC)
+		notifier.now = func() time.Time { return now }
+
+		watchlistID := primitive.NewObjectID()
+		watchlist := model.Watchlist{ID: watchlistID, Name: "watchlist", Stocks: []string{"MSFT"}, UserID: "userId"}
+
+		below := model.PriceAlert{ID: primitive.NewObjectID(), UserID: "userId", Symbol: "MSFT", Condition: model.PriceBelow, Threshold: 250, Active: true}
+		yield := model.PriceAlert{ID: primitive.NewObjectID(), UserID: "userId", Symbol: "KO", Condition: model.YieldAbove, Threshold: 3.5, Recurring: true, Active: true, Triggered: true}
+
+		expectedReturn := 9.0
+		expectedRaise := 5.5
+		userprofile := userprofileModel.Userprofile{Email: "alice@example.com", ExpectedReturn: &expectedReturn, DefaultExpectation: &expectedRaise}
+
+		msft := model.CalculatedStockInfo{Ticker: "MSFT", Price: 240, DividendYield: 1, DividendStatus: model.MetricOk}
+		ko := model.CalculatedStockInfo{Ticker: "KO", Price: 50, DividendYield: 3.3, DividendStatus: model.MetricOk}
+
+		watchlists.EXPECT().List().Return([]model.Watchlist{watchlist}, nil)
+		alertRules.EXPECT().GetByWatchlist(watchlistID).Return(nil, nil)
+		recommendations.EXPECT().Get(watchlistID).Return(nil, nil)
+		stockClient.EXPECT().Get(gomock.Any(), "MSFT").Return(model.StockData{Ticker: "MSFT"}, nil).Times(1)
+		stockClient.EXPECT().Get(gomock.Any(), "KO").Return(model.StockData{Ticker: "KO"}, nil).Times(1)
+		userprofileClient.EXPECT().GetLocalizedUserprofile(gomock.Any(), "userId").Return(userprofile, "", nil).Times(1)
+		stockService.EXPECT().Calculate(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(func(stock *model.StockData, expectedRaise, expectedReturn float64) model.CalculatedStockInfo {
+			if stock.Ticker == "MSFT" {
+				return msft
+			}
+			return ko
+		}).AnyTimes()
+		priceAlerts.EXPECT().GetActive().Return([]model.PriceAlert{below, yield}, nil)
+		channels.EXPECT().Notify(&model.Watchlist{UserID: "userId"}, userprofile.Email, &model.Notification{Kind: model.NotificationPrice, AlertName: "MSFT price below 250.00", Added: []string{"MSFT"}, Current: []string{"MSFT"}, Stocks: []model.CalculatedStockInfo{msft}, Locale: DefaultLocale}).Return(nil)
+		priceAlerts.EXPECT().RecordFiring(below.ID, model.PriceAlertFiring{At: now, Price: 240, DividendYield: 1}, false).Return(nil)
+		priceAlerts.EXPECT().UpdateTriggered(yield.ID, false).Return(nil)
+
+		notifier.NotifyChanges()
+	})
+	t.Run("fetches shared stocks and userprofiles once and reports the run", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		recommendations := mocks.NewMockrecommendationProvider(ctrl)
+		watchlists := mocks.NewMockwatchlistList(ctrl)
+		alertRules := mocks.NewMockalertRuleProvider(ctrl)
+		stockClient := mocks.NewMockstockGetter(ctrl)
+		stockClient.EXPECT().RefreshSP500DivYield(gomock.Any()).Return(1.0).AnyTimes()
+		stockService := mocks.NewMockstockRecommendator(ctrl)
+		userprofileClient := mocks.NewMockuserprofileGetter(ctrl)
+		channels := mocks.NewMocknotificationSender(ctrl)
+		priceAlerts := mocks.NewMockpriceAlertProvider(ctrl)
+		priceAlerts.EXPECT().GetActive().Return(nil, nil)
+
+		notifier := NewNotifier(recommendations, watchlists, alertRules, stockClient, stockService, userprofileClient, channels, &mockPreferences{}, priceAlerts)
+
+		expectedReturn := 9.0
+		expectedRaise := 5.5
+		userprofile := userprofileModel.Userprofile{Email: "alice@example.com", ExpectedReturn: &expectedReturn, DefaultExpectation: &expectedRaise}
+
+		first := model.Watchlist{ID: primitive.NewObjectID(), Name: "first", Stocks: []string{"INTC", "XOM"}, UserID: "userId"}
+		second := model.Watchlist{ID: primitive.NewObjectID(), Name: "second", Stocks: []string{"INTC"}, UserID: "userId"}
+		third := model.Watchlist{ID: primitive.NewObjectID(), Name: "third", Stocks: []string{"INTC"}, UserID: "otherId"}
+
+		watchlists.EXPECT().List().Return([]model.Watchlist{first, second, third}, nil)
+		alertRules.EXPECT().GetByWatchlist(gomock.Any()).Return(nil, nil).Times(3)
+		recommendations.EXPECT().Get(gomock.Any()).Return([]string{"INTC"}, nil).Times(3)
+		stockClient.EXPECT().Get(gomock.Any(), "INTC").Return(model.StockData{Ticker: "INTC"}, nil).Times(1)
+		stockClient.EXPECT().Get(gomock.Any(), "XOM").Return(model.StockData{}, errors.New("unavailable")).Times(1)
+		userprofileClient.EXPECT().GetLocalizedUserprofile(gomock.Any(), "userId").Return(userprofile, "", nil).Times(1)
+		userprofileClient.EXPECT().GetLocalizedUserprofile(gomock.Any(), "otherId").Return(userprofile, "", nil).Times(1)
+		stockService.EXPECT().Calculate(gomock.Any(), gomock.Any(), gomock.Any()).Return(model.CalculatedStockInfo{Ticker: "INTC", PriceColor: "green", PeColor: "green"}).Times(3)
+
+		stats := notifier.Run()
+
+		if stats.Watchlists != 3 || stats.Symbols != 2 || stats.Users != 2 || stats.FetchFailures != 1 || stats.NotificationsSent != 0 {
+			t.Fatalf("unexpected stats [%+v]", stats)
+		}
+	})
+	t.Run("preview returns the notifications without sending them or updating the state", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		recommendations := mocks.NewMockrecommendationProvider(ctrl)
+		watchlists := mocks.NewMockwatchlistList(ctrl)
+		alertRules := mocks.NewMockalertRuleProvider(ctrl)
+		stockClient := mocks.NewMockstockGetter(ctrl)
+		stockClient.EXPECT().RefreshSP500DivYield(gomock.Any()).Return(1.0).AnyTimes()
+		stockService := mocks.NewMockstockRecommendator(ctrl)
+		userprofileClient := mocks.NewMockuserprofileGetter(ctrl)
+		channels := mocks.NewMocknotificationSender(ctrl)
+		priceAlerts := mocks.NewMockpriceAlertProvider(ctrl)
+
+		notifier := NewNotifier(recommendations, watchlists, alertRules, stockClient, stockService, userprofileClient, channels, &mockPreferences{}, priceAlerts)
+
+		watchlistID := primitive.NewObjectID()
+		watchlist := model.Watchlist{ID: watchlistID, Name: "watchlist", Stocks: []string{"INTC"}, UserID: "userId"}
+		alertRule := model.AlertRule{ID: primitive.NewObjectID(), WatchlistID: watchlistID, Name: "high yield", Expression: "dividendYield > 4"}
+
+		expectedReturn := 9.0
+		expectedRaise := 5.5
+		userprofile := userprofileModel.Userprofile{Email: "alice@example.com", ExpectedReturn: &expectedReturn, DefaultExpectation: &expectedRaise}
+
+		intc := model.CalculatedStockInfo{Ticker: "INTC", DividendYield: 4.5, PriceColor: "green", PeColor: "green"}
+
+		recommendations.EXPECT().Get(watchlistID).Return([]string{"XOM"}, nil)
+		stockClient.EXPECT().Get(gomock.Any(), "INTC").Return(model.StockData{Ticker: "INTC"}, nil)
+		userprofileClient.EXPECT().GetLocalizedUserprofile(gomock.Any(), "userId").Return(userprofile, "", nil)
+		stockService.EXPECT().Calculate(gomock.Any(), gomock.Any(), gomock.Any()).Return(intc)
+		alertRules.EXPECT().GetByWatchlist(watchlistID).Return([]model.AlertRule{alertRule}, nil)
+		recommendations.EXPECT().Update(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+		alertRules.EXPECT().UpdateMatching(gomock.Any(), gomock.Any()).Times(0)
+		channels.EXPECT().Notify(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+
+		preview, err := notifier.Preview(&watchlist)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !preview.Enabled || len(preview.Notifications) != 2 {
+			t.Fatalf("unexpected preview [%+v]", preview)
+		}
+
+		change := preview.Notifications[0].Notification
+		if change.Kind != model.NotificationChange || len(change.Removed) != 1 || change.Removed[0] != "XOM" || len(change.Added) != 1 || change.Added[0] != "INTC" {
+			t.Fatalf("unexpected change [%+v]", change)
+		}
+
+		alert := preview.Notifications[1].Notification
+		if alert.Kind != model.NotificationAlert || alert.AlertName != "high yield" || len(alert.Added) != 1 || alert.Added[0] != "INTC" {
+			t.Fatalf("unexpected alert [%+v]", alert)
+		}
+	})
+	t.Run("email when a subscribed signal changes", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		recommendations := mocks.NewMockrecommendationProvider(ctrl)
+		watchlists := mocks.NewMockwatchlistList(ctrl)
+		alertRules := mocks.NewMockalertRuleProvider(ctrl)
+		stockClient := mocks.NewMockstockGetter(ctrl)
+		stockClient.EXPECT().RefreshSP500DivYield(gomock.Any()).Return(1.0).AnyTimes()
+		stockService := mocks.NewMockstockRecommendator(ctrl)
+		userprofileClient := mocks.NewMockuserprofileGetter(ctrl)
+		channels := mocks.NewMocknotificationSender(ctrl)
+		priceAlerts := mocks.NewMockpriceAlertProvider(ctrl)
+		priceAlerts.EXPECT().GetActive().Return(nil, nil).AnyTimes()
+
+		notifier := NewNotifier(recommendations, watchlists, alertRules, stockClient, stockService, userprofileClient, channels, &mockPreferences{}, priceAlerts)
+
+		watchlistID := primitive.NewObjectID()
+		watchlist := model.Watchlist{ID: watchlistID, Name: "watchlist", Stocks: []string{"INTC", "XOM"}, UserID: "userId", Subscriptions: []model.SignalSubscription{{Signal: model.SignalDividend, To: "green"}}}
+
+		expectedReturn := 9.0
+		expectedRaise := 5.5
+		userprofile := userprofileModel.Userprofile{Email: "alice@example.com", ExpectedReturn: &expectedReturn, DefaultExpectation: &expectedRaise}
+
+		intc := model.CalculatedStockInfo{Ticker: "INTC", PriceColor: "red", DividendColor: "green", PeColor: "red"}
+		previous := map[string]model.SignalState{
+			"INTC": {Price: "yellow", Dividend: "yellow", Pe: "red"},
+			"XOM":  {Price: "green", Dividend: "green", Pe: "green"},
+		}
+		transition := model.SignalTransition{Symbol: "INTC", Signal: model.SignalDividend, From: "yellow", To: "green"}
+
+		watchlists.EXPECT().List().Return([]model.Watchlist{watchlist}, nil)
+		recommendations.EXPECT().Get(watchlistID).Return([]string{}, nil)
+		recommendations.EXPECT().GetSignals(watchlistID).Return(previous, nil)
+		stockClient.EXPECT().Get(gomock.Any(), "INTC").Return(model.StockData{Ticker: "INTC"}, nil)
+		stockClient.EXPECT().Get(gomock.Any(), "XOM").Return(model.StockData{}, errors.New("unavailable"))
+		userprofileClient.EXPECT().GetLocalizedUserprofile(gomock.Any(), "userId").Return(userprofile, "", nil)
+		stockService.EXPECT().Calculate(gomock.Any(), gomock.Any(), gomock.Any()).Return(intc)
+		alertRules.EXPECT().GetByWatchlist(watchlistID).Return(nil, nil)
+		channels.EXPECT().Notify(&watchlist, userprofile.Email, &model.Notification{Kind: model.NotificationSignal, WatchlistID: watchlistID, WatchlistName: watchlist.Name, Added: []string{"INTC"}, Current: []string{"INTC"}, Stocks: []model.CalculatedStockInfo{intc}, Transitions: []model.SignalTransition{transition}, Locale: DefaultLocale}).Return(nil)
+		recommendations.EXPECT().UpdateSignals(watchlistID, map[string]model.SignalState{
+			"INTC": {Price: "red", Dividend: "green", Pe: "red"},
+			"XOM":  {Price: "green", Dividend: "green", Pe: "green"},
+		}).Return(nil)
+
+		notifier.NotifyChanges()
+	})
+	t.Run("email in the locale of the userprofile without preferred locale", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		recommendations := mocks.NewMockrecommendationProvider(ctrl)
+		watchlists := mocks.NewMockwatchlistList(ctrl)
+		alertRules := mocks.NewMockalertRuleProvider(ctrl)
+		stockClient := mocks.NewMockstockGetter(ctrl)
+		stockClient.EXPECT().RefreshSP500DivYield(gomock.Any()).Return(1.0).AnyTimes()
+		stockService := mocks.NewMockstockRecommendator(ctrl)
+		userprofileClient := mocks.NewMockuserprofileGetter(ctrl)
+		channels := mocks.NewMocknotificationSender(ctrl)
+		priceAlerts := mocks.NewMockpriceAlertProvider(ctrl)
+		priceAlerts.EXPECT().GetActive().Return(nil, nil).AnyTimes()
+
+		notifier := NewNotifier(recommendations, watchlists, alertRules, stockClient, stockService, userprofileClient, channels, &mockPreferences{}, priceAlerts)
+
+		watchlistID := primitive.NewObjectID()
+		watchlist := model.Watchlist{ID: watchlistID, Name: "watchlist", Stocks: []string{"INTC"}, UserID: "userId"}
+
+		expectedReturn := 9.0
+		expectedRaise := 5.5
+		userprofile := userprofileModel.Userprofile{Email: "alice@example.com", ExpectedReturn: &expectedReturn, DefaultExpectation: &expectedRaise}
+
+		intc := model.CalculatedStockInfo{Ticker: "INTC", PriceColor: "red"}
+
+		var empty []string
+
+		watchlists.EXPECT().List().Return([]model.Watchlist{watchlist}, nil)
+		alertRules.EXPECT().GetByWatchlist(watchlistID).Return(nil, nil)
+		recommendations.EXPECT().Get(watchlistID).Return([]string{"INTC"}, nil)
+		recommendations.EXPECT().Update(gomock.Any(), watchlistID, empty).Return(nil)
+		stockClient.EXPECT().Get(gomock.Any(), "INTC").Return(model.StockData{Ticker: "INTC"}, nil)
+		userprofileClient.EXPECT().GetLocalizedUserprofile(gomock.Any(), "userId").Return(userprofile, "hu", nil)
+		stockService.EXPECT().Calculate(gomock.Any(), gomock.Any(), gomock.Any()).Return(intc)
+		channels.EXPECT().Notify(&watchlist, userprofile.Email, &model.Notification{Kind: model.NotificationChange, WatchlistID: watchlistID, WatchlistName: watchlist.Name, Removed: []string{"INTC"}, Added: empty, Current: empty, Stocks: []model.CalculatedStockInfo{intc}, Locale: "hu"}).Return(nil)
+
+		notifier.NotifyChanges()
+	})
+	t.Run("no email while a change waits for the dwell time", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		recommendations := mocks.NewMockrecommendationProvider(ctrl)
+		watchlists := mocks.NewMockwatchlistList(ctrl)
+		alertRules := mocks.NewMockalertRuleProvider(ctrl)
+		stockClient := mocks.NewMockstockGetter(ctrl)
+		stockClient.EXPECT().RefreshSP500DivYield(gomock.Any()).Return(1.0).AnyTimes()
+		stockService := mocks.NewMockstockRecommendator(ctrl)
+		userprofileClient := mocks.NewMockuserprofileGetter(ctrl)
+		channels := mocks.NewMocknotificationSender(ctrl)
+		priceAlerts := mocks.NewMockpriceAlertProvider(ctrl)
+		priceAlerts.EXPECT().GetActive().Return(nil, nil).AnyTimes()
+
+		notifier := NewNotifier(recommendations, watchlists, alertRules, stockClient, stockService, userprofileClient, channels, &mockPreferences{}, priceAlerts)
+		now := time.Date(2021, 3, 1, 15, 0, 0, 0, time.UTC)
+		notifier.now = func() time.Time { return now }
+
+		rule := DefaultNotificationRule()
+		rule.Hysteresis = &model.Hysteresis{DwellMinutes: 60}
+
+		watchlistID := primitive.NewObjectID()
+		watchlist := model.Watchlist{ID: watchlistID, Name: "watchlist", Stocks: []string{"INTC"}, UserID: "userId", Rule: &rule}
+
+		expectedReturn := 9.0
+		expectedRaise := 5.5
+		userprofile := userprofileModel.Userprofile{Email: "alice@example.com", ExpectedReturn: &expectedReturn, DefaultExpectation: &expectedRaise}
+
+		watchlists.EXPECT().List().Return([]model.Watchlist{watchlist}, nil)
+		recommendations.EXPECT().Get(watchlistID).Return([]string{}, nil)
+		recommendations.EXPECT().GetPending(watchlistID).Return(nil, nil)
+		stockClient.EXPECT().Get(gomock.Any(), "INTC").Return(model.StockData{Ticker: "INTC"}, nil)
+		userprofileClient.EXPECT().GetLocalizedUserprofile(gomock.Any(), "userId").Return(userprofile, "", nil)
+		stockService.EXPECT().Calculate(gomock.Any(), gomock.Any(), gomock.Any()).Return(model.CalculatedStockInfo{Ticker: "INTC", PriceColor: "green", PeColor: "green"})
+		alertRules.EXPECT().GetByWatchlist(watchlistID).Return(nil, nil)
+		channels.EXPECT().Notify(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+		recommendations.EXPECT().UpdatePending(watchlistID, map[string]model.PendingChange{"INTC": {Entering: true, Since: now}}).Return(nil)
 
 		notifier.NotifyChanges()
 	})
@@ -0,0 +1,135 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"github.com/nagymarci/stock-watchlist/model"
+)
+
+const (
+	defaultOutboxMaxAttempts = 8
+	defaultOutboxBackoff     = time.Minute
+	//outboxClaim is how long a claimed entry is hidden from other workers
+	outboxClaim = 5 * time.Minute
+)
+
+type outboxStore interface {
+	Add(entry model.OutboxEntry) error
+	Claim(now time.Time, claimedUntil time.Time) (model.OutboxEntry, error)
+	MarkFailed(id primitive.ObjectID, attempts int, lastError string, status string, nextAttemptAt time.Time, channels []primitive.ObjectID) error
+	Delete(id primitive.ObjectID) error
+}
+
+//Outbox stores the notifications first, and delivers them later with retries.
+//Failed deliveries are retried with exponential backoff through the failed channels only,
+//and dead-lettered after maxAttempts
+type Outbox struct {
+	entries     outboxStore
+	next        notificationSender
+	maxAttempts int
+	backoff     time.Duration
+	now         func() time.Time
+}
+
+func NewOutbox(o outboxStore, ns notificationSender) *Outbox {
+	return &Outbox{
+		entries:     o,
+		next:        ns,
+		maxAttempts: defaultOutboxMaxAttempts,
+		backoff:     defaultOutboxBackoff,
+		now:         time.Now,
+	}
+}
+
+//Notify writes the notification to the outbox, it is delivered by the next Process
+func (o *Outbox) Notify(watchlist *model.Watchlist, email string, notification *model.Notification) error {
+	now := o.now().UTC()
+
+	err := o.entries.Add(model.OutboxEntry{
+		Watchlist:     *watchlist,
+		Email:         email,
+		Notification:  *notification,
+		Status:        model.OutboxPending,
+		CreatedAt:     now,
+		NextAttemptAt: now,
+	})
+
+	if err != nil {
+		return fmt.Errorf("Failed to write notification to outbox: [%v]", err)
+	}
+
+	return nil
+}
+
+//Process delivers the due entries of the outbox
+func (o *Outbox) Process() {
+	delivered, failed := 0, 0
+
+	for {
+		now := o.now().UTC()
+
+		entry, err := o.entries.Claim(now, now.Add(outboxClaim))
+
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			break
+		}
+
+		if err != nil {
+			logrus.Errorf("Failed to claim outbox entry [%v]", err)
+			break
+		}
+
+		if o.deliver(&entry, now) {
+			delivered++
+		} else {
+			failed++
+		}
+	}
+
+	if delivered > 0 || failed > 0 {
+		logrus.WithFields(logrus.Fields{"delivered": delivered, "failed": failed}).Infoln("Outbox processed")
+	}
+}
+
+func (o *Outbox) deliver(entry *model.OutboxEntry, now time.Time) bool {
+	log := logrus.WithFields(logrus.Fields{"outboxId": entry.ID, "userId": entry.Watchlist.UserID})
+
+	err := o.next.Notify(&entry.Watchlist, entry.Email, &entry.Notification)
+
+	if err == nil {
+		if err := o.entries.Delete(entry.ID); err != nil {
+			log.Errorln("Failed to delete delivered outbox entry ", err)
+		}
+		return true
+	}
+
+	//channels already delivered to are not retried
+	channels := entry.Watchlist.Channels
+	var delivery *DeliveryError
+	if errors.As(err, &delivery) && delivery.Partial() {
+		channels = delivery.FailedChannels()
+	}
+
+	attempts := entry.Attempts + 1
+	status := model.OutboxPending
+	next := now.Add(o.backoff * time.Duration(1<<uint(attempts-1)))
+
+	if attempts >= o.maxAttempts {
+		status = model.OutboxDead
+		log.Errorf("Notification dead-lettered after [%d] attempts [%v]", attempts, err)
+	} else {
+		log.Warnf("Failed to deliver notification, attempt [%d] [%v]", attempts, err)
+	}
+
+	if err := o.entries.MarkFailed(entry.ID, attempts, err.Error(), status, next, channels); err != nil {
+		log.Errorln("Failed to update outbox entry ", err)
+	}
+
+	return false
+}
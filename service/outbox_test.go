@@ -0,0 +1,171 @@
+package service
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"github.com/nagymarci/stock-watchlist/model"
+	"github.com/nagymarci/stock-watchlist/service/mocks"
+)
+
+type mockOutbox struct {
+	entries []model.OutboxEntry
+}
+
+func (m *mockOutbox) Add(entry model.OutboxEntry) error {
+	entry.ID = primitive.NewObjectID()
+	m.entries = append(m.entries, entry)
+	return nil
+}
+
+func (m *mockOutbox) Claim(now time.Time, claimedUntil time.Time) (model.OutboxEntry, error) {
+	for i := range m.entries {
+		if m.entries[i].Status == model.OutboxPending && !m.entries[i].NextAttemptAt.After(now) {
+			m.entries[i].NextAttemptAt = claimedUntil
+			return m.entries[i], nil
+		}
+	}
+	return model.OutboxEntry{}, mongo.ErrNoDocuments
+}
+
+func (m *mockOutbox) MarkFailed(id primitive.ObjectID, attempts int, lastError string, status string, nextAttemptAt time.Time, channels []primitive.ObjectID) error {
+	for i := range m.entries {
+		if m.entries[i].ID == id {
+			m.entries[i].Watchlist.Channels = channels
+			m.entries[i].Attempts = attempts
+			m.entries[i].LastError = lastError
+			m.entries[i].Status = status
+			m.entries[i].NextAttemptAt = nextAttemptAt
+		}
+	}
+	return nil
+}
+
+func (m *mockOutbox) Delete(id primitive.ObjectID) error {
+	var kept []model.OutboxEntry
+	for _, entry := range m.entries {
+		if entry.ID != id {
+			kept = append(kept, entry)
+		}
+	}
+	m.entries = kept
+	return nil
+}
+
+func TestOutbox(t *testing.T) {
+	watchlist := model.Watchlist{ID: primitive.NewObjectID(), Name: "watchlist", UserID: "userId"}
+	notification := model.Notification{Kind: model.NotificationChange, WatchlistID: watchlist.ID, Added: []string{"INTC"}}
+
+	t.Run("delivers and removes the entry", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		channels := mocks.NewMocknotificationSender(ctrl)
+		entries := &mockOutbox{}
+		outbox := NewOutbox(entries, channels)
+
+		if err := outbox.Notify(&watchlist, "alice@example.com", &notification); err != nil || len(entries.entries) != 1 {
+			t.Fatalf("expected outbox entry, got [%v] [%v]", entries.entries, err)
+		}
+
+		channels.EXPECT().Notify(&watchlist, "alice@example.com", &notification).Return(nil)
+
+		outbox.Process()
+
+		if len(entries.entries) != 0 {
+			t.Fatalf("expected delivered entry to be removed, got [%v]", entries.entries)
+		}
+	})
+	t.Run("retries with backoff and dead-letters", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		channels := mocks.NewMocknotificationSender(ctrl)
+		entries := &mockOutbox{}
+		outbox := NewOutbox(entries, channels)
+		outbox.maxAttempts = 3
+
+		now := time.Date(2020, 12, 3, 14, 0, 0, 0, time.UTC)
+		outbox.now = func() time.Time { return now }
+
+		outbox.Notify(&watchlist, "alice@example.com", &notification)
+
+		channels.EXPECT().Notify(gomock.Any(), gomock.Any(), gomock.Any()).Return(errors.New("unavailable")).Times(3)
+
+		outbox.Process()
+
+		entry := entries.entries[0]
+		if entry.Attempts != 1 || entry.Status != model.OutboxPending || !entry.NextAttemptAt.Equal(now.Add(time.Minute)) || entry.LastError != "unavailable" {
+			t.Fatalf("unexpected entry after first attempt [%+v]", entry)
+		}
+
+		//not due yet
+		outbox.Process()
+
+		now = now.Add(time.Minute)
+		outbox.Process()
+
+		if entries.entries[0].Attempts != 2 || !entries.entries[0].NextAttemptAt.Equal(now.Add(2*time.Minute)) {
+			t.Fatalf("expected doubled backoff, got [%+v]", entries.entries[0])
+		}
+
+		now = now.Add(2 * time.Minute)
+		outbox.Process()
+
+		if entries.entries[0].Attempts != 3 || entries.entries[0].Status != model.OutboxDead {
+			t.Fatalf("expected dead-lettered entry, got [%+v]", entries.entries[0])
+		}
+
+		now = now.Add(time.Hour)
+		outbox.Process()
+	})
+	t.Run("retries only the failed channels", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		channels := mocks.NewMocknotificationSender(ctrl)
+		entries := &mockOutbox{}
+		outbox := NewOutbox(entries, channels)
+
+		now := time.Date(2020, 12, 3, 14, 0, 0, 0, time.UTC)
+		outbox.now = func() time.Time { return now }
+
+		hook := model.NotificationChannel{ID: primitive.NewObjectID(), Type: model.ChannelWebhook}
+		chat := model.NotificationChannel{ID: primitive.NewObjectID(), Type: model.ChannelChat}
+
+		target := watchlist
+		target.Channels = []primitive.ObjectID{hook.ID, chat.ID}
+
+		outbox.Notify(&target, "alice@example.com", &notification)
+
+		partial := &DeliveryError{Channels: 2, Failed: []ChannelFailure{{Channel: chat, Err: errors.New("unavailable")}}}
+
+		gomock.InOrder(
+			channels.EXPECT().Notify(&target, "alice@example.com", &notification).Return(partial),
+			channels.EXPECT().Notify(gomock.Any(), "alice@example.com", &notification).DoAndReturn(func(w *model.Watchlist, email string, n *model.Notification) error {
+				if len(w.Channels) != 1 || w.Channels[0] != chat.ID {
+					t.Fatalf("expected retry through the chat channel only, got [%v]", w.Channels)
+				}
+				return nil
+			}),
+		)
+
+		outbox.Process()
+
+		if len(entries.entries) != 1 || entries.entries[0].Attempts != 1 {
+			t.Fatalf("expected entry to be retried, got [%+v]", entries.entries)
+		}
+
+		now = now.Add(time.Minute)
+		outbox.Process()
+
+		if len(entries.entries) != 0 {
+			t.Fatalf("expected delivered entry to be removed, got [%+v]", entries.entries)
+		}
+	})
+}
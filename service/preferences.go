@@ -0,0 +1,207 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"github.com/nagymarci/stock-watchlist/model"
+)
+
+const (
+	defaultDigestHour     = 8
+	defaultDigestTimeZone = "America/New_York"
+)
+
+//DefaultNotificationPreferences returns the preferences of users who have not set them
+func DefaultNotificationPreferences(userID string) model.NotificationPreferences {
+	return model.NotificationPreferences{
+		UserID:        userID,
+		Delivery:      model.DeliveryImmediate,
+		DigestHour:    defaultDigestHour,
+		DigestWeekday: int(time.Monday),
+		TimeZone:      defaultDigestTimeZone,
+	}
+}
+
+//ValidateNotificationPreferences checks the delivery mode, the digest time, the time zone and the locale
+func ValidateNotificationPreferences(request *model.NotificationPreferencesRequest) error {
+	switch request.Delivery {
+	case model.DeliveryImmediate, model.DeliveryDaily, model.DeliveryWeekly:
+	default:
+		return fmt.Errorf("Unknown delivery mode [%s]", request.Delivery)
+	}
+
+	if request.DigestHour < 0 || request.DigestHour > 23 {
+		return fmt.Errorf("Digest hour must be between 0 and 23, got [%d]", request.DigestHour)
+	}
+
+	if request.DigestWeekday < 0 || request.DigestWeekday > 6 {
+		return fmt.Errorf("Digest weekday must be between 0 (Sunday) and 6, got [%d]", request.DigestWeekday)
+	}
+
+	if _, err := time.LoadLocation(request.TimeZone); err != nil || request.TimeZone == "" {
+		return fmt.Errorf("Unknown time zone [%s]", request.TimeZone)
+	}
+
+	if err := ValidateLocale(request.Locale); err != nil {
+		return err
+	}
+
+	if q := request.QuietHours; q != nil {
+		if q.Start < 0 || q.Start > 23 || q.End < 0 || q.End > 23 || q.Start == q.End {
+			return fmt.Errorf("Quiet hours must be two different hours between 0 and 23, got [%d-%d]", q.Start, q.End)
+		}
+	}
+
+	var watchlists []primitive.ObjectID
+	for i := range request.Watchlists {
+		if containsID(watchlists, request.Watchlists[i].WatchlistID) {
+			return fmt.Errorf("Duplicated preferences of watchlist [%s]", request.Watchlists[i].WatchlistID.Hex())
+		}
+		watchlists = append(watchlists, request.Watchlists[i].WatchlistID)
+
+		if err := ValidateWatchlistPreferences(&request.Watchlists[i]); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+//ValidateWatchlistPreferences checks the muted symbols of the watchlist, and makes them upper case
+func ValidateWatchlistPreferences(preferences *model.WatchlistPreferences) error {
+	for i, symbol := range preferences.MutedSymbols {
+		if strings.TrimSpace(symbol) == "" {
+			return errors.New("Muted symbol must not be empty")
+		}
+		preferences.MutedSymbols[i] = strings.ToUpper(strings.TrimSpace(symbol))
+	}
+
+	return nil
+}
+
+//userPreferences returns the preferences of the user, the defaults if the user has not set them
+func userPreferences(preferences preferencesGetter, userID string) (model.NotificationPreferences, error) {
+	result, err := preferences.Get(userID)
+
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return DefaultNotificationPreferences(userID), nil
+	}
+
+	if err != nil {
+		return result, fmt.Errorf("Failed to get notification preferences of [%s]: [%v]", userID, err)
+	}
+
+	return result, nil
+}
+
+//WatchlistPreferencesOf returns the preferences of the watchlist
+func WatchlistPreferencesOf(preferences *model.NotificationPreferences, watchlistID primitive.ObjectID) model.WatchlistPreferences {
+	for _, p := range preferences.Watchlists {
+		if p.WatchlistID == watchlistID {
+			return p
+		}
+	}
+
+	return model.WatchlistPreferences{WatchlistID: watchlistID}
+}
+
+//SetWatchlistPreferences replaces the preferences of the watchlist, or adds them if not set yet
+func SetWatchlistPreferences(preferences *model.NotificationPreferences, watchlist model.WatchlistPreferences) {
+	for i := range preferences.Watchlists {
+		if preferences.Watchlists[i].WatchlistID == watchlist.WatchlistID {
+			preferences.Watchlists[i] = watchlist
+			return
+		}
+	}
+
+	preferences.Watchlists = append(preferences.Watchlists, watchlist)
+}
+
+//NotificationsEnabled returns whether the user wants notifications of the watchlist at the given time
+func NotificationsEnabled(preferences *model.NotificationPreferences, watchlistID primitive.ObjectID, now time.Time) bool {
+	if preferences.Disabled {
+		return false
+	}
+
+	watchlist := WatchlistPreferencesOf(preferences, watchlistID)
+
+	if watchlist.Disabled {
+		return false
+	}
+
+	return watchlist.SnoozeUntil == nil || !now.Before(*watchlist.SnoozeUntil)
+}
+
+//InQuietHours returns whether the given time falls into the quiet hours of the user
+func InQuietHours(preferences *model.NotificationPreferences, now time.Time) bool {
+	q := preferences.QuietHours
+
+	if q == nil {
+		return false
+	}
+
+	hour := now.In(location(preferences)).Hour()
+
+	if q.Start < q.End {
+		return hour >= q.Start && hour < q.End
+	}
+
+	return hour >= q.Start || hour < q.End
+}
+
+//FilterMuted removes the muted symbols from the notification,
+//and returns whether any change is left to notify about
+func FilterMuted(notification *model.Notification, muted []string) bool {
+	if len(muted) > 0 {
+		notification.Removed = withoutSymbols(notification.Removed, muted)
+		notification.Added = withoutSymbols(notification.Added, muted)
+		notification.Current = withoutSymbols(notification.Current, muted)
+
+		var stocks []model.CalculatedStockInfo
+		for _, stock := range notification.Stocks {
+			if !contains(muted, stock.Ticker) {
+				stocks = append(stocks, stock)
+			}
+		}
+		notification.Stocks = stocks
+
+		var transitions []model.SignalTransition
+		for _, transition := range notification.Transitions {
+			if !contains(muted, transition.Symbol) {
+				transitions = append(transitions, transition)
+			}
+		}
+		notification.Transitions = transitions
+	}
+
+	return len(notification.Removed) > 0 || len(notification.Added) > 0
+}
+
+func withoutSymbols(symbols []string, muted []string) []string {
+	var result []string
+
+	for _, symbol := range symbols {
+		if !contains(muted, symbol) {
+			result = append(result, symbol)
+		}
+	}
+
+	return result
+}
+
+//location returns the time zone of the preferences, the default one if that is invalid
+func location(preferences *model.NotificationPreferences) *time.Location {
+	loc, err := time.LoadLocation(preferences.TimeZone)
+
+	if err != nil || preferences.TimeZone == "" {
+		loc, _ = time.LoadLocation(defaultDigestTimeZone)
+	}
+
+	return loc
+}
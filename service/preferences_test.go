@@ -0,0 +1,55 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/nagymarci/stock-watchlist/model"
+)
+
+func TestInQuietHours(t *testing.T) {
+	preferences := model.NotificationPreferences{TimeZone: "Europe/Budapest", QuietHours: &model.QuietHours{Start: 22, End: 7}}
+	loc, _ := time.LoadLocation("Europe/Budapest")
+
+	tests := map[int]bool{21: false, 22: true, 23: true, 0: true, 6: true, 7: false, 12: false}
+
+	for hour, expected := range tests {
+		if InQuietHours(&preferences, time.Date(2020, 12, 3, hour, 30, 0, 0, loc).UTC()) != expected {
+			t.Errorf("expected [%v] at [%d]", expected, hour)
+		}
+	}
+}
+
+func TestValidateNotificationPreferences(t *testing.T) {
+	watchlistID := primitive.NewObjectID()
+
+	valid := model.NotificationPreferencesRequest{
+		Delivery:   model.DeliveryDaily,
+		DigestHour: 8,
+		TimeZone:   "Europe/Budapest",
+		QuietHours: &model.QuietHours{Start: 22, End: 7},
+		Watchlists: []model.WatchlistPreferences{{WatchlistID: watchlistID, MutedSymbols: []string{" intc"}}},
+	}
+
+	if err := ValidateNotificationPreferences(&valid); err != nil || valid.Watchlists[0].MutedSymbols[0] != "INTC" {
+		t.Fatalf("expected valid preferences, got [%v] [%v]", valid.Watchlists[0].MutedSymbols, err)
+	}
+
+	invalid := []model.NotificationPreferencesRequest{
+		{Delivery: "hourly", TimeZone: "UTC"},
+		{Delivery: model.DeliveryDaily, DigestHour: 24, TimeZone: "UTC"},
+		{Delivery: model.DeliveryWeekly, DigestWeekday: 7, TimeZone: "UTC"},
+		{Delivery: model.DeliveryDaily, TimeZone: "Mars/Olympus"},
+		{Delivery: model.DeliveryDaily, TimeZone: "UTC", QuietHours: &model.QuietHours{Start: 5, End: 5}},
+		{Delivery: model.DeliveryDaily, TimeZone: "UTC", Watchlists: []model.WatchlistPreferences{{WatchlistID: watchlistID}, {WatchlistID: watchlistID}}},
+		{Delivery: model.DeliveryDaily, TimeZone: "UTC", Watchlists: []model.WatchlistPreferences{{WatchlistID: watchlistID, MutedSymbols: []string{""}}}},
+	}
+
+	for i := range invalid {
+		if err := ValidateNotificationPreferences(&invalid[i]); err == nil {
+			t.Errorf("expected error for [%+v]", invalid[i])
+		}
+	}
+}
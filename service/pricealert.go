@@ -0,0 +1,62 @@
+package service
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/nagymarci/stock-watchlist/model"
+)
+
+//ValidatePriceAlert checks the condition and the threshold of the alert, and makes the symbol upper case
+func ValidatePriceAlert(request *model.PriceAlertRequest) error {
+	request.Symbol = strings.ToUpper(strings.TrimSpace(request.Symbol))
+
+	if request.Symbol == "" {
+		return fmt.Errorf("Symbol must not be empty")
+	}
+
+	switch request.Condition {
+	case model.PriceBelow, model.PriceAbove, model.YieldAbove, model.YieldBelow:
+	default:
+		return fmt.Errorf("Unknown condition [%s]", request.Condition)
+	}
+
+	if request.Threshold <= 0 {
+		return fmt.Errorf("Threshold must be positive, got [%v]", request.Threshold)
+	}
+
+	return nil
+}
+
+//PriceAlertHolds returns whether the condition of the alert holds for the stock.
+//Conditions on missing price or dividend never hold
+func PriceAlertHolds(alert *model.PriceAlert, calc *model.CalculatedStockInfo) bool {
+	switch alert.Condition {
+	case model.PriceBelow:
+		return calc.Price > 0 && calc.Price < alert.Threshold
+	case model.PriceAbove:
+		return calc.Price > alert.Threshold
+	case model.YieldAbove:
+		return calc.DividendStatus != model.MetricInsufficientData && calc.DividendYield > alert.Threshold
+	case model.YieldBelow:
+		return calc.DividendStatus != model.MetricInsufficientData && calc.DividendYield < alert.Threshold
+	}
+
+	return false
+}
+
+//PriceAlertName describes the alert, e.g. "MSFT price below 250.00"
+func PriceAlertName(alert *model.PriceAlert) string {
+	switch alert.Condition {
+	case model.PriceBelow:
+		return fmt.Sprintf("%s price below %.2f", alert.Symbol, alert.Threshold)
+	case model.PriceAbove:
+		return fmt.Sprintf("%s price above %.2f", alert.Symbol, alert.Threshold)
+	case model.YieldAbove:
+		return fmt.Sprintf("%s yield above %.2f%%", alert.Symbol, alert.Threshold)
+	case model.YieldBelow:
+		return fmt.Sprintf("%s yield below %.2f%%", alert.Symbol, alert.Threshold)
+	}
+
+	return alert.Symbol
+}
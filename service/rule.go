@@ -0,0 +1,108 @@
+package service
+
+import (
+	"fmt"
+
+	"github.com/nagymarci/stock-watchlist/model"
+)
+
+//DefaultNotificationRule returns the rule of watchlists without their own: 2 of 3 green and price green
+func DefaultNotificationRule() model.NotificationRule {
+	return model.NotificationRule{MinGreen: 2, RequiredSignals: []string{model.SignalPrice}}
+}
+
+//NotificationRuleOf returns the notification rule of the watchlist
+func NotificationRuleOf(watchlist *model.Watchlist) model.NotificationRule {
+	if watchlist.Rule == nil {
+		return DefaultNotificationRule()
+	}
+
+	return *watchlist.Rule
+}
+
+//ValidateNotificationRule checks that the rule can be fulfilled
+func ValidateNotificationRule(rule *model.NotificationRule) error {
+	if rule.MinGreen < 0 || rule.MinGreen > 3 {
+		return fmt.Errorf("'minGreen' must be between 0 and 3, got [%d]", rule.MinGreen)
+	}
+
+	if rule.MinScore < 0 || rule.MinScore > 3 {
+		return fmt.Errorf("'minScore' must be between 0 and 3, got [%v]", rule.MinScore)
+	}
+
+	for _, signal := range rule.RequiredSignals {
+		if signal != model.SignalPrice && signal != model.SignalDividend && signal != model.SignalPe {
+			return fmt.Errorf("Unknown signal [%s] in 'requiredSignals'", signal)
+		}
+	}
+
+	if rule.Hysteresis != nil {
+		if rule.Hysteresis.Buffer < 0 || rule.Hysteresis.Buffer > maxHysteresisBuffer {
+			return fmt.Errorf("'hysteresis.buffer' must be between 0 and %v percent, got [%v]", maxHysteresisBuffer, rule.Hysteresis.Buffer)
+		}
+
+		if rule.Hysteresis.DwellMinutes < 0 || rule.Hysteresis.DwellMinutes > maxDwellMinutes {
+			return fmt.Errorf("'hysteresis.dwellMinutes' must be between 0 and %d, got [%d]", maxDwellMinutes, rule.Hysteresis.DwellMinutes)
+		}
+	}
+
+	return nil
+}
+
+//SignalColor returns the color of the given signal
+func SignalColor(stock *model.CalculatedStockInfo, signal string) string {
+	switch signal {
+	case model.SignalPrice:
+		return stock.PriceColor
+	case model.SignalDividend:
+		return stock.DividendColor
+	case model.SignalPe:
+		return stock.PeColor
+	}
+
+	return ""
+}
+
+//Score returns the score of the stock, green signals count 1, yellow signals count 0.5
+func Score(stock *model.CalculatedStockInfo) float64 {
+	result := 0.0
+
+	for _, signal := range []string{model.SignalPrice, model.SignalDividend, model.SignalPe} {
+		switch SignalColor(stock, signal) {
+		case "green":
+			result++
+		case "yellow":
+			result += 0.5
+		}
+	}
+
+	return result
+}
+
+//MatchesRule returns whether the stock fulfills the notification rule
+func MatchesRule(stock *model.CalculatedStockInfo, rule *model.NotificationRule) bool {
+	if calculateReqsFulfilled(stock) < rule.MinGreen {
+		return false
+	}
+
+	for _, signal := range rule.RequiredSignals {
+		if SignalColor(stock, signal) != "green" {
+			return false
+		}
+	}
+
+	return Score(stock) >= rule.MinScore
+}
+
+//FilterByRule returns the stocks matching the notification rule
+func FilterByRule(stockInfos []model.CalculatedStockInfo, rule *model.NotificationRule) []model.CalculatedStockInfo {
+	var result []model.CalculatedStockInfo
+
+	for _, calc := range stockInfos {
+		if MatchesRule(&calc, rule) {
+			result = append(result, calc)
+		}
+	}
+
+	return result
+}
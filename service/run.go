@@ -0,0 +1,164 @@
+package service
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	userprofileModel "github.com/nagymarci/stock-user-profile/model"
+	"github.com/nagymarci/stock-watchlist/model"
+)
+
+//defaultNotifierWorkers is the number of concurrent fetches and evaluations of a notifier run
+const defaultNotifierWorkers = 8
+
+//defaultNotifierRunTimeout is the deadline of the upstream calls of a notifier run
+const defaultNotifierRunTimeout = 5 * time.Minute
+
+//notifierRun holds the data fetched once for a notifier run, and counts its results.
+//The maps are only read after the fetching finished, so the evaluations can share them.
+//A dry run collects the notifications instead of sending them, and leaves the stored state untouched
+type notifierRun struct {
+	stocks        map[string]model.StockData
+	userprofiles  map[string]userprofileModel.Userprofile
+	locales       map[string]string
+	preferences   map[string]model.NotificationPreferences
+	fetchFailures int64
+	sent          int64
+	failed        int64
+
+	dryRun        bool
+	mu            sync.Mutex
+	notifications []model.Notification
+}
+
+func newNotifierRun() *notifierRun {
+	return &notifierRun{
+		stocks:       make(map[string]model.StockData),
+		userprofiles: make(map[string]userprofileModel.Userprofile),
+		locales:      make(map[string]string),
+		preferences:  make(map[string]model.NotificationPreferences),
+	}
+}
+
+//delivered counts the result of a notification
+func (r *notifierRun) delivered(err error) {
+	if err != nil {
+		atomic.AddInt64(&r.failed, 1)
+		return
+	}
+
+	atomic.AddInt64(&r.sent, 1)
+}
+
+//collect keeps the notification of a dry run
+func (r *notifierRun) collect(notification *model.Notification) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.notifications = append(r.notifications, *notification)
+}
+
+//fetchPreferences fetches the notification preferences of the users
+func (n *Notifier) fetchPreferences(run *notifierRun, users []string) {
+	var mu sync.Mutex
+
+	parallel(n.workers, len(users), func(i int) {
+		preferences, err := userPreferences(n.preferences, users[i])
+
+		mu.Lock()
+		defer mu.Unlock()
+
+		if err != nil {
+			logrus.WithField("userId", users[i]).Errorln(err)
+			run.fetchFailures++
+			return
+		}
+
+		run.preferences[users[i]] = preferences
+	})
+}
+
+//fetchStocksAndUserprofiles fetches every symbol and userprofile once within ctx,
+//and refreshes the S&P500 dividend yield the stocks are evaluated against
+func (n *Notifier) fetchStocksAndUserprofiles(ctx context.Context, run *notifierRun, symbols []string, users []string) {
+	var mu sync.Mutex
+
+	if len(symbols) > 0 {
+		n.stockClient.RefreshSP500DivYield(ctx)
+	}
+
+	parallel(n.workers, len(symbols)+len(users), func(i int) {
+		if i < len(symbols) {
+			stock, err := n.stockClient.Get(ctx, symbols[i])
+
+			mu.Lock()
+			defer mu.Unlock()
+
+			if err != nil {
+				logrus.Warnf("Failed to get stock [%s]: [%v]\n", symbols[i], err)
+				run.fetchFailures++
+				return
+			}
+
+			run.stocks[symbols[i]] = stock
+			return
+		}
+
+		userID := users[i-len(symbols)]
+		userprofile, locale, err := n.userprofileClient.GetLocalizedUserprofile(ctx, userID)
+
+		mu.Lock()
+		defer mu.Unlock()
+
+		if err != nil {
+			logrus.WithField("userId", userID).Errorln("Failed to get userprofile to notification ", err)
+			run.fetchFailures++
+			return
+		}
+
+		run.userprofiles[userID] = userprofile
+		run.locales[userID] = locale
+	})
+}
+
+//parallel calls fn for every index in [0, count), running at most workers calls at once
+func parallel(workers int, count int, fn func(i int)) {
+	jobs := make(chan int)
+
+	var wg sync.WaitGroup
+	for w := 0; w < workers && w < count; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				fn(i)
+			}
+		}()
+	}
+
+	for i := 0; i < count; i++ {
+		jobs <- i
+	}
+	close(jobs)
+
+	wg.Wait()
+}
+
+//distinct returns the values without duplicates, keeping their order
+func distinct(values []string) []string {
+	var result []string
+	seen := make(map[string]bool)
+
+	for _, value := range values {
+		if !seen[value] {
+			seen[value] = true
+			result = append(result, value)
+		}
+	}
+
+	return result
+}
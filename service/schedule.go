@@ -0,0 +1,255 @@
+package service
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/robfig/cron/v3"
+	"github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/nagymarci/stock-watchlist/model"
+)
+
+//sessionLead and sessionTail extend the trading session for the hourly checks,
+//so they cover the pre-market and the after-hours moves
+const sessionLead = 90 * time.Minute
+const sessionTail = 2 * time.Hour
+
+//minCheckInterval is the shortest time between two checks of a cron schedule
+const minCheckInterval = 15 * time.Minute
+
+//maxScheduleLookahead is how far the next check is searched for
+const maxScheduleLookahead = 14 * 24 * time.Hour
+
+//ValidateCheckSchedule checks the kind of the schedule and its settings, and that it has a check
+//on a trading day of the exchange, e.g. a cron schedule only on weekends is rejected
+func (c *Calendar) ValidateCheckSchedule(schedule *model.CheckSchedule) error {
+	if err := validateCheckSchedule(schedule); err != nil {
+		return err
+	}
+
+	_, err := c.NextCheck(schedule, time.Now())
+
+	return err
+}
+
+func validateCheckSchedule(schedule *model.CheckSchedule) error {
+	if schedule.TimeZone != "" {
+		if _, err := time.LoadLocation(schedule.TimeZone); err != nil {
+			return fmt.Errorf("Unknown time zone [%s]", schedule.TimeZone)
+		}
+	}
+
+	switch schedule.Kind {
+	case model.ScheduleHourly, model.ScheduleMarketOpen, model.ScheduleMarketClose:
+		return nil
+	case model.ScheduleDaily:
+		_, err := parseSessionTime(schedule.Time)
+		return err
+	case model.ScheduleCron:
+		if strings.HasPrefix(schedule.Cron, "CRON_TZ=") || strings.HasPrefix(schedule.Cron, "TZ=") {
+			return fmt.Errorf("Set the time zone of the cron schedule in timeZone")
+		}
+
+		spec, err := cron.ParseStandard(cronSpec(schedule, time.UTC))
+
+		if err != nil {
+			return fmt.Errorf("Invalid cron expression [%s]: [%v]", schedule.Cron, err)
+		}
+
+		next := spec.Next(time.Now())
+		for i := 0; i < 10; i++ {
+			following := spec.Next(next)
+
+			if following.Sub(next) < minCheckInterval {
+				return fmt.Errorf("Cron schedule must not run more often than every [%v]", minCheckInterval)
+			}
+
+			next = following
+		}
+
+		return nil
+	}
+
+	return fmt.Errorf("Unknown schedule kind [%s]", schedule.Kind)
+}
+
+//NextCheck returns the first check of the schedule after the given time. Without schedule,
+//the watchlist is checked hourly around the trading session. Daily and cron checks are skipped
+//on the days the exchange is closed
+func (c *Calendar) NextCheck(schedule *model.CheckSchedule, after time.Time) (time.Time, error) {
+	if schedule == nil {
+		schedule = &model.CheckSchedule{Kind: model.ScheduleHourly}
+	}
+
+	location := c.location
+	if schedule.TimeZone != "" {
+		var err error
+		if location, err = time.LoadLocation(schedule.TimeZone); err != nil {
+			return time.Time{}, fmt.Errorf("Unknown time zone [%s]", schedule.TimeZone)
+		}
+	}
+
+	switch schedule.Kind {
+	case model.ScheduleHourly:
+		local := after.In(c.location)
+		hour := time.Date(local.Year(), local.Month(), local.Day(), local.Hour(), 0, 0, 0, c.location)
+
+		for t := hour.Add(time.Hour); t.Sub(after) < maxScheduleLookahead; t = t.Add(time.Hour) {
+			day := c.Day(t)
+
+			if day.Open && !t.Before(day.Opens.Add(-sessionLead)) && !t.After(day.Closes.Add(sessionTail)) {
+				return t, nil
+			}
+		}
+	case model.ScheduleMarketOpen, model.ScheduleMarketClose:
+		for t := after; t.Sub(after) < maxScheduleLookahead; t = t.AddDate(0, 0, 1) {
+			day := c.Day(t)
+
+			if !day.Open {
+				continue
+			}
+
+			next := *day.Opens
+			if schedule.Kind == model.ScheduleMarketClose {
+				next = *day.Closes
+			}
+
+			if next.After(after) {
+				return next, nil
+			}
+		}
+	case model.ScheduleDaily:
+		offset, err := parseSessionTime(schedule.Time)
+
+		if err != nil {
+			return time.Time{}, err
+		}
+
+		local := after.In(location)
+
+		for day := 0; day < int(maxScheduleLookahead/(24*time.Hour)); day++ {
+			next := time.Date(local.Year(), local.Month(), local.Day()+day, 0, 0, 0, 0, location).Add(offset)
+
+			if next.After(after) && c.IsTradingDay(next) {
+				return next, nil
+			}
+		}
+	case model.ScheduleCron:
+		spec, err := cron.ParseStandard(cronSpec(schedule, location))
+
+		if err != nil {
+			return time.Time{}, fmt.Errorf("Invalid cron expression [%s]: [%v]", schedule.Cron, err)
+		}
+
+		for next := spec.Next(after); !next.IsZero() && next.Sub(after) < maxScheduleLookahead; next = spec.Next(next) {
+			if c.IsTradingDay(next) {
+				return next, nil
+			}
+		}
+	default:
+		return time.Time{}, fmt.Errorf("Unknown schedule kind [%s]", schedule.Kind)
+	}
+
+	return time.Time{}, fmt.Errorf("No check of schedule [%s] in the next [%v]", schedule.Kind, maxScheduleLookahead)
+}
+
+func cronSpec(schedule *model.CheckSchedule, location *time.Location) string {
+	return "CRON_TZ=" + location.String() + " " + schedule.Cron
+}
+
+type scheduledWatchlists interface {
+	ListDue(now time.Time) ([]model.Watchlist, error)
+	UpdateNextCheck(id primitive.ObjectID, next time.Time) error
+}
+
+type dueRunner interface {
+	RunDue(watchlists []model.Watchlist, priceAlerts bool) (model.NotifierRunStats, error)
+}
+
+//CheckScheduler runs the notifier for the watchlists whose next check is due. The price alerts
+//are checked hourly around the trading session
+type CheckScheduler struct {
+	watchlists     scheduledWatchlists
+	notifier       dueRunner
+	calendar       *Calendar
+	nextAlertCheck time.Time
+	now            func() time.Time
+}
+
+func NewCheckScheduler(w scheduledWatchlists, n dueRunner, c *Calendar) *CheckScheduler {
+	return &CheckScheduler{
+		watchlists: w,
+		notifier:   n,
+		calendar:   c,
+		now:        time.Now,
+	}
+}
+
+//Tick runs the due checks, it is called every minute. Watchlists without next check only get it scheduled,
+//so new and rescheduled watchlists are first checked at their scheduled time
+func (s *CheckScheduler) Tick() {
+	now := s.now()
+
+	watchlists, err := s.watchlists.ListDue(now.UTC())
+
+	if err != nil {
+		logrus.Errorf("Failed to get watchlists [%v]", err)
+		return
+	}
+
+	var due []model.Watchlist
+
+	for _, watchlist := range watchlists {
+		if watchlist.NextCheckAt == nil {
+			s.scheduleNext(&watchlist, now)
+			continue
+		}
+
+		if !watchlist.NextCheckAt.After(now) {
+			due = append(due, watchlist)
+		}
+	}
+
+	alertsDue := !s.nextAlertCheck.IsZero() && !s.nextAlertCheck.After(now)
+
+	if len(due) == 0 && !alertsDue {
+		if s.nextAlertCheck.IsZero() {
+			s.nextAlertCheck, _ = s.calendar.NextCheck(nil, now)
+		}
+		return
+	}
+
+	_, err = s.notifier.RunDue(due, alertsDue)
+
+	if err != nil {
+		logrus.Warnln(err)
+		return
+	}
+
+	if alertsDue {
+		s.nextAlertCheck, _ = s.calendar.NextCheck(nil, now)
+	}
+
+	for i := range due {
+		s.scheduleNext(&due[i], now)
+	}
+}
+
+func (s *CheckScheduler) scheduleNext(watchlist *model.Watchlist, now time.Time) {
+	log := logrus.WithField("watchlistId", watchlist.ID)
+
+	next, err := s.calendar.NextCheck(watchlist.Schedule, now)
+
+	//the watchlist is not listed as due again until the end of the lookahead, instead of failing on every tick
+	if err != nil {
+		log.Errorf("Failed to schedule next check [%v]", err)
+		next = now.Add(maxScheduleLookahead)
+	}
+
+	if err := s.watchlists.UpdateNextCheck(watchlist.ID, next.UTC()); err != nil {
+		log.Errorf("Failed to update next check [%v]", err)
+	}
+}
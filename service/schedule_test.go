@@ -0,0 +1,198 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/nagymarci/stock-watchlist/model"
+)
+
+type mockScheduledWatchlists struct {
+	watchlists []model.Watchlist
+	next       map[primitive.ObjectID]time.Time
+}
+
+func (m *mockScheduledWatchlists) ListDue(now time.Time) ([]model.Watchlist, error) {
+	var result []model.Watchlist
+	for _, watchlist := range m.watchlists {
+		if watchlist.NextCheckAt == nil || !watchlist.NextCheckAt.After(now) {
+			result = append(result, watchlist)
+		}
+	}
+	return result, nil
+}
+
+func (m *mockScheduledWatchlists) UpdateNextCheck(id primitive.ObjectID, next time.Time) error {
+	m.next[id] = next
+	return nil
+}
+
+type mockDueRunner struct {
+	watchlists  []model.Watchlist
+	priceAlerts bool
+	runs        int
+}
+
+func (m *mockDueRunner) RunDue(watchlists []model.Watchlist, priceAlerts bool) (model.NotifierRunStats, error) {
+	m.watchlists = watchlists
+	m.priceAlerts = priceAlerts
+	m.runs++
+	return model.NotifierRunStats{}, nil
+}
+
+func TestNextCheck(t *testing.T) {
+	calendar := NewUSCalendar()
+	newYork := calendar.Location()
+
+	//Wednesday before Thanksgiving, 18:30
+	after := time.Date(2021, 11, 24, 18, 30, 0, 0, newYork)
+
+	tests := []struct {
+		name     string
+		schedule *model.CheckSchedule
+		expected time.Time
+	}{
+		{"hourly skips the holiday", nil, time.Date(2021, 11, 26, 8, 0, 0, 0, newYork)},
+		{"market open", &model.CheckSchedule{Kind: model.ScheduleMarketOpen}, time.Date(2021, 11, 26, 9, 30, 0, 0, newYork)},
+		{"market close follows the early close", &model.CheckSchedule{Kind: model.ScheduleMarketClose}, time.Date(2021, 11, 26, 13, 0, 0, 0, newYork)},
+		{"daily skips the holiday", &model.CheckSchedule{Kind: model.ScheduleDaily, Time: "07:15"}, time.Date(2021, 11, 26, 7, 15, 0, 0, newYork)},
+		{"daily in time zone", &model.CheckSchedule{Kind: model.ScheduleDaily, Time: "07:15", TimeZone: "Europe/Budapest"}, time.Date(2021, 11, 26, 7, 15, 0, 0, time.FixedZone("CET", 3600))},
+		{"cron", &model.CheckSchedule{Kind: model.ScheduleCron, Cron: "0 12 * * MON"}, time.Date(2021, 11, 29, 12, 0, 0, 0, newYork)},
+		{"cron skips the holiday", &model.CheckSchedule{Kind: model.ScheduleCron, Cron: "0 12 * * THU"}, time.Date(2021, 12, 2, 12, 0, 0, 0, newYork)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			next, err := calendar.NextCheck(tt.schedule, after)
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !next.Equal(tt.expected) {
+				t.Fatalf("unexpected next check [%v], expected [%v]", next, tt.expected)
+			}
+		})
+	}
+}
+
+func TestNextCheckHourly(t *testing.T) {
+	calendar, err := newCalendar(model.MarketCalendar{Exchange: "NSE", TimeZone: "Asia/Kolkata", Open: "09:15", Close: "15:30"}, func(year int) []model.MarketHoliday { return nil })
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	kolkata := calendar.Location()
+
+	next, err := calendar.NextCheck(nil, time.Date(2021, 11, 22, 10, 10, 0, 0, kolkata).UTC())
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if expected := time.Date(2021, 11, 22, 11, 0, 0, 0, kolkata); !next.Equal(expected) {
+		t.Fatalf("unexpected next check [%v], expected [%v]", next, expected)
+	}
+}
+
+func TestValidateCheckSchedule(t *testing.T) {
+	calendar := NewUSCalendar()
+
+	valid := []model.CheckSchedule{
+		{Kind: model.ScheduleHourly},
+		{Kind: model.ScheduleMarketClose},
+		{Kind: model.ScheduleDaily, Time: "17:00", TimeZone: "Europe/London"},
+		{Kind: model.ScheduleCron, Cron: "*/30 9-16 * * MON-FRI"},
+	}
+
+	for _, schedule := range valid {
+		if err := calendar.ValidateCheckSchedule(&schedule); err != nil {
+			t.Fatalf("unexpected error for [%+v]: [%v]", schedule, err)
+		}
+	}
+
+	invalid := []model.CheckSchedule{
+		{Kind: "weekly"},
+		{Kind: model.ScheduleDaily, Time: "5pm"},
+		{Kind: model.ScheduleDaily, Time: "17:00", TimeZone: "Mars/Olympus"},
+		{Kind: model.ScheduleCron, Cron: "every day"},
+		{Kind: model.ScheduleCron, Cron: "* * * * *"},
+		{Kind: model.ScheduleCron, Cron: "CRON_TZ=UTC 0 * * * *"},
+		{Kind: model.ScheduleCron, Cron: "0 10 * * SAT"},
+	}
+
+	for _, schedule := range invalid {
+		if err := calendar.ValidateCheckSchedule(&schedule); err == nil {
+			t.Fatalf("expected error for [%+v]", schedule)
+		}
+	}
+}
+
+func TestCheckScheduler(t *testing.T) {
+	calendar := NewUSCalendar()
+	now := time.Date(2021, 11, 24, 10, 0, 0, 0, calendar.Location())
+
+	past := now.Add(-time.Minute)
+	future := now.Add(time.Hour)
+
+	due := model.Watchlist{ID: primitive.NewObjectID(), NextCheckAt: &past, Schedule: &model.CheckSchedule{Kind: model.ScheduleMarketClose}}
+	later := model.Watchlist{ID: primitive.NewObjectID(), NextCheckAt: &future}
+	unscheduled := model.Watchlist{ID: primitive.NewObjectID()}
+
+	watchlists := &mockScheduledWatchlists{watchlists: []model.Watchlist{due, later, unscheduled}, next: make(map[primitive.ObjectID]time.Time)}
+	runner := &mockDueRunner{}
+
+	scheduler := NewCheckScheduler(watchlists, runner, calendar)
+	scheduler.now = func() time.Time { return now }
+
+	t.Run("runs the due watchlists and schedules the next checks", func(t *testing.T) {
+		scheduler.Tick()
+
+		if runner.runs != 1 || len(runner.watchlists) != 1 || runner.watchlists[0].ID != due.ID || runner.priceAlerts {
+			t.Fatalf("unexpected run [%+v]", runner)
+		}
+
+		if next := watchlists.next[due.ID]; !next.Equal(time.Date(2021, 11, 24, 16, 0, 0, 0, calendar.Location())) {
+			t.Fatalf("unexpected next check [%v]", next)
+		}
+
+		if next := watchlists.next[unscheduled.ID]; !next.Equal(time.Date(2021, 11, 24, 11, 0, 0, 0, calendar.Location())) {
+			t.Fatalf("unexpected next check [%v]", next)
+		}
+
+		if _, ok := watchlists.next[later.ID]; ok {
+			t.Fatal("unexpected next check of watchlist not due")
+		}
+	})
+	t.Run("checks the price alerts hourly", func(t *testing.T) {
+		watchlists.watchlists = []model.Watchlist{later}
+		runner.runs = 0
+
+		scheduler.Tick()
+
+		if runner.runs != 0 {
+			t.Fatalf("unexpected run [%+v]", runner)
+		}
+
+		now = time.Date(2021, 11, 24, 11, 0, 0, 0, calendar.Location())
+
+		scheduler.Tick()
+
+		if runner.runs != 1 || len(runner.watchlists) != 1 || runner.watchlists[0].ID != later.ID || !runner.priceAlerts {
+			t.Fatalf("unexpected run [%+v]", runner)
+		}
+	})
+	t.Run("postpones the watchlists that cannot be scheduled", func(t *testing.T) {
+		weekends := model.Watchlist{ID: primitive.NewObjectID(), Schedule: &model.CheckSchedule{Kind: model.ScheduleCron, Cron: "0 10 * * SAT"}}
+		watchlists.watchlists = []model.Watchlist{weekends}
+
+		scheduler.Tick()
+
+		if next := watchlists.next[weekends.ID]; !next.Equal(now.Add(maxScheduleLookahead)) {
+			t.Fatalf("unexpected next check [%v]", next)
+		}
+	})
+}
@@ -0,0 +1,76 @@
+package service
+
+import (
+	"math"
+
+	"github.com/nagymarci/stock-watchlist/model"
+)
+
+//sensitivityStep is the change of the S&P500 yield and the expectation, in percentage points
+const sensitivityStep float64 = 1
+
+//Sensitivity returns the prices at which the price, dividend and PE signals of the stock turn green,
+//and how the opt-in price moves when the S&P500 dividend yield or the expectation changes
+func (ss *StockService) Sensitivity(stockInfo *model.StockData, expectedRaise float64, expectedReturn float64) model.StockSensitivity {
+	sp500DivYield := ss.sp500Client.GetSP500DivYield()
+
+	calculated := calculate(stockInfo, expectedRaise, expectedReturn, sp500DivYield)
+
+	result := model.StockSensitivity{
+		Ticker:     calculated.Ticker,
+		Price:      calculated.Price,
+		OptInPrice: calculated.OptInPrice,
+	}
+
+	result.PriceTarget = signalTarget(calculated.PriceStatus, calculated.OptInPrice, calculated.Price)
+
+	minOptInYield := calculateMinOptInYield(stockInfo.DividendYield5yr.Max, stockInfo.DividendYield5yr.Avg)
+	//the dividend target does not depend on the benchmark
+	dividendTargetStatus := calculated.DividendStatus
+	if dividendTargetStatus == model.MetricStaleBenchmark {
+		dividendTargetStatus = model.MetricOk
+	}
+	if minOptInYield <= 0 {
+		dividendTargetStatus = model.MetricInsufficientData
+	}
+	result.DividendTarget = signalTarget(dividendTargetStatus, calculated.AnnualDividend/minOptInYield*100, calculated.Price)
+
+	peTargetStatus := calculated.PeStatus
+	if calculated.OptInPe <= 0 {
+		peTargetStatus = model.MetricInsufficientData
+	}
+	result.PeTarget = signalTarget(peTargetStatus, calculated.OptInPe*stockInfo.Eps, calculated.Price)
+
+	if calculated.PriceStatus != model.MetricOk {
+		return result
+	}
+
+	shift := func(sp float64, raise float64) model.OptInPriceShift {
+		minYieldFromExpRaise := calculateMinYieldFromExpRaise(raise, expectedReturn)
+		optInYield, _ := calculateOptInYield(stockInfo.DividendYield5yr.Max, stockInfo.DividendYield5yr.Avg, sp, minYieldFromExpRaise)
+		optInPrice := calculateOptInPrice(optInYield, calculated.AnnualDividend, sp, minYieldFromExpRaise)
+
+		return model.OptInPriceShift{OptInPrice: optInPrice, Delta: optInPrice - calculated.OptInPrice}
+	}
+
+	result.Sp500Up = shift(sp500DivYield+sensitivityStep, expectedRaise)
+	result.Sp500Down = shift(math.Max(sp500DivYield-sensitivityStep, 0), expectedRaise)
+	result.ExpectationUp = shift(sp500DivYield, expectedRaise+sensitivityStep)
+	result.ExpectationDown = shift(sp500DivYield, expectedRaise-sensitivityStep)
+
+	return result
+}
+
+//signalTarget returns the target price, the signal is green below it
+func signalTarget(status string, targetPrice float64, price float64) model.SignalTarget {
+	if status != model.MetricOk {
+		return model.SignalTarget{Status: model.MetricInsufficientData}
+	}
+
+	return model.SignalTarget{
+		Status:       model.MetricOk,
+		TargetPrice:  targetPrice,
+		Delta:        targetPrice - price,
+		DeltaPercent: (targetPrice - price) / price * 100,
+	}
+}
@@ -0,0 +1,80 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/nagymarci/stock-watchlist/model"
+)
+
+func TestStockSensitivity(t *testing.T) {
+	t.Run("signals turn green below the target prices", func(t *testing.T) {
+		stockService := NewStockService(&mockSp500Client{})
+
+		stock := intcStockData()
+
+		result := stockService.Sensitivity(&stock, 5.5, 9.0)
+
+		colors := []struct {
+			name   string
+			target model.SignalTarget
+			color  func(model.CalculatedStockInfo) string
+		}{
+			{"price", result.PriceTarget, func(c model.CalculatedStockInfo) string { return c.PriceColor }},
+			{"dividend", result.DividendTarget, func(c model.CalculatedStockInfo) string { return c.DividendColor }},
+			{"pe", result.PeTarget, func(c model.CalculatedStockInfo) string { return c.PeColor }},
+		}
+
+		for _, c := range colors {
+			if c.target.Status != model.MetricOk {
+				t.Fatalf("expected [%s] target, got [%+v]", c.name, c.target)
+			}
+
+			below := intcStockData()
+			below.Price = c.target.TargetPrice * 0.999
+			if color := c.color(stockService.Calculate(&below, 5.5, 9.0)); color != "green" {
+				t.Errorf("expected [%s] green below [%f], got [%s]", c.name, c.target.TargetPrice, color)
+			}
+
+			above := intcStockData()
+			above.Price = c.target.TargetPrice * 1.001
+			if color := c.color(stockService.Calculate(&above, 5.5, 9.0)); color == "green" {
+				t.Errorf("expected [%s] not green above [%f]", c.name, c.target.TargetPrice)
+			}
+
+			if !almostEqual(c.target.Delta, c.target.TargetPrice-stock.Price) {
+				t.Errorf("expected [%s] delta from the current price, got [%+v]", c.name, c.target)
+			}
+		}
+	})
+	t.Run("opt-in price moves with the inputs", func(t *testing.T) {
+		stockService := NewStockService(&mockSp500Client{})
+
+		stock := intcStockData()
+
+		result := stockService.Sensitivity(&stock, 5.5, 9.0)
+
+		if !almostEqual(result.OptInPrice, 37.714285714285715) {
+			t.Fatalf("expected opt-in price [37.714285714285715], got [%f]", result.OptInPrice)
+		}
+
+		if result.Sp500Up.Delta > 0 || result.Sp500Down.Delta < 0 {
+			t.Errorf("expected opt-in price not to rise with higher S&P500 yield, got [%+v] [%+v]", result.Sp500Up, result.Sp500Down)
+		}
+
+		if result.ExpectationUp.Delta <= 0 || result.ExpectationDown.Delta >= 0 {
+			t.Errorf("expected opt-in price to rise with higher expectation, got [%+v] [%+v]", result.ExpectationUp, result.ExpectationDown)
+		}
+	})
+	t.Run("insufficient data has no target", func(t *testing.T) {
+		stockService := NewStockService(&mockSp500Client{})
+
+		stock := intcStockData()
+		stock.Eps = -1
+
+		result := stockService.Sensitivity(&stock, 5.5, 9.0)
+
+		if result.PeTarget != (model.SignalTarget{Status: model.MetricInsufficientData}) {
+			t.Errorf("expected insufficient pe target, got [%+v]", result.PeTarget)
+		}
+	})
+}
@@ -0,0 +1,109 @@
+package service
+
+import (
+	"fmt"
+
+	"github.com/nagymarci/stock-watchlist/model"
+)
+
+var signals = []string{model.SignalPrice, model.SignalDividend, model.SignalPe}
+
+var signalColors = []string{"green", "yellow", "red"}
+
+//ValidateSignalSubscriptions checks the signals and the colors of the subscriptions
+func ValidateSignalSubscriptions(subscriptions []model.SignalSubscription) error {
+	for _, subscription := range subscriptions {
+		if !contains(signals, subscription.Signal) {
+			return fmt.Errorf("Unknown signal [%s]", subscription.Signal)
+		}
+
+		if subscription.From == "" && subscription.To == "" {
+			return fmt.Errorf("Subscription to [%s] needs 'from' or 'to'", subscription.Signal)
+		}
+
+		for _, color := range []string{subscription.From, subscription.To} {
+			if color != "" && !contains(signalColors, color) {
+				return fmt.Errorf("Unknown color [%s]", color)
+			}
+		}
+
+		if subscription.From == subscription.To {
+			return fmt.Errorf("Subscription to [%s] never matches, 'from' and 'to' are both [%s]", subscription.Signal, subscription.From)
+		}
+	}
+
+	return nil
+}
+
+//SignalStateOf returns the colors of the signals of the stock
+func SignalStateOf(stock *model.CalculatedStockInfo) model.SignalState {
+	return model.SignalState{Price: stock.PriceColor, Dividend: stock.DividendColor, Pe: stock.PeColor}
+}
+
+func signalColorOf(state *model.SignalState, signal string) string {
+	switch signal {
+	case model.SignalPrice:
+		return state.Price
+	case model.SignalDividend:
+		return state.Dividend
+	case model.SignalPe:
+		return state.Pe
+	}
+	return ""
+}
+
+//SignalTransitions returns the signals of the stocks that changed color since the previous state.
+//Stocks without previous state have no transitions, their current state is the baseline
+func SignalTransitions(previous map[string]model.SignalState, calculated []model.CalculatedStockInfo) []model.SignalTransition {
+	var result []model.SignalTransition
+
+	for i := range calculated {
+		before, ok := previous[calculated[i].Ticker]
+
+		if !ok {
+			continue
+		}
+
+		after := SignalStateOf(&calculated[i])
+
+		for _, signal := range signals {
+			from := signalColorOf(&before, signal)
+			to := signalColorOf(&after, signal)
+
+			if from != to {
+				result = append(result, model.SignalTransition{Symbol: calculated[i].Ticker, Signal: signal, From: from, To: to})
+			}
+		}
+	}
+
+	return result
+}
+
+//SubscribedTransitions returns the transitions matching any of the subscriptions
+func SubscribedTransitions(transitions []model.SignalTransition, subscriptions []model.SignalSubscription) []model.SignalTransition {
+	var result []model.SignalTransition
+
+	for _, transition := range transitions {
+		for _, subscription := range subscriptions {
+			if subscription.Signal == transition.Signal &&
+				(subscription.From == "" || subscription.From == transition.From) &&
+				(subscription.To == "" || subscription.To == transition.To) {
+				result = append(result, transition)
+				break
+			}
+		}
+	}
+
+	return result
+}
+
+//transitionSymbols returns the symbols of the transitions, keeping their order
+func transitionSymbols(transitions []model.SignalTransition) []string {
+	var result []string
+
+	for _, transition := range transitions {
+		result = append(result, transition.Symbol)
+	}
+
+	return distinct(result)
+}
@@ -0,0 +1,70 @@
+package service
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/nagymarci/stock-watchlist/model"
+)
+
+func TestSignalTransitions(t *testing.T) {
+	previous := map[string]model.SignalState{
+		"INTC": {Price: "green", Dividend: "yellow", Pe: "green"},
+		"XOM":  {Price: "red", Dividend: "green", Pe: "yellow"},
+	}
+
+	calculated := []model.CalculatedStockInfo{
+		{Ticker: "INTC", PriceColor: "green", DividendColor: "green", PeColor: "yellow"},
+		{Ticker: "XOM", PriceColor: "red", DividendColor: "green", PeColor: "yellow"},
+		{Ticker: "KO", PriceColor: "green", DividendColor: "green", PeColor: "green"},
+	}
+
+	transitions := SignalTransitions(previous, calculated)
+
+	expected := []model.SignalTransition{
+		{Symbol: "INTC", Signal: model.SignalDividend, From: "yellow", To: "green"},
+		{Symbol: "INTC", Signal: model.SignalPe, From: "green", To: "yellow"},
+	}
+
+	if !reflect.DeepEqual(transitions, expected) {
+		t.Fatalf("unexpected transitions [%+v]", transitions)
+	}
+
+	t.Run("filters by subscription", func(t *testing.T) {
+		tests := []struct {
+			subscription model.SignalSubscription
+			expected     []model.SignalTransition
+		}{
+			{model.SignalSubscription{Signal: model.SignalDividend, To: "green"}, expected[:1]},
+			{model.SignalSubscription{Signal: model.SignalPe, From: "green"}, expected[1:]},
+			{model.SignalSubscription{Signal: model.SignalPe, To: "green"}, nil},
+			{model.SignalSubscription{Signal: model.SignalPrice, From: "green"}, nil},
+		}
+
+		for _, tt := range tests {
+			if result := SubscribedTransitions(transitions, []model.SignalSubscription{tt.subscription}); !reflect.DeepEqual(result, tt.expected) {
+				t.Fatalf("unexpected transitions [%+v] for [%+v]", result, tt.subscription)
+			}
+		}
+	})
+	t.Run("validates subscriptions", func(t *testing.T) {
+		valid := []model.SignalSubscription{{Signal: model.SignalPe, From: "green"}, {Signal: model.SignalDividend, From: "red", To: "green"}}
+
+		if err := ValidateSignalSubscriptions(valid); err != nil {
+			t.Fatal(err)
+		}
+
+		invalid := []model.SignalSubscription{
+			{Signal: "eps", To: "green"},
+			{Signal: model.SignalPe},
+			{Signal: model.SignalPe, To: "blue"},
+			{Signal: model.SignalPe, From: "green", To: "green"},
+		}
+
+		for _, subscription := range invalid {
+			if err := ValidateSignalSubscriptions([]model.SignalSubscription{subscription}); err == nil {
+				t.Fatalf("expected error for [%+v]", subscription)
+			}
+		}
+	})
+}
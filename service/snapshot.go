@@ -0,0 +1,107 @@
+package service
+
+//go:generate $GOPATH/bin/mockgen -source=snapshot.go -destination=mocks/mock_snapshot-deps.go -package=mocks
+import (
+	"context"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	userprofileModel "github.com/nagymarci/stock-user-profile/model"
+	"github.com/nagymarci/stock-watchlist/model"
+)
+
+const (
+	defaultExpectation    float64 = 9.0
+	defaultExpectedReturn float64 = 9.0
+)
+
+//DefaultUserprofile returns the userprofile used when the user's own profile is not available
+func DefaultUserprofile() userprofileModel.Userprofile {
+	expectation := defaultExpectation
+	expectedReturn := defaultExpectedReturn
+
+	return userprofileModel.Userprofile{DefaultExpectation: &expectation, ExpectedReturn: &expectedReturn}
+}
+
+type snapshotSaver interface {
+	Save(snapshot model.StockSnapshot) error
+}
+
+type stockLister interface {
+	GetAll(ctx context.Context) ([]model.StockData, error)
+}
+
+type stockCalculator interface {
+	Calculate(stockInfo *model.StockData, expectedRaise float64, expectedReturn float64) model.CalculatedStockInfo
+}
+
+type Snapshotter struct {
+	snapshots    snapshotSaver
+	stockClient  stockLister
+	sp500Client  sP500Client
+	stockService stockCalculator
+	location     *time.Location
+	now          func() time.Time
+}
+
+func NewSnapshotter(s snapshotSaver, sc stockLister, sp sP500Client, ss stockCalculator) *Snapshotter {
+	location, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		logrus.Errorf("Failed to load location, using UTC for snapshot dates [%v]", err)
+		location = time.UTC
+	}
+
+	return &Snapshotter{
+		snapshots:    s,
+		stockClient:  sc,
+		sp500Client:  sp,
+		stockService: ss,
+		location:     location,
+		now:          time.Now,
+	}
+}
+
+//TakeSnapshots saves the current stock data and the calculated information
+//of every registered stock for today, calculated with the default expectations
+func (s *Snapshotter) TakeSnapshots() {
+	stocks, err := s.stockClient.GetAll(context.Background())
+
+	if err != nil {
+		logrus.Errorf("Failed to get stocks for snapshot [%v]", err)
+		return
+	}
+
+	date := SnapshotDate(s.now(), s.location)
+	sp500DivYield := s.sp500Client.GetSP500DivYield()
+
+	saved := 0
+	for _, stock := range stocks {
+		log := logrus.WithField("symbol", stock.Ticker)
+
+		snapshot := model.StockSnapshot{
+			Symbol:        stock.Ticker,
+			Date:          date,
+			Sp500DivYield: sp500DivYield,
+			Data:          stock,
+			Calculated:    s.stockService.Calculate(&stock, defaultExpectation, defaultExpectedReturn),
+		}
+
+		err := s.snapshots.Save(snapshot)
+
+		if err != nil {
+			log.Errorf("Failed to save snapshot [%v]", err)
+			continue
+		}
+
+		saved++
+	}
+
+	logrus.Infof("Saved [%d] snapshots for [%s]", saved, date.Format("2006-01-02"))
+}
+
+//SnapshotDate returns the day of t in the given location, as midnight UTC
+func SnapshotDate(t time.Time, location *time.Location) time.Time {
+	local := t.In(location)
+	return time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, time.UTC)
+}
@@ -0,0 +1,101 @@
+package service
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+
+	"github.com/nagymarci/stock-watchlist/model"
+	"github.com/nagymarci/stock-watchlist/service/mocks"
+)
+
+func TestSnapshotDate(t *testing.T) {
+	newYork, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		name     string
+		time     time.Time
+		expected time.Time
+	}{
+		{"same day", time.Date(2021, 3, 5, 21, 30, 0, 0, time.UTC), time.Date(2021, 3, 5, 0, 0, 0, 0, time.UTC)},
+		{"previous day before midnight in new york", time.Date(2021, 3, 6, 2, 0, 0, 0, time.UTC), time.Date(2021, 3, 5, 0, 0, 0, 0, time.UTC)},
+		{"daylight saving time", time.Date(2021, 7, 6, 3, 59, 0, 0, time.UTC), time.Date(2021, 7, 5, 0, 0, 0, 0, time.UTC)},
+		{"after midnight in new york", time.Date(2021, 7, 6, 4, 0, 0, 0, time.UTC), time.Date(2021, 7, 6, 0, 0, 0, 0, time.UTC)},
+		{"year boundary", time.Date(2021, 1, 1, 3, 0, 0, 0, time.UTC), time.Date(2020, 12, 31, 0, 0, 0, 0, time.UTC)},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if date := SnapshotDate(c.time, newYork); !date.Equal(c.expected) || date.Location() != time.UTC {
+				t.Fatalf("expected [%v], got [%v]", c.expected, date)
+			}
+		})
+	}
+}
+
+func TestSnapshotter(t *testing.T) {
+	now := time.Date(2021, 3, 6, 2, 0, 0, 0, time.UTC)
+	date := time.Date(2021, 3, 5, 0, 0, 0, 0, time.UTC)
+
+	newSnapshotter := func(ctrl *gomock.Controller) (*Snapshotter, *mocks.MocksnapshotSaver, *mocks.MockstockLister, *mocks.MockstockCalculator) {
+		snapshots := mocks.NewMocksnapshotSaver(ctrl)
+		stocks := mocks.NewMockstockLister(ctrl)
+		calculator := mocks.NewMockstockCalculator(ctrl)
+
+		snapshotter := NewSnapshotter(snapshots, stocks, &mockSp500Client{}, calculator)
+		snapshotter.now = func() time.Time { return now }
+
+		return snapshotter, snapshots, stocks, calculator
+	}
+
+	t.Run("saves every stock with the default expectations", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		snapshotter, snapshots, stocks, calculator := newSnapshotter(ctrl)
+
+		intc := intcStockData()
+		xom := model.StockData{Ticker: "XOM", Price: 40}
+
+		stocks.EXPECT().GetAll(gomock.Any()).Return([]model.StockData{intc, xom}, nil)
+		calculator.EXPECT().Calculate(&intc, defaultExpectation, defaultExpectedReturn).Return(model.CalculatedStockInfo{Ticker: "INTC"})
+		calculator.EXPECT().Calculate(&xom, defaultExpectation, defaultExpectedReturn).Return(model.CalculatedStockInfo{Ticker: "XOM"})
+		snapshots.EXPECT().Save(model.StockSnapshot{Symbol: "INTC", Date: date, Sp500DivYield: 1, Data: intc, Calculated: model.CalculatedStockInfo{Ticker: "INTC"}}).Return(nil)
+		snapshots.EXPECT().Save(model.StockSnapshot{Symbol: "XOM", Date: date, Sp500DivYield: 1, Data: xom, Calculated: model.CalculatedStockInfo{Ticker: "XOM"}}).Return(nil)
+
+		snapshotter.TakeSnapshots()
+	})
+	t.Run("saves nothing if the stocks cannot be fetched", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		snapshotter, snapshots, stocks, calculator := newSnapshotter(ctrl)
+
+		stocks.EXPECT().GetAll(gomock.Any()).Return(nil, errors.New("unavailable"))
+		calculator.EXPECT().Calculate(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+		snapshots.EXPECT().Save(gomock.Any()).Times(0)
+
+		snapshotter.TakeSnapshots()
+	})
+	t.Run("continues after a failed save", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		snapshotter, snapshots, stocks, calculator := newSnapshotter(ctrl)
+
+		stocks.EXPECT().GetAll(gomock.Any()).Return([]model.StockData{{Ticker: "INTC"}, {Ticker: "XOM"}}, nil)
+		calculator.EXPECT().Calculate(gomock.Any(), gomock.Any(), gomock.Any()).Return(model.CalculatedStockInfo{}).Times(2)
+
+		gomock.InOrder(
+			snapshots.EXPECT().Save(gomock.Any()).Return(errors.New("write failed")),
+			snapshots.EXPECT().Save(gomock.Any()).Return(nil),
+		)
+
+		snapshotter.TakeSnapshots()
+	})
+}
@@ -29,22 +29,52 @@ const (
 	minOptInYieldWeight          float64 = 0.4
 )
 
+//Validate classifies the stock data and the benchmark used for the calculation.
+//It returns every issue found ordered by severity, or StockDataValid if there is none
+func Validate(stockInfo *model.StockData, sp500DivYield float64) []string {
+	var result []string
+
+	if !(stockInfo.Price > 0) {
+		result = append(result, model.StockDataZeroPrice)
+	}
+
+	if !(sp500DivYield > 0) {
+		result = append(result, model.StockDataStaleBenchmark)
+	}
+
+	if !(stockInfo.Eps > 0) {
+		result = append(result, model.StockDataNegativeEps)
+	}
+
+	if !(stockInfo.Dividend > 0) {
+		result = append(result, model.StockDataMissingDividend)
+	}
+
+	if len(result) == 0 {
+		result = append(result, model.StockDataValid)
+	}
+
+	return result
+}
+
 //Calculate returns the dynamically computed data from the latest information
 func (ss *StockService) Calculate(stockInfo *model.StockData, expectedRaise float64, expectedReturn float64) model.CalculatedStockInfo {
+	return calculate(stockInfo, expectedRaise, expectedReturn, ss.sp500Client.GetSP500DivYield())
+}
+
+func calculate(stockInfo *model.StockData, expectedRaise float64, expectedReturn float64, sp500DivYield float64) model.CalculatedStockInfo {
 	var result model.CalculatedStockInfo
 
-	sp500DivYield := ss.sp500Client.GetSP500DivYield()
+	issues := Validate(stockInfo, sp500DivYield)
 
-	minYieldFromExpRaise := expectedReturn - expectedRaise
-	if minYieldFromExpRaise <= 0.0 {
-		minYieldFromExpRaise = 0.1
-	}
+	minYieldFromExpRaise := calculateMinYieldFromExpRaise(expectedRaise, expectedReturn)
 
 	optInYield, minOptInYield := calculateOptInYield(stockInfo.DividendYield5yr.Max, stockInfo.DividendYield5yr.Avg, sp500DivYield, minYieldFromExpRaise)
 
 	optInPe := calculateOptInPe(stockInfo.PeRatio5yr.Min, stockInfo.PeRatio5yr.Avg)
 
 	result.Ticker = stockInfo.Ticker
+	result.Status = issues[0]
 	result.AnnualDividend = stockInfo.Dividend * defaultDividendPerYear
 
 	//TODO store this in DB with the other info for the given stock
@@ -52,24 +82,51 @@ func (ss *StockService) Calculate(stockInfo *model.StockData, expectedRaise floa
 		result.AnnualDividend = stockInfo.Dividend * monthlyDividendPerYear
 	}
 	result.Price = stockInfo.Price
-	result.DividendYield = result.AnnualDividend / result.Price * 100
-	result.CurrentPe = result.Price / stockInfo.Eps
-	if stockInfo.Eps == 0 {
-		result.CurrentPe = math.MaxFloat64
+
+	result.DividendColor = "blank"
+	result.DividendStatus = model.MetricInsufficientData
+	if !contains(issues, model.StockDataZeroPrice) && !contains(issues, model.StockDataMissingDividend) {
+		result.DividendYield = result.AnnualDividend / result.Price * 100
+		result.DividendColor = calculateDividendColor(result.DividendYield, minOptInYield, stockInfo.DividendYield5yr.Avg)
+		result.DividendStatus = model.MetricOk
+
+		//the opt-in yield is guarded by the benchmark, it is not reported without one
+		if contains(issues, model.StockDataStaleBenchmark) {
+			result.DividendStatus = model.MetricStaleBenchmark
+		} else {
+			result.OptInYield = optInYield
+		}
 	}
-	result.OptInYield = optInYield
-	result.DividendColor = calculateDividendColor(result.DividendYield, minOptInYield, stockInfo.DividendYield5yr.Avg)
-	result.OptInPe = optInPe
-	result.PeColor = calculatePeColor(result.CurrentPe, optInPe, stockInfo.PeRatio5yr.Avg)
 
-	optInPrice := calculateOptInPrice(optInYield, result.AnnualDividend, sp500DivYield, minYieldFromExpRaise)
+	result.PeColor = "blank"
+	result.PeStatus = model.MetricInsufficientData
+	if !contains(issues, model.StockDataZeroPrice) && !contains(issues, model.StockDataNegativeEps) {
+		result.CurrentPe = result.Price / stockInfo.Eps
+		result.OptInPe = optInPe
+		result.PeColor = calculatePeColor(result.CurrentPe, optInPe, stockInfo.PeRatio5yr.Avg)
+		result.PeStatus = model.MetricOk
+	}
 
-	result.OptInPrice = optInPrice
-	result.PriceColor = calculatePriceColor(result.Price, optInPrice)
+	result.PriceColor = "blank"
+	result.PriceStatus = model.MetricInsufficientData
+	if !contains(issues, model.StockDataZeroPrice) && !contains(issues, model.StockDataMissingDividend) && !contains(issues, model.StockDataStaleBenchmark) {
+		result.OptInPrice = calculateOptInPrice(optInYield, result.AnnualDividend, sp500DivYield, minYieldFromExpRaise)
+		result.PriceColor = calculatePriceColor(result.Price, result.OptInPrice)
+		result.PriceStatus = model.MetricOk
+	}
 
 	return result
 }
 
+func calculateMinYieldFromExpRaise(expectedRaise float64, expectedReturn float64) float64 {
+	minYieldFromExpRaise := expectedReturn - expectedRaise
+	if minYieldFromExpRaise <= 0.0 {
+		minYieldFromExpRaise = 0.1
+	}
+
+	return minYieldFromExpRaise
+}
+
 func calculatePriceColor(price float64, optInPrice float64) string {
 	if price < optInPrice {
 		return "green"
@@ -1,6 +1,7 @@
 package service
 
 import (
+	"math"
 	"testing"
 
 	"github.com/nagymarci/stock-watchlist/model"
@@ -12,6 +13,35 @@ func (sp *mockSp500Client) GetSP500DivYield() float64 {
 	return 1.0
 }
 
+type staleSp500Client struct{}
+
+func (sp *staleSp500Client) GetSP500DivYield() float64 {
+	return 0
+}
+
+func intcStockData() model.StockData {
+	stock := model.StockData{}
+	stock.Ticker = "INTC"
+	stock.Dividend = 0.33
+	stock.Eps = 5.43
+	stock.Price = 49.28
+	stock.DividendYield5yr.Avg = 2.62
+	stock.DividendYield5yr.Max = 3.65
+	stock.PeRatio5yr.Avg = 14.89
+	stock.PeRatio5yr.Min = 8.79
+
+	return stock
+}
+
+func assertFinite(t *testing.T, result model.CalculatedStockInfo) {
+	t.Helper()
+	for _, value := range []float64{result.Price, result.OptInPrice, result.DividendYield, result.OptInYield, result.CurrentPe, result.OptInPe} {
+		if math.IsNaN(value) || math.IsInf(value, 0) {
+			t.Fatalf("expected finite values, got [%+v]", result)
+		}
+	}
+}
+
 func TestStockCalculate(t *testing.T) {
 	t.Run("math works", func(t *testing.T) {
 		stockService := NewStockService(&mockSp500Client{})
@@ -38,6 +68,10 @@ func TestStockCalculate(t *testing.T) {
 		expectedResult.DividendYield = 2.678571428571429
 		expectedResult.OptInYield = 3.5
 		expectedResult.DividendColor = "yellow"
+		expectedResult.Status = model.StockDataValid
+		expectedResult.PriceStatus = model.MetricOk
+		expectedResult.DividendStatus = model.MetricOk
+		expectedResult.PeStatus = model.MetricOk
 
 		result := stockService.Calculate(&stock, 5.5, 9.0)
 
@@ -46,4 +80,93 @@ func TestStockCalculate(t *testing.T) {
 		}
 
 	})
+	t.Run("zero price is insufficient data", func(t *testing.T) {
+		stockService := NewStockService(&mockSp500Client{})
+
+		stock := intcStockData()
+		stock.Price = 0
+
+		result := stockService.Calculate(&stock, 5.5, 9.0)
+
+		assertFinite(t, result)
+
+		if result.Status != model.StockDataZeroPrice {
+			t.Errorf("expected [%s], got [%s]", model.StockDataZeroPrice, result.Status)
+		}
+
+		if result.PriceStatus != model.MetricInsufficientData || result.DividendStatus != model.MetricInsufficientData || result.PeStatus != model.MetricInsufficientData {
+			t.Errorf("expected every metric to be insufficient, got [%+v]", result)
+		}
+
+		if result.PriceColor == "green" || result.DividendColor == "green" || result.PeColor == "green" {
+			t.Errorf("expected no green color, got [%+v]", result)
+		}
+	})
+	t.Run("stale benchmark affects price and opt-in yield", func(t *testing.T) {
+		stockService := NewStockService(&staleSp500Client{})
+
+		stock := intcStockData()
+
+		result := stockService.Calculate(&stock, 5.5, 9.0)
+
+		assertFinite(t, result)
+
+		if result.Status != model.StockDataStaleBenchmark {
+			t.Errorf("expected [%s], got [%s]", model.StockDataStaleBenchmark, result.Status)
+		}
+
+		if result.PriceStatus != model.MetricInsufficientData || result.OptInPrice != 0 {
+			t.Errorf("expected insufficient price, got [%+v]", result)
+		}
+
+		if result.DividendStatus != model.MetricStaleBenchmark || result.OptInYield != 0 || result.DividendYield == 0 {
+			t.Errorf("expected stale dividend without opt-in yield, got [%+v]", result)
+		}
+
+		if result.PeStatus != model.MetricOk {
+			t.Errorf("expected pe to be calculated, got [%+v]", result)
+		}
+	})
+	t.Run("negative eps only affects pe", func(t *testing.T) {
+		stockService := NewStockService(&mockSp500Client{})
+
+		stock := intcStockData()
+		stock.Eps = -1.2
+
+		result := stockService.Calculate(&stock, 5.5, 9.0)
+
+		if result.Status != model.StockDataNegativeEps {
+			t.Errorf("expected [%s], got [%s]", model.StockDataNegativeEps, result.Status)
+		}
+
+		if result.PeStatus != model.MetricInsufficientData || result.PeColor != "blank" || result.CurrentPe != 0 {
+			t.Errorf("expected insufficient pe, got [%+v]", result)
+		}
+
+		if result.PriceStatus != model.MetricOk || result.DividendStatus != model.MetricOk {
+			t.Errorf("expected price and dividend to be calculated, got [%+v]", result)
+		}
+	})
+	t.Run("missing dividend affects price and dividend", func(t *testing.T) {
+		stockService := NewStockService(&mockSp500Client{})
+
+		stock := intcStockData()
+		stock.Dividend = 0
+
+		result := stockService.Calculate(&stock, 5.5, 9.0)
+
+		assertFinite(t, result)
+
+		if result.Status != model.StockDataMissingDividend {
+			t.Errorf("expected [%s], got [%s]", model.StockDataMissingDividend, result.Status)
+		}
+
+		if result.PriceStatus != model.MetricInsufficientData || result.DividendStatus != model.MetricInsufficientData {
+			t.Errorf("expected insufficient price and dividend, got [%+v]", result)
+		}
+
+		if result.PeStatus != model.MetricOk {
+			t.Errorf("expected pe to be calculated, got [%+v]", result)
+		}
+	})
 }
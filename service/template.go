@@ -0,0 +1,358 @@
+package service
+
+import (
+	"bytes"
+	"fmt"
+	"html"
+	htmlTemplate "html/template"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	textTemplate "text/template"
+
+	"github.com/nagymarci/stock-watchlist/model"
+)
+
+//templateData is passed to the notification templates
+type templateData struct {
+	*model.Notification
+	AddedStocks   []model.CalculatedStockInfo
+	RemovedStocks []model.CalculatedStockInfo
+	CurrentStocks []model.CalculatedStockInfo
+	Unsubscribe   model.UnsubscribeLinks
+}
+
+const textHelpers = `{{define "row"}}{{t "stock.row" .Ticker (money .Price) (money .OptInPrice) (percent .DividendYield) (colorName .PriceColor) (colorName .DividendColor) (colorName .PeColor)}}{{end}}
+{{define "transition"}}{{t "stock.transition" .Symbol (signalName .Signal) (colorName .To) (colorName .From)}}{{end}}
+{{define "footer"}}{{with .Unsubscribe.Watchlist}}
+{{t "footer.watchlist"}}: {{.}}{{end}}{{with .Unsubscribe.All}}
+{{t "footer.all"}}: {{.}}
+{{end}}{{end}}` + headingHelper
+
+const htmlHelpers = `{{define "table"}}<table cellpadding="6" style="border-collapse:collapse">
+<tr><th align="left">{{t "table.ticker"}}</th><th align="right">{{t "table.price"}}</th><th align="right">{{t "table.optInPrice"}}</th><th align="right">{{t "table.yield"}}</th><th>{{t "table.price"}}</th><th>{{t "table.dividend"}}</th><th>{{t "table.pe"}}</th></tr>
+{{range .}}<tr><td><b>{{.Ticker}}</b></td><td align="right">{{money .Price}}</td><td align="right">{{money .OptInPrice}}</td><td align="right">{{percent .DividendYield}}</td><td style="{{color .PriceColor}}">{{colorName .PriceColor}}</td><td style="{{color .DividendColor}}">{{colorName .DividendColor}}</td><td style="{{color .PeColor}}">{{colorName .PeColor}}</td></tr>
+{{end}}</table>{{end}}
+{{define "transitions"}}<table cellpadding="6" style="border-collapse:collapse">
+<tr><th align="left">{{t "table.ticker"}}</th><th align="left">{{t "table.signal"}}</th><th>{{t "table.from"}}</th><th>{{t "table.to"}}</th></tr>
+{{range .}}<tr><td><b>{{.Symbol}}</b></td><td>{{signalName .Signal}}</td><td style="{{color .From}}">{{colorName .From}}</td><td style="{{color .To}}">{{colorName .To}}</td></tr>
+{{end}}</table>{{end}}
+{{define "footer"}}{{if or .Unsubscribe.Watchlist .Unsubscribe.All}}<p style="font-size:small;color:#777">
+{{with .Unsubscribe.Watchlist}}<a href="{{.}}">{{t "footer.watchlist"}}</a>{{end}}
+{{with .Unsubscribe.All}}<a href="{{.}}">{{t "footer.all"}}</a>{{end}}
+</p>{{end}}{{end}}` + headingHelper
+
+const headingHelper = `{{define "heading"}}{{if .WatchlistName}}{{.WatchlistName}}{{if .AlertName}} - {{t "digest.alert" .AlertName}}{{end}}{{else}}{{.AlertName}}{{end}}{{end}}`
+
+//The default templates take their texts from the catalog of the locale with the t function,
+//html templates can emphasize the arguments with bold
+var defaultSubjectTemplates = map[string]string{
+	model.NotificationChange: `{{t "change.subject" .WatchlistName}}`,
+	model.NotificationAlert:  `{{t "alert.subject" .WatchlistName .AlertName}}`,
+	model.NotificationDigest: `{{t "digest.subject"}}`,
+	model.NotificationPrice:  `{{t "priceAlert.subject" .AlertName}}`,
+	model.NotificationSignal: `{{t "signal.subject" .WatchlistName}}`,
+}
+
+var defaultTextTemplates = map[string]string{
+	model.NotificationChange: `{{t "change.intro" .WatchlistName}}
+{{if .RemovedStocks}}
+{{t "change.removed"}}:
+{{range .RemovedStocks}}  {{template "row" .}}
+{{end}}{{end}}{{if .AddedStocks}}
+{{t "change.added"}}:
+{{range .AddedStocks}}  {{template "row" .}}
+{{end}}{{end}}
+{{t "change.current" (join .Current)}}
+{{template "footer" .}}`,
+	model.NotificationAlert: `{{t "alert.intro" .AlertName .WatchlistName}}
+{{if .RemovedStocks}}
+{{t "alert.removed"}}:
+{{range .RemovedStocks}}  {{template "row" .}}
+{{end}}{{end}}{{if .AddedStocks}}
+{{t "alert.added"}}:
+{{range .AddedStocks}}  {{template "row" .}}
+{{end}}{{end}}
+{{t "alert.current" (join .Current)}}
+{{template "footer" .}}`,
+	model.NotificationDigest: `{{t "digest.intro"}}
+{{range .Changes}}
+{{template "heading" .}}
+{{if .Transitions}}{{range .Transitions}}  {{template "transition" .}}
+{{end}}{{else}}{{range stocks . .Removed}}  - {{template "row" .}}
+{{end}}{{range stocks . .Added}}  + {{template "row" .}}
+{{end}}  {{t "digest.current" (join .Current)}}
+{{end}}{{end}}{{template "footer" .}}`,
+	model.NotificationPrice: `{{t "priceAlert.intro" .AlertName}}
+{{range .AddedStocks}}
+{{template "row" .}}
+{{end}}{{template "footer" .}}`,
+	model.NotificationSignal: `{{t "signal.intro" .WatchlistName}}
+{{range .Transitions}}
+{{template "transition" .}}{{end}}
+
+{{t "signal.current"}}:
+{{range .AddedStocks}}  {{template "row" .}}
+{{end}}{{template "footer" .}}`,
+}
+
+var defaultHTMLTemplates = map[string]string{
+	model.NotificationChange: `<html><body>
+<p>{{t "change.intro" (bold .WatchlistName)}}</p>
+{{if .RemovedStocks}}<h3>{{t "change.removed"}}</h3>{{template "table" .RemovedStocks}}{{end}}
+{{if .AddedStocks}}<h3>{{t "change.added"}}</h3>{{template "table" .AddedStocks}}{{end}}
+<p>{{t "change.current" (join .Current)}}</p>
+{{template "footer" .}}
+</body></html>
+`,
+	model.NotificationAlert: `<html><body>
+<p>{{t "alert.intro" (bold .AlertName) (bold .WatchlistName)}}</p>
+{{if .RemovedStocks}}<h3>{{t "alert.removed"}}</h3>{{template "table" .RemovedStocks}}{{end}}
+{{if .AddedStocks}}<h3>{{t "alert.added"}}</h3>{{template "table" .AddedStocks}}{{end}}
+<p>{{t "alert.current" (join .Current)}}</p>
+{{template "footer" .}}
+</body></html>
+`,
+	model.NotificationDigest: `<html><body>
+<p>{{t "digest.intro"}}</p>
+{{range .Changes}}<h2>{{template "heading" .}}</h2>
+{{if .Transitions}}{{template "transitions" .Transitions}}
+{{else}}{{with stocks . .Removed}}<h3>{{t "digest.removed"}}</h3>{{template "table" .}}{{end}}
+{{with stocks . .Added}}<h3>{{t "digest.added"}}</h3>{{template "table" .}}{{end}}
+<p>{{t "digest.current" (join .Current)}}</p>
+{{end}}{{end}}{{template "footer" .}}
+</body></html>
+`,
+	model.NotificationPrice: `<html><body>
+<p>{{t "priceAlert.intro" (bold .AlertName)}}</p>
+{{template "table" .AddedStocks}}
+{{template "footer" .}}
+</body></html>
+`,
+	model.NotificationSignal: `<html><body>
+<p>{{t "signal.intro" (bold .WatchlistName)}}</p>
+{{template "transitions" .Transitions}}
+<h3>{{t "signal.current"}}</h3>{{template "table" .AddedStocks}}
+{{template "footer" .}}
+</body></html>
+`,
+}
+
+var colorStyles = map[string]string{
+	"green":  "background-color:#c8e6c9",
+	"yellow": "background-color:#fff9c4",
+	"red":    "background-color:#ffcdd2",
+}
+
+//textFuncs returns the template functions formatting in the locale
+func textFuncs(locale *Locale) map[string]interface{} {
+	return map[string]interface{}{
+		"t":       locale.T,
+		"number":  locale.Number,
+		"money":   locale.Money,
+		"percent": locale.Percent,
+		"colorName": func(color string) string {
+			return locale.Name("color", color)
+		},
+		"signalName": func(signal string) string {
+			return locale.Name("metric", signal)
+		},
+		"join": func(values []string) string {
+			if len(values) == 0 {
+				return locale.T("stock.none")
+			}
+			return strings.Join(values, ", ")
+		},
+		"color": func(color string) htmlTemplate.CSS {
+			return htmlTemplate.CSS(colorStyles[color])
+		},
+		"stocks": func(notification model.Notification, symbols []string) []model.CalculatedStockInfo {
+			return stocksOf(&notification, symbols)
+		},
+	}
+}
+
+//htmlFuncs returns the template functions of the html templates, where the messages are escaped
+//except for the arguments already being html
+func htmlFuncs(locale *Locale) map[string]interface{} {
+	funcs := textFuncs(locale)
+
+	funcs["t"] = func(key string, args ...interface{}) htmlTemplate.HTML {
+		escaped := make([]interface{}, len(args))
+		for i, arg := range args {
+			if h, ok := arg.(htmlTemplate.HTML); ok {
+				escaped[i] = string(h)
+			} else {
+				escaped[i] = html.EscapeString(fmt.Sprint(arg))
+			}
+		}
+
+		message, ok := locale.message(key)
+		if !ok {
+			message = key
+		}
+
+		if len(args) == 0 {
+			return htmlTemplate.HTML(html.EscapeString(message))
+		}
+
+		return htmlTemplate.HTML(fmt.Sprintf(html.EscapeString(message), escaped...))
+	}
+	funcs["bold"] = func(value string) htmlTemplate.HTML {
+		return htmlTemplate.HTML("<b>" + html.EscapeString(value) + "</b>")
+	}
+
+	return funcs
+}
+
+//templateSet holds the parsed templates of one locale by notification kind
+type templateSet struct {
+	subjects map[string]*textTemplate.Template
+	texts    map[string]*textTemplate.Template
+	htmls    map[string]*htmlTemplate.Template
+}
+
+//Renderer renders the notifications with text and html templates, in the locale of the notification
+type Renderer struct {
+	sets map[string]*templateSet
+}
+
+//NewRenderer parses the default templates for every locale. If dir is set, the templates found there override
+//the defaults, named <kind>.subject.txt, <kind>.txt and <kind>.html, e.g. digest.html. Templates in the
+//<locale> subdirectory, e.g. hu/digest.html, override them for that locale only
+func NewRenderer(dir string) (*Renderer, error) {
+	r := &Renderer{sets: make(map[string]*templateSet)}
+
+	for _, tag := range Locales() {
+		set, err := parseTemplates(dir, locales[tag])
+
+		if err != nil {
+			return nil, err
+		}
+
+		r.sets[tag] = set
+	}
+
+	return r, nil
+}
+
+func parseTemplates(dir string, locale *Locale) (*templateSet, error) {
+	set := &templateSet{
+		subjects: make(map[string]*textTemplate.Template),
+		texts:    make(map[string]*textTemplate.Template),
+		htmls:    make(map[string]*htmlTemplate.Template),
+	}
+
+	for kind := range defaultTextTemplates {
+		source, err := templateSource(dir, locale.Tag, kind+".subject.txt", defaultSubjectTemplates[kind])
+		if err != nil {
+			return nil, err
+		}
+		if set.subjects[kind], err = textTemplate.New(kind).Funcs(textFuncs(locale)).Parse(source); err != nil {
+			return nil, fmt.Errorf("Failed to parse subject template of [%s] in [%s]: [%v]", kind, locale.Tag, err)
+		}
+
+		source, err = templateSource(dir, locale.Tag, kind+".txt", defaultTextTemplates[kind])
+		if err != nil {
+			return nil, err
+		}
+		if set.texts[kind], err = textTemplate.New(kind).Funcs(textFuncs(locale)).Parse(textHelpers + source); err != nil {
+			return nil, fmt.Errorf("Failed to parse text template of [%s] in [%s]: [%v]", kind, locale.Tag, err)
+		}
+
+		source, err = templateSource(dir, locale.Tag, kind+".html", defaultHTMLTemplates[kind])
+		if err != nil {
+			return nil, err
+		}
+		if set.htmls[kind], err = htmlTemplate.New(kind).Funcs(htmlFuncs(locale)).Parse(htmlHelpers + source); err != nil {
+			return nil, fmt.Errorf("Failed to parse html template of [%s] in [%s]: [%v]", kind, locale.Tag, err)
+		}
+	}
+
+	return set, nil
+}
+
+//templateSource returns the template of the locale from dir, or the one shared by the locales,
+//or the default if neither exists
+func templateSource(dir string, locale string, name string, defaultSource string) (string, error) {
+	if dir == "" {
+		return defaultSource, nil
+	}
+
+	for _, path := range []string{filepath.Join(dir, locale, name), filepath.Join(dir, name)} {
+		content, err := ioutil.ReadFile(path)
+
+		if os.IsNotExist(err) {
+			continue
+		}
+
+		if err != nil {
+			return "", fmt.Errorf("Failed to read template [%s]: [%v]", path, err)
+		}
+
+		return string(content), nil
+	}
+
+	return defaultSource, nil
+}
+
+//Render renders the subject, the text and the html body of the notification, with the unsubscribe links in the footer
+func (r *Renderer) Render(notification *model.Notification, links model.UnsubscribeLinks) (model.RenderedMessage, error) {
+	result := model.RenderedMessage{Unsubscribe: links}
+
+	set := r.sets[LocaleOf(notification.Locale).Tag]
+
+	subject, ok := set.subjects[notification.Kind]
+	if !ok {
+		return result, fmt.Errorf("No template for notification kind [%s]", notification.Kind)
+	}
+
+	data := templateData{
+		Notification:  notification,
+		AddedStocks:   stocksOf(notification, notification.Added),
+		RemovedStocks: stocksOf(notification, notification.Removed),
+		CurrentStocks: stocksOf(notification, notification.Current),
+		Unsubscribe:   links,
+	}
+
+	var buffer bytes.Buffer
+	if err := subject.Execute(&buffer, data); err != nil {
+		return result, fmt.Errorf("Failed to render subject: [%v]", err)
+	}
+	result.Subject = strings.TrimSpace(buffer.String())
+
+	buffer.Reset()
+	if err := set.texts[notification.Kind].Execute(&buffer, data); err != nil {
+		return result, fmt.Errorf("Failed to render text: [%v]", err)
+	}
+	result.Text = buffer.String()
+
+	buffer.Reset()
+	if err := set.htmls[notification.Kind].Execute(&buffer, data); err != nil {
+		return result, fmt.Errorf("Failed to render html: [%v]", err)
+	}
+	result.HTML = buffer.String()
+
+	return result, nil
+}
+
+//stocksOf returns the calculated information of the symbols, stocks without information only have a ticker
+func stocksOf(notification *model.Notification, symbols []string) []model.CalculatedStockInfo {
+	var result []model.CalculatedStockInfo
+
+	for _, symbol := range symbols {
+		info := model.CalculatedStockInfo{Ticker: symbol}
+
+		for _, calc := range notification.Stocks {
+			if calc.Ticker == symbol {
+				info = calc
+				break
+			}
+		}
+
+		result = append(result, info)
+	}
+
+	return result
+}
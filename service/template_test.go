@@ -0,0 +1,272 @@
+package service
+
+import (
+	"io/ioutil"
+	"mime"
+	"mime/multipart"
+	netMail "net/mail"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nagymarci/stock-watchlist/model"
+)
+
+func testRenderer(t *testing.T) *Renderer {
+	renderer, err := NewRenderer("")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return renderer
+}
+
+func TestRenderer(t *testing.T) {
+	notification := model.Notification{
+		Kind:          model.NotificationChange,
+		WatchlistName: "dividend <growth>",
+		Removed:       []string{"XOM"},
+		Added:         []string{"INTC"},
+		Current:       []string{"INTC"},
+		Stocks: []model.CalculatedStockInfo{
+			{Ticker: "INTC", Price: 37, OptInPrice: 45.123, DividendYield: 3.57, PriceColor: "green", DividendColor: "yellow", PeColor: "red"},
+		},
+	}
+
+	t.Run("renders the default templates", func(t *testing.T) {
+		message, err := testRenderer(t).Render(&notification, model.UnsubscribeLinks{})
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if message.Subject != "dividend <growth> changed!" {
+			t.Fatalf("unexpected subject [%s]", message.Subject)
+		}
+
+		if !strings.Contains(message.Text, "INTC: price $37.00, opt-in price $45.12, yield 3.57%") || !strings.Contains(message.Text, "XOM: price $0.00") {
+			t.Fatalf("unexpected text [%s]", message.Text)
+		}
+
+		if !strings.Contains(message.HTML, "dividend &lt;growth&gt;") || !strings.Contains(message.HTML, colorStyles["green"]) {
+			t.Fatalf("unexpected html [%s]", message.HTML)
+		}
+	})
+	t.Run("uses the templates of the directory", func(t *testing.T) {
+		dir, err := ioutil.TempDir("", "templates")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer os.RemoveAll(dir)
+
+		ioutil.WriteFile(filepath.Join(dir, "change.subject.txt"), []byte("[{{.WatchlistName}}] {{len .Added}} new"), 0644)
+
+		renderer, err := NewRenderer(dir)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		message, err := renderer.Render(&notification, model.UnsubscribeLinks{})
+
+		if err != nil || message.Subject != "[dividend <growth>] 1 new" || message.HTML == "" {
+			t.Fatalf("unexpected message [%+v] [%v]", message, err)
+		}
+	})
+	t.Run("uses the templates of the locale directory", func(t *testing.T) {
+		dir, err := ioutil.TempDir("", "templates")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer os.RemoveAll(dir)
+
+		os.Mkdir(filepath.Join(dir, "hu"), 0755)
+		ioutil.WriteFile(filepath.Join(dir, "change.subject.txt"), []byte("shared"), 0644)
+		ioutil.WriteFile(filepath.Join(dir, "hu", "change.subject.txt"), []byte("{{money 1234.5}}"), 0644)
+
+		renderer, err := NewRenderer(dir)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		hungarian := notification
+		hungarian.Locale = "hu"
+
+		english, _ := renderer.Render(&notification, model.UnsubscribeLinks{})
+		localized, _ := renderer.Render(&hungarian, model.UnsubscribeLinks{})
+
+		if english.Subject != "shared" || localized.Subject != "1\u00a0234,50\u00a0USD" {
+			t.Fatalf("unexpected subjects [%s] [%s]", english.Subject, localized.Subject)
+		}
+	})
+	t.Run("renders in the locale of the notification", func(t *testing.T) {
+		hungarian := notification
+		hungarian.Locale = "hu-HU"
+
+		message, err := testRenderer(t).Render(&hungarian, model.UnsubscribeLinks{All: "https://example.com/unsubscribe?token=a"})
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if message.Subject != "Változott: dividend <growth>" {
+			t.Fatalf("unexpected subject [%s]", message.Subject)
+		}
+
+		if !strings.Contains(message.Text, "INTC: árfolyam 37,00\u00a0USD, belépési ár 45,12\u00a0USD, hozam 3,57%, ár/osztalék/pe zöld/sárga/piros") || !strings.Contains(message.Text, "Minden értesítés leállítása") {
+			t.Fatalf("unexpected text [%s]", message.Text)
+		}
+
+		if !strings.Contains(message.HTML, "Megváltoztak a(z) <b>dividend &lt;growth&gt;</b> profil ajánlásai.") || !strings.Contains(message.HTML, "<th align=\"left\">Részvény</th>") {
+			t.Fatalf("unexpected html [%s]", message.HTML)
+		}
+
+		price := model.Notification{Kind: model.NotificationPrice, AlertName: "INTC below 40", Added: []string{"INTC"}, Stocks: notification.Stocks, Locale: "hu"}
+
+		message, _ = testRenderer(t).Render(&price, model.UnsubscribeLinks{})
+
+		if message.Subject != "INTC below 40!" || !strings.Contains(message.Text, "Teljesült a(z) INTC below 40 árfolyamriasztás.") {
+			t.Fatalf("unexpected message [%+v]", message)
+		}
+	})
+	t.Run("fails on invalid template", func(t *testing.T) {
+		dir, err := ioutil.TempDir("", "templates")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer os.RemoveAll(dir)
+
+		ioutil.WriteFile(filepath.Join(dir, "alert.html"), []byte("{{.AlertName"), 0644)
+
+		if _, err := NewRenderer(dir); err == nil {
+			t.Fatal("expected error")
+		}
+	})
+	t.Run("renders the changes of the digest", func(t *testing.T) {
+		digest := model.Notification{Kind: model.NotificationDigest, Changes: []model.Notification{notification}}
+
+		message, err := testRenderer(t).Render(&digest, model.UnsubscribeLinks{})
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !strings.Contains(message.Text, "  + INTC: price $37.00") || !strings.Contains(message.Text, "  - XOM") || !strings.Contains(message.HTML, "<h3>Added</h3>") {
+			t.Fatalf("unexpected message [%+v]", message)
+		}
+	})
+	t.Run("renders the unsubscribe links", func(t *testing.T) {
+		links := model.UnsubscribeLinks{Watchlist: "https://example.com/unsubscribe?token=w", All: "https://example.com/unsubscribe?token=a"}
+
+		message, err := testRenderer(t).Render(&notification, links)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !strings.Contains(message.Text, links.Watchlist) || !strings.Contains(message.Text, links.All) {
+			t.Fatalf("unexpected text [%s]", message.Text)
+		}
+
+		if !strings.Contains(message.HTML, `href="https://example.com/unsubscribe?token=w"`) || message.Unsubscribe != links {
+			t.Fatalf("unexpected html [%s]", message.HTML)
+		}
+
+		message, _ = testRenderer(t).Render(&notification, model.UnsubscribeLinks{})
+
+		if strings.Contains(message.Text, "Stop") || strings.Contains(message.HTML, "Stop") {
+			t.Fatalf("unexpected links [%s]", message.Text)
+		}
+	})
+	t.Run("renders the signal transitions", func(t *testing.T) {
+		signal := model.Notification{
+			Kind:          model.NotificationSignal,
+			WatchlistName: "dividend",
+			Added:         []string{"INTC"},
+			Stocks:        notification.Stocks,
+			Transitions:   []model.SignalTransition{{Symbol: "INTC", Signal: model.SignalDividend, From: "yellow", To: "green"}},
+		}
+
+		message, err := testRenderer(t).Render(&signal, model.UnsubscribeLinks{})
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if message.Subject != "dividend signals changed!" || !strings.Contains(message.Text, "INTC: dividend turned green, was yellow") {
+			t.Fatalf("unexpected message [%+v]", message)
+		}
+	})
+	t.Run("fails on unknown kind", func(t *testing.T) {
+		if _, err := testRenderer(t).Render(&model.Notification{Kind: "unknown"}, model.UnsubscribeLinks{}); err == nil {
+			t.Fatal("expected error")
+		}
+	})
+}
+
+func TestBuildMailMessage(t *testing.T) {
+	from := &netMail.Address{Name: "Stock Watchlist", Address: "watchlist@example.com"}
+	date := time.Date(2021, 3, 5, 16, 30, 0, 0, time.UTC)
+
+	raw, err := buildMailMessage(from, "alice@example.com", date, &model.RenderedMessage{Subject: "Árfolyam changed!", Text: "text body", HTML: "<p>html body</p>"})
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	message, err := netMail.ReadMessage(strings.NewReader(string(raw)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	subject, _ := new(mime.WordDecoder).DecodeHeader(message.Header.Get("Subject"))
+	if subject != "Árfolyam changed!" {
+		t.Fatalf("unexpected subject [%s]", subject)
+	}
+
+	if message.Header.Get("From") != `"Stock Watchlist" <watchlist@example.com>` || message.Header.Get("To") != "<alice@example.com>" {
+		t.Fatalf("unexpected addresses [%v]", message.Header)
+	}
+
+	if sent, err := message.Header.Date(); err != nil || !sent.Equal(date) {
+		t.Fatalf("unexpected date [%s]", message.Header.Get("Date"))
+	}
+
+	if id := message.Header.Get("Message-ID"); !strings.HasPrefix(id, "<") || !strings.HasSuffix(id, "@example.com>") {
+		t.Fatalf("unexpected message id [%s]", id)
+	}
+
+	mediaType, params, err := mime.ParseMediaType(message.Header.Get("Content-Type"))
+	if err != nil || mediaType != "multipart/alternative" {
+		t.Fatalf("unexpected content type [%s]", message.Header.Get("Content-Type"))
+	}
+
+	reader := multipart.NewReader(message.Body, params["boundary"])
+
+	var bodies []string
+	for {
+		part, err := reader.NextPart()
+		if err != nil {
+			break
+		}
+		content, _ := ioutil.ReadAll(part)
+		bodies = append(bodies, part.Header.Get("Content-Type")+": "+string(content))
+	}
+
+	if len(bodies) != 2 || bodies[0] != "text/plain; charset=UTF-8: text body" || bodies[1] != "text/html; charset=UTF-8: <p>html body</p>" {
+		t.Fatalf("unexpected parts [%v]", bodies)
+	}
+
+	if message.Header.Get("List-Unsubscribe") != "" {
+		t.Fatalf("unexpected header [%s]", message.Header.Get("List-Unsubscribe"))
+	}
+
+	raw, _ = buildMailMessage(from, "alice@example.com", date, &model.RenderedMessage{Subject: "changed", Text: "text", Unsubscribe: model.UnsubscribeLinks{Watchlist: "https://example.com/u?token=w", All: "https://example.com/u?token=a"}})
+	message, _ = netMail.ReadMessage(strings.NewReader(string(raw)))
+
+	if message.Header.Get("List-Unsubscribe") != "<https://example.com/u?token=w>" || message.Header.Get("List-Unsubscribe-Post") != "List-Unsubscribe=One-Click" {
+		t.Fatalf("unexpected headers [%v]", message.Header)
+	}
+}
@@ -0,0 +1,123 @@
+package service
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/url"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/nagymarci/stock-watchlist/model"
+)
+
+//defaultUnsubscribeTTL is how long the unsubscribe links of a notification are valid
+const defaultUnsubscribeTTL = 30 * 24 * time.Hour
+
+type unsubscribeClaims struct {
+	UserID      string `json:"u"`
+	WatchlistID string `json:"w,omitempty"`
+	ExpiresAt   int64  `json:"e"`
+}
+
+//UnsubscribeTokens signs and verifies the tokens of the unsubscribe links.
+//A token is the base64 encoded claims and their HMAC-SHA256 signature, separated by a dot
+type UnsubscribeTokens struct {
+	secret  []byte
+	baseURL string
+	ttl     time.Duration
+	now     func() time.Time
+}
+
+func NewUnsubscribeTokens(secret string, baseURL string) *UnsubscribeTokens {
+	return &UnsubscribeTokens{
+		secret:  []byte(secret),
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		ttl:     defaultUnsubscribeTTL,
+		now:     time.Now,
+	}
+}
+
+//Token returns a token disabling the notifications of the watchlist, or all of them if watchlistID is zero
+func (t *UnsubscribeTokens) Token(userID string, watchlistID primitive.ObjectID) string {
+	claims := unsubscribeClaims{UserID: userID, ExpiresAt: t.now().Add(t.ttl).Unix()}
+
+	if !watchlistID.IsZero() {
+		claims.WatchlistID = watchlistID.Hex()
+	}
+
+	payload, _ := json.Marshal(claims)
+	encoded := base64.RawURLEncoding.EncodeToString(payload)
+
+	return encoded + "." + base64.RawURLEncoding.EncodeToString(t.sign(encoded))
+}
+
+//Parse verifies the token, and returns the user and the watchlist it was issued for
+func (t *UnsubscribeTokens) Parse(token string) (string, primitive.ObjectID, error) {
+	parts := strings.Split(token, ".")
+
+	if len(parts) != 2 {
+		return "", primitive.NilObjectID, errors.New("Malformed unsubscribe token")
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[1])
+
+	if err != nil || !hmac.Equal(signature, t.sign(parts[0])) {
+		return "", primitive.NilObjectID, errors.New("Invalid unsubscribe token signature")
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(parts[0])
+
+	var claims unsubscribeClaims
+	if err != nil || json.Unmarshal(payload, &claims) != nil || claims.UserID == "" {
+		return "", primitive.NilObjectID, errors.New("Malformed unsubscribe token")
+	}
+
+	if t.now().Unix() > claims.ExpiresAt {
+		return "", primitive.NilObjectID, errors.New("Unsubscribe token expired")
+	}
+
+	if claims.WatchlistID == "" {
+		return claims.UserID, primitive.NilObjectID, nil
+	}
+
+	watchlistID, err := primitive.ObjectIDFromHex(claims.WatchlistID)
+
+	if err != nil {
+		return "", primitive.NilObjectID, errors.New("Malformed unsubscribe token")
+	}
+
+	return claims.UserID, watchlistID, nil
+}
+
+//Links returns the unsubscribe links of the notifications of the watchlist.
+//Notifications without watchlist only get the link disabling all of them
+func (t *UnsubscribeTokens) Links(userID string, watchlistID primitive.ObjectID) model.UnsubscribeLinks {
+	var links model.UnsubscribeLinks
+
+	if t == nil || userID == "" {
+		return links
+	}
+
+	links.All = t.link(t.Token(userID, primitive.NilObjectID))
+
+	if !watchlistID.IsZero() {
+		links.Watchlist = t.link(t.Token(userID, watchlistID))
+	}
+
+	return links
+}
+
+func (t *UnsubscribeTokens) link(token string) string {
+	return t.baseURL + "/unsubscribe?token=" + url.QueryEscape(token)
+}
+
+func (t *UnsubscribeTokens) sign(payload string) []byte {
+	mac := hmac.New(sha256.New, t.secret)
+	mac.Write([]byte(payload))
+	return mac.Sum(nil)
+}
@@ -0,0 +1,76 @@
+package service
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestUnsubscribeTokens(t *testing.T) {
+	now := time.Date(2021, 3, 1, 12, 0, 0, 0, time.UTC)
+	tokens := NewUnsubscribeTokens("secret", "https://watchlist.example.com/")
+	tokens.now = func() time.Time { return now }
+
+	watchlistID := primitive.NewObjectID()
+
+	t.Run("parses its own tokens", func(t *testing.T) {
+		userID, id, err := tokens.Parse(tokens.Token("auth0|user", watchlistID))
+
+		if err != nil || userID != "auth0|user" || id != watchlistID {
+			t.Fatalf("unexpected result [%s] [%s] [%v]", userID, id.Hex(), err)
+		}
+
+		userID, id, err = tokens.Parse(tokens.Token("auth0|user", primitive.NilObjectID))
+
+		if err != nil || userID != "auth0|user" || !id.IsZero() {
+			t.Fatalf("unexpected result [%s] [%s] [%v]", userID, id.Hex(), err)
+		}
+	})
+	t.Run("rejects tampered tokens", func(t *testing.T) {
+		token := tokens.Token("auth0|user", watchlistID)
+		other := NewUnsubscribeTokens("other", "")
+		other.now = tokens.now
+
+		forged := strings.Split(other.Token("auth0|victim", watchlistID), ".")[0] + "." + strings.Split(token, ".")[1]
+
+		for _, invalid := range []string{"", "garbage", forged, other.Token("auth0|user", watchlistID)} {
+			if _, _, err := tokens.Parse(invalid); err == nil {
+				t.Fatalf("expected error for [%s]", invalid)
+			}
+		}
+	})
+	t.Run("rejects expired tokens", func(t *testing.T) {
+		token := tokens.Token("auth0|user", watchlistID)
+
+		later := NewUnsubscribeTokens("secret", "")
+		later.now = func() time.Time { return now.Add(defaultUnsubscribeTTL + time.Second) }
+
+		if _, _, err := later.Parse(token); err == nil {
+			t.Fatal("expected error")
+		}
+	})
+	t.Run("builds links", func(t *testing.T) {
+		links := tokens.Links("auth0|user", watchlistID)
+
+		u, err := url.Parse(links.Watchlist)
+		if err != nil || u.Host != "watchlist.example.com" || u.Path != "/unsubscribe" {
+			t.Fatalf("unexpected link [%s]", links.Watchlist)
+		}
+
+		if _, id, err := tokens.Parse(u.Query().Get("token")); err != nil || id != watchlistID {
+			t.Fatalf("unexpected token [%s] [%v]", id.Hex(), err)
+		}
+
+		if links := tokens.Links("auth0|user", primitive.NilObjectID); links.Watchlist != "" || links.All == "" {
+			t.Fatalf("unexpected links [%+v]", links)
+		}
+
+		var disabled *UnsubscribeTokens
+		if links := disabled.Links("auth0|user", watchlistID); links.Watchlist != "" || links.All != "" {
+			t.Fatalf("unexpected links [%+v]", links)
+		}
+	})
+}
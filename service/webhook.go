@@ -0,0 +1,263 @@
+package service
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"syscall"
+	"time"
+
+	"github.com/nagymarci/stock-watchlist/model"
+)
+
+const (
+	minWebhookSecretLength = 16
+	webhookMaxAttempts     = 3
+	webhookTimeout         = 10 * time.Second
+
+	//WebhookSignatureHeader holds the hex encoded HMAC-SHA256 of "<timestamp>.<body>", prefixed with "sha256="
+	WebhookSignatureHeader = "X-Watchlist-Signature"
+	//WebhookTimestampHeader holds the unix time of the delivery
+	WebhookTimestampHeader = "X-Watchlist-Timestamp"
+)
+
+//webhookPayload carries the rendered message and the notification, with the stocks formatted in the locale of the user
+type webhookPayload struct {
+	Event        string              `json:"event"`
+	Subject      string              `json:"subject"`
+	Text         string              `json:"text"`
+	Locale       string              `json:"locale"`
+	SentAt       time.Time           `json:"sentAt"`
+	Notification *model.Notification `json:"notification"`
+	Formatted    []formattedStock    `json:"formatted,omitempty"`
+}
+
+//formattedStock holds the values of a stock formatted for display
+type formattedStock struct {
+	Ticker        string `json:"ticker"`
+	Price         string `json:"price"`
+	OptInPrice    string `json:"optInPrice"`
+	Dividend      string `json:"dividend"`
+	DividendYield string `json:"dividendYield"`
+}
+
+//ErrForbiddenAddress is returned for webhook hosts on private, loopback or link-local networks
+var ErrForbiddenAddress = errors.New("address is not public")
+
+var privateNetworks = parseNetworks("10.0.0.0/8", "172.16.0.0/12", "192.168.0.0/16", "100.64.0.0/10", "fc00::/7")
+
+func parseNetworks(cidrs ...string) []*net.IPNet {
+	var result []*net.IPNet
+
+	for _, cidr := range cidrs {
+		_, network, err := net.ParseCIDR(cidr)
+		if err != nil {
+			panic(err)
+		}
+		result = append(result, network)
+	}
+
+	return result
+}
+
+//publicIP returns whether the ip is routable on the internet, webhooks are not posted to internal services
+func publicIP(ip net.IP) bool {
+	if ip.IsLoopback() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() || ip.IsUnspecified() || ip.IsMulticast() {
+		return false
+	}
+
+	for _, network := range privateNetworks {
+		if network.Contains(ip) {
+			return false
+		}
+	}
+
+	return true
+}
+
+//rejectPrivateAddress checks the resolved address right before connecting, so a host can not
+//be pointed to an internal address after the channel was validated
+func rejectPrivateAddress(network, address string, c syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+
+	if err != nil {
+		return err
+	}
+
+	ip := net.ParseIP(host)
+	if ip == nil || !publicIP(ip) {
+		return fmt.Errorf("Refusing to connect to [%s]: %w", host, ErrForbiddenAddress)
+	}
+
+	return nil
+}
+
+//newWebhookClient returns a client that only connects to public addresses
+func newWebhookClient() *http.Client {
+	dialer := &net.Dialer{Timeout: webhookTimeout, Control: rejectPrivateAddress}
+
+	return &http.Client{
+		Timeout:   webhookTimeout,
+		Transport: &http.Transport{DialContext: dialer.DialContext, TLSHandshakeTimeout: webhookTimeout},
+	}
+}
+
+type chatPayload struct {
+	Text string `json:"text"`
+}
+
+//Webhook posts the notifications as JSON signed with the secret of the channel
+type Webhook struct {
+	client  *http.Client
+	backoff time.Duration
+}
+
+func NewWebhook() *Webhook {
+	return &Webhook{
+		client:  newWebhookClient(),
+		backoff: time.Second,
+	}
+}
+
+func (wh *Webhook) Send(channel *model.NotificationChannel, notification *model.Notification, message *model.RenderedMessage) error {
+	now := time.Now().UTC()
+	locale := LocaleOf(notification.Locale)
+
+	body, err := json.Marshal(webhookPayload{
+		Event:        "watchlist." + notification.Kind,
+		Subject:      message.Subject,
+		Text:         message.Text,
+		Locale:       locale.Tag,
+		SentAt:       now,
+		Notification: notification,
+		Formatted:    formatStocks(locale, notification),
+	})
+
+	if err != nil {
+		return fmt.Errorf("Failed to serialize webhook payload: [%v]", err)
+	}
+
+	timestamp := strconv.FormatInt(now.Unix(), 10)
+
+	headers := map[string]string{
+		WebhookTimestampHeader: timestamp,
+		WebhookSignatureHeader: "sha256=" + SignWebhook(channel.Secret, timestamp, body),
+	}
+
+	return postWithRetry(wh.client, wh.backoff, channel.Address, body, headers)
+}
+
+//formatStocks formats the stocks of the notification and of the changes of a digest
+func formatStocks(locale *Locale, notification *model.Notification) []formattedStock {
+	var result []formattedStock
+
+	stocks := append([]model.CalculatedStockInfo{}, notification.Stocks...)
+	for _, change := range notification.Changes {
+		stocks = append(stocks, change.Stocks...)
+	}
+
+	var tickers []string
+	for _, stock := range stocks {
+		if contains(tickers, stock.Ticker) {
+			continue
+		}
+		tickers = append(tickers, stock.Ticker)
+
+		result = append(result, formattedStock{
+			Ticker:        stock.Ticker,
+			Price:         locale.Money(stock.Price),
+			OptInPrice:    locale.Money(stock.OptInPrice),
+			Dividend:      locale.Money(stock.AnnualDividend),
+			DividendYield: locale.Percent(stock.DividendYield),
+		})
+	}
+
+	return result
+}
+
+//SignWebhook returns the signature of the webhook body sent at timestamp
+func SignWebhook(secret string, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+//ChatWebhook posts the notifications as text to chat incoming webhooks
+type ChatWebhook struct {
+	client  *http.Client
+	backoff time.Duration
+}
+
+func NewChatWebhook() *ChatWebhook {
+	return &ChatWebhook{
+		client:  newWebhookClient(),
+		backoff: time.Second,
+	}
+}
+
+func (cw *ChatWebhook) Send(channel *model.NotificationChannel, notification *model.Notification, message *model.RenderedMessage) error {
+	body, err := json.Marshal(chatPayload{Text: "*" + message.Subject + "*\n" + message.Text})
+
+	if err != nil {
+		return fmt.Errorf("Failed to serialize chat payload: [%v]", err)
+	}
+
+	return postWithRetry(cw.client, cw.backoff, channel.Address, body, nil)
+}
+
+//postWithRetry posts the body, retrying with exponential backoff on network errors, 429 and 5xx responses.
+//Connections refused to non-public addresses are not retried
+func postWithRetry(client *http.Client, backoff time.Duration, url string, body []byte, headers map[string]string) error {
+	var lastErr error
+
+	for attempt := 0; attempt < webhookMaxAttempts; attempt++ {
+		if attempt > 0 {
+			time.Sleep(backoff * time.Duration(1<<uint(attempt-1)))
+		}
+
+		req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(body))
+
+		if err != nil {
+			return fmt.Errorf("Failed to create webhook request: [%v]", err)
+		}
+
+		req.Header.Set("Content-Type", "application/json")
+		for key, value := range headers {
+			req.Header.Set(key, value)
+		}
+
+		resp, err := client.Do(req)
+
+		if errors.Is(err, ErrForbiddenAddress) {
+			return fmt.Errorf("Failed to post webhook: %w", err)
+		}
+
+		if err != nil {
+			lastErr = fmt.Errorf("Failed to post webhook: [%v]", err)
+			continue
+		}
+
+		resp.Body.Close()
+
+		if resp.StatusCode < 300 {
+			return nil
+		}
+
+		lastErr = fmt.Errorf("Failed to post webhook, status code [%d]", resp.StatusCode)
+
+		if resp.StatusCode != http.StatusTooManyRequests && resp.StatusCode < 500 {
+			return lastErr
+		}
+	}
+
+	return lastErr
+}